@@ -16,8 +16,8 @@ type AfData struct {
 	NumTransID uint64
 	Subs       map[string]*AfSubscription
 	PfdTrans   map[string]*AfPfdTransaction
-	Mu         sync.RWMutex
-	Log        *logrus.Entry
+	Mu         sync.RWMutex  `json:"-"`
+	Log        *logrus.Entry `json:"-"`
 }
 
 func (a *AfData) NewSub(numCorreID uint64, tiSub *models.NefTrafficInfluSub) *AfSubscription {
@@ -35,9 +35,11 @@ func (a *AfData) NewSub(numCorreID uint64, tiSub *models.NefTrafficInfluSub) *Af
 func (a *AfData) NewPfdTrans() *AfPfdTransaction {
 	a.NumTransID++
 	pfdTr := AfPfdTransaction{
-		TransID:   strconv.FormatUint(a.NumTransID, 10),
-		ExtAppIDs: make(map[string]struct{}),
-		Log:       a.Log.WithField(logger.FieldPfdTransID, fmt.Sprintf("PFDT:%d", a.NumTransID)),
+		TransID:       strconv.FormatUint(a.NumTransID, 10),
+		ExtAppIDs:     make(map[string]struct{}),
+		AllowedDelays: make(map[string]int32),
+		CachingTimes:  make(map[string]int32),
+		Log:           a.Log.WithField(logger.FieldPfdTransID, fmt.Sprintf("PFDT:%d", a.NumTransID)),
 	}
 	pfdTr.Log.Infoln("New pfd transcation")
 	return &pfdTr
@@ -1,13 +1,19 @@
 package context
 
 import (
+	"maps"
+	"time"
+
 	"github.com/sirupsen/logrus"
 )
 
 type AfPfdTransaction struct {
-	TransID   string
-	ExtAppIDs map[string]struct{}
-	Log       *logrus.Entry
+	TransID       string
+	ExtAppIDs     map[string]struct{}
+	AllowedDelays map[string]int32 // allowedDelay (in seconds) of each appID requested by AF
+	CachingTimes  map[string]int32 // cachingTime (in seconds) of each appID, requested by AF or configured
+	Expiry        *time.Time       // granted expiry, nil means the transaction never expires
+	Log           *logrus.Entry    `json:"-"`
 }
 
 func (a *AfPfdTransaction) GetExtAppIDs() []string {
@@ -23,11 +29,41 @@ func (a *AfPfdTransaction) AddExtAppID(appID string) {
 	a.Log.Infof("appID[%s] is added", appID)
 }
 
+func (a *AfPfdTransaction) SetAllowedDelay(appID string, allowedDelay int32) {
+	if allowedDelay == 0 {
+		delete(a.AllowedDelays, appID)
+		return
+	}
+	a.AllowedDelays[appID] = allowedDelay
+}
+
+// SetCachingTime keeps the caching time as a duration, UDR only holds the time when the caching expires
+func (a *AfPfdTransaction) SetCachingTime(appID string, cachingTime int32) {
+	if cachingTime == 0 {
+		delete(a.CachingTimes, appID)
+		return
+	}
+	a.CachingTimes[appID] = cachingTime
+}
+
 func (a *AfPfdTransaction) DeleteExtAppID(appID string) {
 	delete(a.ExtAppIDs, appID)
+	delete(a.AllowedDelays, appID)
+	delete(a.CachingTimes, appID)
 	a.Log.Infof("appID[%s] is deleted", appID)
 }
 
 func (a *AfPfdTransaction) DeleteAllExtAppIDs() {
 	a.ExtAppIDs = make(map[string]struct{})
+	a.AllowedDelays = make(map[string]int32)
+	a.CachingTimes = make(map[string]int32)
+}
+
+// clone copies the transaction, the appIDs of the copy can be changed without the lock of AF
+func (a *AfPfdTransaction) clone() *AfPfdTransaction {
+	c := *a
+	c.ExtAppIDs = maps.Clone(a.ExtAppIDs)
+	c.AllowedDelays = maps.Clone(a.AllowedDelays)
+	c.CachingTimes = maps.Clone(a.CachingTimes)
+	return &c
 }
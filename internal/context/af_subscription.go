@@ -1,17 +1,24 @@
 package context
 
 import (
+	"time"
+
 	"github.com/free5gc/openapi/models"
 	"github.com/sirupsen/logrus"
 )
 
 type AfSubscription struct {
-	SubID        string
-	TiSub        *models.NefTrafficInfluSub
-	AppSessID    string // use in single UE case
-	InfluID      string // use in multiple UE case
-	NotifCorreID string
-	Log          *logrus.Entry
+	SubID             string
+	TiSub             *models.NefTrafficInfluSub
+	AppSessID         string // use in single UE case
+	PcfUri            string // use in single UE case, apiRoot of the PCF bound to the UE
+	Supi              string // use in single UE case, translated from GPSI
+	InfluID           string // use in multiple UE case
+	NotifCorreID      string
+	SmfAckUri         string            // ackUri of the pending SMF notification waiting for AF acknowledgement
+	TempValidityState TempValidityState // ACTIVE when the traffic influence is installed in PCF/UDR
+	Expiry            *time.Time        // granted expiry, nil means the subscription never expires
+	Log               *logrus.Entry     `json:"-"`
 }
 
 func (s *AfSubscription) PatchTiSubData(tiSubPatch *models.NefTrafficInfluSubPatch) {
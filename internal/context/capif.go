@@ -0,0 +1,100 @@
+package context
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/free5gc/nef/internal/logger"
+)
+
+const (
+	DefaultInvocationLogReportInterval = time.Minute
+	// The publication to CAPIF is retried with the backoff doubled from min to max
+	ServiceAPIPublishMinBackoff = time.Second
+	ServiceAPIPublishMaxBackoff = 5 * time.Minute
+)
+
+// InvocationLogReporter reports the invocation logs of the northbound APIs to CAPIF
+type InvocationLogReporter interface {
+	ReportInvocationLogs()
+}
+
+// ServiceAPIPublisher publishes the northbound APIs to CAPIF
+type ServiceAPIPublisher interface {
+	PublishServiceAPIs() error
+}
+
+// RunServiceAPIPublisher publishes the northbound APIs to CAPIF, and retries with exponential backoff
+// until all of them are published or ctx is done. Nothing is run without CAPIF configured.
+func (c *NefContext) RunServiceAPIPublisher(ctx context.Context, wg *sync.WaitGroup, publisher ServiceAPIPublisher) {
+	if c.Config().Capif() == nil {
+		return
+	}
+
+	wg.Add(1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				// Print stack for panic to log. Fatalf() will let program exit.
+				logger.CtxLog.Fatalf("panic: %v\n%s", p, string(debug.Stack()))
+			}
+			wg.Done()
+		}()
+
+		backoff := ServiceAPIPublishMinBackoff
+		for {
+			err := publisher.PublishServiceAPIs()
+			if err == nil {
+				return
+			}
+			logger.CtxLog.Errorf("publish APIs to CAPIF failed, retry in %s: %+v", backoff, err)
+
+			select {
+			case <-ctx.Done():
+				logger.CtxLog.Infoln("Service API publisher is stopped")
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, ServiceAPIPublishMaxBackoff)
+		}
+	}()
+}
+
+// RunInvocationLogReporter reports the API invocation logs periodically until ctx is done,
+// the pending logs are reported once more when it's stopped. Nothing is run without CAPIF configured.
+func (c *NefContext) RunInvocationLogReporter(ctx context.Context, wg *sync.WaitGroup, reporter InvocationLogReporter) {
+	capif := c.Config().Capif()
+	if capif == nil {
+		return
+	}
+	interval := DefaultInvocationLogReportInterval
+	if capif.LogReportInterval > 0 {
+		interval = time.Duration(capif.LogReportInterval) * time.Second
+	}
+
+	wg.Add(1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				// Print stack for panic to log. Fatalf() will let program exit.
+				logger.CtxLog.Fatalf("panic: %v\n%s", p, string(debug.Stack()))
+			}
+			wg.Done()
+		}()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				reporter.ReportInvocationLogs()
+				logger.CtxLog.Infoln("Invocation log reporter is stopped")
+				return
+			case <-ticker.C:
+				reporter.ReportInvocationLogs()
+			}
+		}
+	}()
+}
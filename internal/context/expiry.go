@@ -0,0 +1,184 @@
+package context
+
+import (
+	"context"
+	"maps"
+	"reflect"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/openapi/models"
+)
+
+const ExpiryCheckInterval = time.Second
+
+// ExpiryHandler tears down the resources of the traffic influence subscriptions and
+// PFD management transactions that reach their granted expiry.
+type ExpiryHandler interface {
+	ExpireTrafficInflu(af *AfData, sub *AfSubscription)
+	ExpirePfdTrans(af *AfData, pfdTr *AfPfdTransaction) error
+}
+
+func isExpiredAt(expiry *time.Time, now time.Time) bool {
+	return expiry != nil && !now.Before(*expiry)
+}
+
+// RunExpiryReaper removes the expired subscriptions and transactions periodically until ctx is done.
+func (c *NefContext) RunExpiryReaper(ctx context.Context, wg *sync.WaitGroup, handler ExpiryHandler) {
+	wg.Add(1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				// Print stack for panic to log. Fatalf() will let program exit.
+				logger.CtxLog.Fatalf("panic: %v\n%s", p, string(debug.Stack()))
+			}
+			wg.Done()
+		}()
+
+		ticker := time.NewTicker(ExpiryCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				logger.CtxLog.Infoln("Expiry reaper is stopped")
+				return
+			case now := <-ticker.C:
+				c.ReapExpiredResources(now, handler)
+			}
+		}
+	}()
+}
+
+// expiredSub is an expired subscription. The traffic influence is torn down on a copy of the subscription,
+// so that the lock of AF is not held during the requests to PCF/UDR and the notification to AF.
+type expiredSub struct {
+	sub       *AfSubscription
+	work      *AfSubscription
+	tiSub     models.NefTrafficInfluSub
+	appSessID string
+	influID   string
+}
+
+// expiredPfdTrans is an expired PFD transaction, whose PFDs are deleted from UDR on a copy of it
+type expiredPfdTrans struct {
+	pfdTr     *AfPfdTransaction
+	work      *AfPfdTransaction
+	extAppIDs map[string]struct{}
+	err       error
+}
+
+// ReapExpiredResources removes the subscriptions and transactions which reach their expiry at now.
+// The expired ones are collected under the lock of AF, torn down without it and removed under it again
+// if they are not changed in the meantime.
+func (c *NefContext) ReapExpiredResources(now time.Time, handler ExpiryHandler) {
+	c.mu.RLock()
+	afs := make([]*AfData, 0, len(c.afs))
+	for _, af := range c.afs {
+		afs = append(afs, af)
+	}
+	c.mu.RUnlock()
+
+	for _, af := range afs {
+		subs, pfdTrs := collectExpiredResources(af, now)
+		if len(subs) == 0 && len(pfdTrs) == 0 {
+			continue
+		}
+
+		for _, es := range subs {
+			handler.ExpireTrafficInflu(af, es.work)
+		}
+		for _, ep := range pfdTrs {
+			if ep.err = handler.ExpirePfdTrans(af, ep.work); ep.err != nil {
+				// Retry in next check
+				ep.work.Log.Errorf("Expire PFD transaction failed: %+v", ep.err)
+			}
+		}
+
+		c.removeExpiredResources(af, subs, pfdTrs)
+	}
+}
+
+func collectExpiredResources(af *AfData, now time.Time) ([]*expiredSub, []*expiredPfdTrans) {
+	af.Mu.RLock()
+	defer af.Mu.RUnlock()
+
+	var subs []*expiredSub
+	for _, sub := range af.Subs {
+		if sub.TiSub == nil || !isExpiredAt(sub.Expiry, now) {
+			continue
+		}
+		work := *sub
+		tiSub := *sub.TiSub
+		work.TiSub = &tiSub
+		subs = append(subs, &expiredSub{
+			sub:       sub,
+			work:      &work,
+			tiSub:     tiSub,
+			appSessID: sub.AppSessID,
+			influID:   sub.InfluID,
+		})
+	}
+
+	var pfdTrs []*expiredPfdTrans
+	for _, pfdTr := range af.PfdTrans {
+		if !isExpiredAt(pfdTr.Expiry, now) {
+			continue
+		}
+		pfdTrs = append(pfdTrs, &expiredPfdTrans{
+			pfdTr:     pfdTr,
+			work:      pfdTr.clone(),
+			extAppIDs: maps.Clone(pfdTr.ExtAppIDs),
+		})
+	}
+	return subs, pfdTrs
+}
+
+// removeExpiredResources removes the expired subscriptions and transactions which are torn down and not
+// changed in the meantime, the changed ones are expired again in next check.
+func (c *NefContext) removeExpiredResources(af *AfData, subs []*expiredSub, pfdTrs []*expiredPfdTrans) {
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	changed := false
+	for _, es := range subs {
+		sub, ok := af.Subs[es.work.SubID]
+		if !ok || sub != es.sub {
+			continue
+		}
+		if sub.AppSessID != es.appSessID || sub.InfluID != es.influID || !reflect.DeepEqual(*sub.TiSub, es.tiSub) {
+			sub.Log.Infoln("Subscription is changed during expiry, expire it again in next check")
+			continue
+		}
+		delete(af.Subs, sub.SubID)
+		sub.Log.Infof("Subscription is removed due to expiry[%s]", sub.Expiry.Format(time.RFC3339))
+		changed = true
+	}
+	for _, ep := range pfdTrs {
+		pfdTr, ok := af.PfdTrans[ep.work.TransID]
+		if !ok || pfdTr != ep.pfdTr {
+			continue
+		}
+		if !reflect.DeepEqual(pfdTr.ExtAppIDs, ep.extAppIDs) {
+			pfdTr.Log.Infoln("PFD Management Transaction is changed during expiry, expire it again in next check")
+			continue
+		}
+		changed = true
+		if ep.err != nil {
+			// Keep the appIDs not deleted from UDR
+			for appID := range ep.extAppIDs {
+				if _, ok := ep.work.ExtAppIDs[appID]; !ok {
+					pfdTr.DeleteExtAppID(appID)
+				}
+			}
+			continue
+		}
+		delete(af.PfdTrans, pfdTr.TransID)
+		pfdTr.Log.Infof("PFD Management Transaction is removed due to expiry[%s]",
+			pfdTr.Expiry.Format(time.RFC3339))
+	}
+	if changed {
+		c.SaveAf(af)
+	}
+}
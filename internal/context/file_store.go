@@ -0,0 +1,125 @@
+package context
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	fileStoreAfDir        = "afs"
+	fileStoreCountersFile = "counters.json"
+)
+
+// FileStore stores each AF in a JSON file under the directory, the files are replaced atomically.
+type FileStore struct {
+	dir string
+}
+
+type fileStoreCounters struct {
+	NumCorreID uint64 `json:"numCorreID"`
+}
+
+var _ Store = &FileStore{}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(filepath.Join(dir, fileStoreAfDir), 0o750); err != nil {
+		return nil, fmt.Errorf("create file store[%s] failed: %w", dir, err)
+	}
+	return &FileStore{
+		dir: dir,
+	}, nil
+}
+
+func (s *FileStore) afFile(afID string) string {
+	// AF ID is escaped to be a valid file name
+	return filepath.Join(s.dir, fileStoreAfDir, url.PathEscape(afID)+".json")
+}
+
+func (s *FileStore) SaveAf(af *AfData) error {
+	data, err := json.Marshal(af)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.afFile(af.AfID), data)
+}
+
+func (s *FileStore) DeleteAf(afID string) error {
+	if err := os.Remove(s.afFile(afID)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *FileStore) SaveNumCorreID(numCorreID uint64) error {
+	data, err := json.Marshal(&fileStoreCounters{
+		NumCorreID: numCorreID,
+	})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(s.dir, fileStoreCountersFile), data)
+}
+
+func (s *FileStore) Load() ([]*AfData, uint64, error) {
+	var counters fileStoreCounters
+	data, err := os.ReadFile(filepath.Join(s.dir, fileStoreCountersFile))
+	switch {
+	case err == nil:
+		if err = json.Unmarshal(data, &counters); err != nil {
+			return nil, 0, fmt.Errorf("decode %s failed: %w", fileStoreCountersFile, err)
+		}
+	case !os.IsNotExist(err):
+		return nil, 0, err
+	}
+
+	entries, err := os.ReadDir(filepath.Join(s.dir, fileStoreAfDir))
+	if err != nil {
+		return nil, 0, err
+	}
+	afs := make([]*AfData, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			// e.g. the temporary file left by an interrupted write
+			continue
+		}
+		data, err = os.ReadFile(filepath.Join(s.dir, fileStoreAfDir, entry.Name()))
+		if err != nil {
+			return nil, 0, err
+		}
+		af := &AfData{}
+		if err = json.Unmarshal(data, af); err != nil {
+			return nil, 0, fmt.Errorf("decode %s failed: %w", entry.Name(), err)
+		}
+		afs = append(afs, af)
+	}
+	return afs, counters.NumCorreID, nil
+}
+
+// writeFileAtomic writes to a temporary file and renames it, so that a crash never leaves a partial file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// No effect after the file is renamed
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
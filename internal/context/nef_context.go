@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/free5gc/nef/internal/logger"
 	"github.com/free5gc/nef/pkg/factory"
@@ -12,6 +13,13 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	// DefaultHeartBeatTimer is used if NRF doesn't provide the heartBeatTimer
+	DefaultHeartBeatTimer = 60 * time.Second
+	// CorreIDBatchSize is the number of correlation IDs reserved in the store at a time
+	CorreIDBatchSize = 1000
+)
+
 type nef interface {
 	Config() *factory.Config
 }
@@ -19,12 +27,17 @@ type nef interface {
 type NefContext struct {
 	nef
 
-	nfInstID       string // NF Instance ID
-	pcfPaUri       string
-	udrDrUri       string
+	nfInstID       string                                // NF Instance ID
+	nfInstances    map[NfDiscoveryKey]*nfDiscoveryResult // NF instances discovered from NRF
 	numCorreID     uint64
+	maxCorreID     uint64            // end of the batch of correlation IDs reserved in the store
+	heartBeatTimer int32             // in seconds, provided by NRF
+	load           int32             // in percentage, reported to NRF
+	serviceList    []factory.Service // services registered to NRF, initialized from config and changed by OAM
 	OAuth2Required bool
 	afs            map[string]*AfData
+	intGroupIDs    map[string]string // externalGroupId -> internalGroupId
+	store          Store             // nil if the state is not persisted
 	mu             sync.RWMutex
 }
 
@@ -34,7 +47,21 @@ func NewContext(nef nef) (*NefContext, error) {
 		nfInstID: uuid.New().String(),
 	}
 	c.afs = make(map[string]*AfData)
+	c.intGroupIDs = make(map[string]string)
+	c.nfInstances = make(map[NfDiscoveryKey]*nfDiscoveryResult)
+	c.serviceList = nef.Config().ServiceList()
 	logger.CtxLog.Infof("New nfInstID: [%s]", c.nfInstID)
+
+	if storeCfg := nef.Config().Store(); storeCfg != nil {
+		store, err := newStore(storeCfg)
+		if err != nil {
+			return nil, err
+		}
+		c.store = store
+		if err = c.restore(); err != nil {
+			return nil, fmt.Errorf("restore NEF context failed: %w", err)
+		}
+	}
 	return c, nil
 }
 
@@ -51,30 +78,60 @@ func (c *NefContext) SetNfInstID(id string) {
 	logger.CtxLog.Infof("Set nfInstID: [%s]", c.nfInstID)
 }
 
-func (c *NefContext) PcfPaUri() string {
+func (c *NefContext) HeartBeatTimer() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.heartBeatTimer <= 0 {
+		return DefaultHeartBeatTimer
+	}
+	return time.Duration(c.heartBeatTimer) * time.Second
+}
+
+func (c *NefContext) SetHeartBeatTimer(timer int32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.heartBeatTimer = timer
+	logger.CtxLog.Infof("Set heartBeatTimer: [%d]", c.heartBeatTimer)
+}
+
+func (c *NefContext) Load() int32 {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.pcfPaUri
+	return c.load
 }
 
-func (c *NefContext) SetPcfPaUri(uri string) {
+func (c *NefContext) SetLoad(load int32) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.pcfPaUri = uri
-	logger.CtxLog.Infof("Set pcfPaUri: [%s]", c.pcfPaUri)
+	c.load = load
+	logger.CtxLog.Infof("Set load: [%d]", c.load)
 }
 
-func (c *NefContext) UdrDrUri() string {
+func (c *NefContext) ServiceList() []factory.Service {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.udrDrUri
+	return c.serviceList
 }
 
-func (c *NefContext) SetUdrDrUri(uri string) {
+func (c *NefContext) SetServiceList(serviceList []factory.Service) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.udrDrUri = uri
-	logger.CtxLog.Infof("Set udrDrUri: [%s]", c.udrDrUri)
+	c.serviceList = serviceList
+	logger.CtxLog.Infof("Set serviceList: %+v", c.serviceList)
+}
+
+func (c *NefContext) GetIntGroupID(extGroupID string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	intGroupID, ok := c.intGroupIDs[extGroupID]
+	return intGroupID, ok
+}
+
+func (c *NefContext) SetIntGroupID(extGroupID, intGroupID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.intGroupIDs[extGroupID] = intGroupID
+	logger.CtxLog.Infof("Set internalGroupId[%s] for externalGroupId[%s]", intGroupID, extGroupID)
 }
 
 func (c *NefContext) NewAf(afID string) *AfData {
@@ -87,11 +144,16 @@ func (c *NefContext) NewAf(afID string) *AfData {
 	return af
 }
 
+// AddAf adds the AF and persists it, af.Mu shall not be locked by the caller.
 func (c *NefContext) AddAf(af *AfData) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
 	c.afs[af.AfID] = af
+	c.mu.Unlock()
 	af.Log.Infoln("AF is added")
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+	c.SaveAf(af)
 }
 
 func (c *NefContext) GetAf(afID string) *AfData {
@@ -105,13 +167,27 @@ func (c *NefContext) DeleteAf(afID string) {
 	defer c.mu.Unlock()
 	delete(c.afs, afID)
 	logger.CtxLog.Infof("AF[%s] is deleted", afID)
+	if c.store != nil {
+		if err := c.store.DeleteAf(afID); err != nil {
+			logger.CtxLog.Errorf("Delete AF[%s] from store failed: %+v", afID, err)
+		}
+	}
 }
 
+// NewCorreID allocates a correlation ID. The IDs are reserved from the store in batches of CorreIDBatchSize,
+// so that they're not reused after restart without persisting the counter for every ID.
 func (c *NefContext) NewCorreID() uint64 {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	c.numCorreID++
+	if c.store != nil && c.numCorreID > c.maxCorreID {
+		// The IDs of the batch not allocated before restart are skipped
+		c.maxCorreID = c.numCorreID + CorreIDBatchSize - 1
+		if err := c.store.SaveNumCorreID(c.maxCorreID); err != nil {
+			logger.CtxLog.Errorf("Persist correlation ID failed: %+v", err)
+		}
+	}
 	return c.numCorreID
 }
 
@@ -119,6 +195,7 @@ func (c *NefContext) ResetCorreID() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.numCorreID = 0
+	c.maxCorreID = 0
 }
 
 func (c *NefContext) IsAppIDExisted(appID string) (string, string, bool) {
@@ -0,0 +1,117 @@
+package context
+
+import (
+	"time"
+
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/openapi/models"
+)
+
+// NfInstance is an NF instance discovered from NRF which provides the NF service,
+// Priority/Capacity/Load are the ones of the NF service if NRF provides them, otherwise of the NF profile.
+type NfInstance struct {
+	NfInstID string
+	Uri      string // apiRoot of the NF service
+	Priority int32  // lower value means higher priority
+	Capacity int32
+	Load     int32 // in percentage
+	Locality string
+}
+
+// NfDiscoveryKey is the query of NF discovery, the NF instances discovered are cached with it
+type NfDiscoveryKey struct {
+	SrvName           models.ServiceName
+	Sst               int32 // S-NSSAI is absent if Sst is 0
+	Sd                string
+	Dnn               string
+	Supi              string
+	PreferredLocality string
+}
+
+// nfDiscoveryResult is the NF instances discovered, they're discovered again once expired
+type nfDiscoveryResult struct {
+	nfInstances []*NfInstance
+	expiry      time.Time
+}
+
+// NfInstances returns the NF instances discovered with the key, empty if they're not discovered yet
+// or the validity period of the discovery is over
+func (c *NefContext) NfInstances(key NfDiscoveryKey) []*NfInstance {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	result, ok := c.nfInstances[key]
+	if !ok || !time.Now().Before(result.expiry) {
+		return nil
+	}
+	return result.nfInstances
+}
+
+// NfInstanceUri returns the apiRoot of the NF service provided by the NF instance,
+// empty if the NF instance is not discovered yet
+func (c *NefContext) NfInstanceUri(srvName models.ServiceName, nfInstID string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	now := time.Now()
+	for key, result := range c.nfInstances {
+		if key.SrvName != srvName || !now.Before(result.expiry) {
+			continue
+		}
+		for _, nfInst := range result.nfInstances {
+			if nfInst.NfInstID == nfInstID {
+				return nfInst.Uri
+			}
+		}
+	}
+	return ""
+}
+
+// SetNfInstances caches the NF instances discovered with the key for validity, 0 means they're not cached.
+// The expired results of other keys are removed meanwhile, so that the cache doesn't grow with the keys
+// which are not used again, e.g. the SUPIs.
+func (c *NefContext) SetNfInstances(key NfDiscoveryKey, nfInstances []*NfInstance, validity time.Duration) {
+	if validity <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for k, result := range c.nfInstances {
+		if !now.Before(result.expiry) {
+			delete(c.nfInstances, k)
+		}
+	}
+	c.nfInstances[key] = &nfDiscoveryResult{
+		nfInstances: nfInstances,
+		expiry:      now.Add(validity),
+	}
+	for _, nfInst := range nfInstances {
+		logger.CtxLog.Infof("Set %s URI: [%s] of NF instance[%s] for %+v", key.SrvName, nfInst.Uri, nfInst.NfInstID, key)
+	}
+}
+
+// EvictNfInstance removes the NF instance from the cached ones, the NF services it provided are
+// discovered from NRF again on next use if no other NF instance provides them.
+func (c *NefContext) EvictNfInstance(nfInstID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, result := range c.nfInstances {
+		// A new slice is built, since the old one may be in use by the consumer
+		remained := make([]*NfInstance, 0, len(result.nfInstances))
+		for _, nfInst := range result.nfInstances {
+			if nfInst.NfInstID == nfInstID {
+				logger.CtxLog.Infof("Evict %s URI[%s] of NF instance[%s]", key.SrvName, nfInst.Uri, nfInstID)
+				continue
+			}
+			remained = append(remained, nfInst)
+		}
+		if len(remained) == 0 {
+			delete(c.nfInstances, key)
+		} else {
+			c.nfInstances[key] = &nfDiscoveryResult{
+				nfInstances: remained,
+				expiry:      result.expiry,
+			}
+		}
+	}
+}
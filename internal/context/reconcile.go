@@ -0,0 +1,62 @@
+package context
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/free5gc/nef/internal/logger"
+)
+
+const DefaultReconcileInterval = 10 * time.Minute
+
+// Reconciler compares the AFs with UDR/PCF, and repairs or reports the mismatches.
+type Reconciler interface {
+	Reconcile(now time.Time)
+}
+
+// RunReconciler reconciles once at startup and then periodically until ctx is done.
+func (c *NefContext) RunReconciler(ctx context.Context, wg *sync.WaitGroup, reconciler Reconciler) {
+	interval := DefaultReconcileInterval
+	if cfg := c.Config().Reconciliation(); cfg != nil && cfg.Interval > 0 {
+		interval = time.Duration(cfg.Interval) * time.Second
+	}
+
+	wg.Add(1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				// Print stack for panic to log. Fatalf() will let program exit.
+				logger.CtxLog.Fatalf("panic: %v\n%s", p, string(debug.Stack()))
+			}
+			wg.Done()
+		}()
+
+		reconciler.Reconcile(time.Now())
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				logger.CtxLog.Infoln("Reconciler is stopped")
+				return
+			case now := <-ticker.C:
+				reconciler.Reconcile(now)
+			}
+		}
+	}()
+}
+
+// Afs returns a snapshot of the AFs, the AFs are still shared with NefContext.
+func (c *NefContext) Afs() []*AfData {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	afs := make([]*AfData, 0, len(c.afs))
+	for _, af := range c.afs {
+		afs = append(afs, af)
+	}
+	return afs
+}
@@ -0,0 +1,84 @@
+package context
+
+import (
+	"fmt"
+
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/pkg/factory"
+)
+
+// Store persists the AFs with their subscriptions and transactions, and the counters of NefContext,
+// so that they're restored after NEF restarts.
+type Store interface {
+	// SaveAf stores the AF with its subscriptions and transactions, replacing the stored one.
+	// af.Mu is locked by the caller.
+	SaveAf(af *AfData) error
+	DeleteAf(afID string) error
+	// SaveNumCorreID stores the end of the batch of correlation IDs reserved by NefContext
+	SaveNumCorreID(numCorreID uint64) error
+	// Load returns all stored AFs and the correlation ID counter, the Log of the AFs,
+	// subscriptions and transactions are not restored.
+	Load() ([]*AfData, uint64, error)
+}
+
+func newStore(cfg *factory.Store) (Store, error) {
+	switch cfg.Type {
+	case factory.StoreTypeFile:
+		return NewFileStore(cfg.Path)
+	default:
+		return nil, fmt.Errorf("store type[%s] is not supported", cfg.Type)
+	}
+}
+
+// restore rebuilds the AFs and the correlation ID counter from the store
+func (c *NefContext) restore() error {
+	afs, numCorreID, err := c.store.Load()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The IDs up to the end of the reserved batch may have been allocated
+	c.numCorreID = numCorreID
+	c.maxCorreID = numCorreID
+	for _, af := range afs {
+		af.Log = logger.CtxLog.WithField(logger.FieldAFID, fmt.Sprintf("AF:%s", af.AfID))
+		if af.Subs == nil {
+			af.Subs = make(map[string]*AfSubscription)
+		}
+		if af.PfdTrans == nil {
+			af.PfdTrans = make(map[string]*AfPfdTransaction)
+		}
+		for _, sub := range af.Subs {
+			sub.Log = af.Log.WithField(logger.FieldSubID, fmt.Sprintf("SUB:%s", sub.SubID))
+		}
+		for _, pfdTr := range af.PfdTrans {
+			pfdTr.Log = af.Log.WithField(logger.FieldPfdTransID, fmt.Sprintf("PFDT:%s", pfdTr.TransID))
+			if pfdTr.ExtAppIDs == nil {
+				pfdTr.ExtAppIDs = make(map[string]struct{})
+			}
+			if pfdTr.AllowedDelays == nil {
+				pfdTr.AllowedDelays = make(map[string]int32)
+			}
+			if pfdTr.CachingTimes == nil {
+				pfdTr.CachingTimes = make(map[string]int32)
+			}
+		}
+		c.afs[af.AfID] = af
+		af.Log.Infof("AF is restored with %d subscriptions and %d transactions", len(af.Subs), len(af.PfdTrans))
+	}
+	return nil
+}
+
+// SaveAf persists the AF after its subscriptions or transactions are changed, af.Mu is locked by the caller.
+// The failure is only logged since the AF in NefContext is still valid.
+func (c *NefContext) SaveAf(af *AfData) {
+	if c.store == nil {
+		return
+	}
+	if err := c.store.SaveAf(af); err != nil {
+		af.Log.Errorf("Persist AF failed: %+v", err)
+	}
+}
@@ -0,0 +1,211 @@
+package context
+
+import (
+	"context"
+	"reflect"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/openapi/models"
+)
+
+const TempValidityCheckInterval = time.Second
+
+type TempValidityState int
+
+const (
+	// TempValidityActive is the zero value, i.e. a subscription without tempValidities is always active
+	TempValidityActive TempValidityState = iota
+	TempValidityInactive
+	TempValidityExpired
+)
+
+func (s TempValidityState) String() string {
+	switch s {
+	case TempValidityActive:
+		return "ACTIVE"
+	case TempValidityInactive:
+		return "INACTIVE"
+	case TempValidityExpired:
+		return "EXPIRED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// TempValidityStateAt returns the state of the given tempValidities at the given time,
+// an absent startTime or stopTime means the window is not bounded on that side.
+func TempValidityStateAt(tempVals []models.TemporalValidity, now time.Time) TempValidityState {
+	if len(tempVals) == 0 {
+		return TempValidityActive
+	}
+
+	state := TempValidityExpired
+	for _, tempVal := range tempVals {
+		if tempVal.StopTime != nil && !now.Before(*tempVal.StopTime) {
+			// The window has ended
+			continue
+		}
+		if tempVal.StartTime == nil || !now.Before(*tempVal.StartTime) {
+			return TempValidityActive
+		}
+		// The window has not started yet
+		state = TempValidityInactive
+	}
+	return state
+}
+
+// TempValidityHandler acts on the traffic influence of a subscription
+// when its state of tempValidities changes.
+type TempValidityHandler interface {
+	ActivateTrafficInflu(af *AfData, sub *AfSubscription) error
+	DeactivateTrafficInflu(af *AfData, sub *AfSubscription) error
+	ExpireTrafficInflu(af *AfData, sub *AfSubscription)
+}
+
+// RunTempValidityScheduler checks the tempValidities of all traffic influence subscriptions
+// periodically until ctx is done.
+func (c *NefContext) RunTempValidityScheduler(ctx context.Context, wg *sync.WaitGroup, handler TempValidityHandler) {
+	wg.Add(1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				// Print stack for panic to log. Fatalf() will let program exit.
+				logger.CtxLog.Fatalf("panic: %v\n%s", p, string(debug.Stack()))
+			}
+			wg.Done()
+		}()
+
+		ticker := time.NewTicker(TempValidityCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				logger.CtxLog.Infoln("TempValidity scheduler is stopped")
+				return
+			case now := <-ticker.C:
+				c.CheckTempValidities(now, handler)
+			}
+		}
+	}()
+}
+
+// tempValidityChange is a change of the tempValidities state of a subscription. The traffic influence
+// is (un)installed on a copy of the subscription, so that the lock of AF is not held during the requests
+// to PCF/UDR, and the result is applied to the subscription afterwards.
+type tempValidityChange struct {
+	sub   *AfSubscription
+	work  *AfSubscription
+	state TempValidityState
+	err   error
+}
+
+// CheckTempValidities activates, deactivates or removes the subscriptions whose state of tempValidities
+// changes at now. The changes are collected under the lock of AF, the requests to PCF/UDR are sent without
+// it and the results are applied under it again.
+func (c *NefContext) CheckTempValidities(now time.Time, handler TempValidityHandler) {
+	c.mu.RLock()
+	afs := make([]*AfData, 0, len(c.afs))
+	for _, af := range c.afs {
+		afs = append(afs, af)
+	}
+	c.mu.RUnlock()
+
+	for _, af := range afs {
+		changes := collectTempValidityChanges(af, now)
+		if len(changes) == 0 {
+			continue
+		}
+
+		for _, chg := range changes {
+			chg.work.Log.Infof("TempValidity state: %s -> %s", chg.work.TempValidityState, chg.state)
+			switch chg.state {
+			case TempValidityActive:
+				if chg.err = handler.ActivateTrafficInflu(af, chg.work); chg.err != nil {
+					// Retry in next check
+					chg.work.Log.Errorf("Activate traffic influence failed: %+v", chg.err)
+				}
+			case TempValidityInactive:
+				if chg.err = handler.DeactivateTrafficInflu(af, chg.work); chg.err != nil {
+					chg.work.Log.Errorf("Deactivate traffic influence failed: %+v", chg.err)
+				}
+			case TempValidityExpired:
+				handler.ExpireTrafficInflu(af, chg.work)
+			}
+		}
+
+		for _, orphan := range c.applyTempValidityChanges(af, changes) {
+			// The traffic influence is activated while the subscription is removed or replaced
+			orphan.Log.Infoln("Subscription is changed during activation, deactivate the stale traffic influence")
+			if err := handler.DeactivateTrafficInflu(af, orphan); err != nil {
+				orphan.Log.Errorf("Deactivate stale traffic influence failed: %+v", err)
+			}
+		}
+	}
+}
+
+func collectTempValidityChanges(af *AfData, now time.Time) []*tempValidityChange {
+	af.Mu.RLock()
+	defer af.Mu.RUnlock()
+
+	var changes []*tempValidityChange
+	for _, sub := range af.Subs {
+		if sub.TiSub == nil {
+			continue
+		}
+		state := TempValidityStateAt(sub.TiSub.TempValidities, now)
+		if state == sub.TempValidityState {
+			continue
+		}
+		work := *sub
+		tiSub := *sub.TiSub
+		work.TiSub = &tiSub
+		changes = append(changes, &tempValidityChange{
+			sub:   sub,
+			work:  &work,
+			state: state,
+		})
+	}
+	return changes
+}
+
+// applyTempValidityChanges updates the subscriptions by the succeeded changes, and returns the copies of
+// the subscriptions which are activated but removed or replaced in the meantime
+func (c *NefContext) applyTempValidityChanges(af *AfData, changes []*tempValidityChange) []*AfSubscription {
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	var orphans []*AfSubscription
+	changed := false
+	for _, chg := range changes {
+		if chg.err != nil {
+			continue
+		}
+		sub, ok := af.Subs[chg.work.SubID]
+		if !ok || sub != chg.sub ||
+			(chg.state == TempValidityActive && !reflect.DeepEqual(sub.TiSub, chg.work.TiSub)) {
+			// Activated again in next check if the subscription is still there
+			if chg.state == TempValidityActive {
+				orphans = append(orphans, chg.work)
+			}
+			continue
+		}
+
+		sub.AppSessID = chg.work.AppSessID
+		sub.PcfUri = chg.work.PcfUri
+		sub.Supi = chg.work.Supi
+		sub.InfluID = chg.work.InfluID
+		sub.TempValidityState = chg.state
+		if chg.state == TempValidityExpired {
+			delete(af.Subs, sub.SubID)
+			sub.Log.Infoln("Subscription is removed due to expiry of tempValidities")
+		}
+		changed = true
+	}
+	if changed {
+		c.SaveAf(af)
+	}
+	return orphans
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/sbi/notifier"
 	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
 	"github.com/gin-gonic/gin"
@@ -16,6 +17,16 @@ func (s *Server) getCallbackRoutes() []Route {
 			Pattern: "/notification/smf",
 			APIFunc: s.apiPostSmfNotification,
 		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/notification/af-ack/:notifCorreID",
+			APIFunc: s.apiPostAfAckNotification,
+		},
+		{
+			Method:  http.MethodPost,
+			Pattern: "/notification/nf-status",
+			APIFunc: s.apiPostNfStatusNotification,
+		},
 	}
 }
 
@@ -39,3 +50,45 @@ func (s *Server) apiPostSmfNotification(gc *gin.Context) {
 
 	s.Processor().SmfNotification(gc, &eeNotif)
 }
+
+func (s *Server) apiPostAfAckNotification(gc *gin.Context) {
+	var afAckInfo notifier.AfAckInfo
+	reqBody, err := gc.GetRawData()
+	if err != nil {
+		logger.SBILog.Errorf("Get Request Body error: %+v", err)
+		gc.JSON(http.StatusInternalServerError,
+			openapi.ProblemDetailsSystemFailure(err.Error()))
+		return
+	}
+
+	err = openapi.Deserialize(&afAckInfo, reqBody, "application/json")
+	if err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		gc.JSON(http.StatusBadRequest,
+			openapi.ProblemDetailsMalformedReqSyntax(err.Error()))
+		return
+	}
+
+	s.Processor().AfAckNotification(gc, gc.Param("notifCorreID"), &afAckInfo)
+}
+
+func (s *Server) apiPostNfStatusNotification(gc *gin.Context) {
+	var notif models.NrfNfManagementNotificationData
+	reqBody, err := gc.GetRawData()
+	if err != nil {
+		logger.SBILog.Errorf("Get Request Body error: %+v", err)
+		gc.JSON(http.StatusInternalServerError,
+			openapi.ProblemDetailsSystemFailure(err.Error()))
+		return
+	}
+
+	err = openapi.Deserialize(&notif, reqBody, "application/json")
+	if err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		gc.JSON(http.StatusBadRequest,
+			openapi.ProblemDetailsMalformedReqSyntax(err.Error()))
+		return
+	}
+
+	s.Processor().NfStatusNotification(gc, &notif)
+}
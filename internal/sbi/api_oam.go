@@ -3,6 +3,9 @@ package sbi
 import (
 	"net/http"
 
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/sbi/processor"
+	"github.com/free5gc/openapi"
 	"github.com/gin-gonic/gin"
 )
 
@@ -13,9 +16,44 @@ func (s *Server) getOamRoutes() []Route {
 			Pattern: "/",
 			APIFunc: s.apiGetOamIndex,
 		},
+		{
+			Method:  http.MethodGet,
+			Pattern: "/reconciliation",
+			APIFunc: s.apiGetReconcileReport,
+		},
+		{
+			Method:  http.MethodPatch,
+			Pattern: "/nf-profile",
+			APIFunc: s.apiPatchNfProfile,
+		},
 	}
 }
 
 func (s *Server) apiGetOamIndex(gc *gin.Context) {
 	s.Processor().GetOamIndex(gc)
 }
+
+func (s *Server) apiGetReconcileReport(gc *gin.Context) {
+	s.Processor().GetReconcileReport(gc)
+}
+
+func (s *Server) apiPatchNfProfile(gc *gin.Context) {
+	var update processor.NfProfileUpdate
+	reqBody, err := gc.GetRawData()
+	if err != nil {
+		logger.SBILog.Errorf("Get Request Body error: %+v", err)
+		gc.JSON(http.StatusInternalServerError,
+			openapi.ProblemDetailsSystemFailure(err.Error()))
+		return
+	}
+
+	err = openapi.Deserialize(&update, reqBody, "application/json")
+	if err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		gc.JSON(http.StatusBadRequest,
+			openapi.ProblemDetailsMalformedReqSyntax(err.Error()))
+		return
+	}
+
+	s.Processor().UpdateNfProfile(gc, &update)
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/sbi/processor"
 	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
 	"github.com/gin-gonic/gin"
@@ -12,60 +13,78 @@ import (
 func (s *Server) getPFDManagementRoutes() []Route {
 	return []Route{
 		{
-			Method:  http.MethodGet,
-			Pattern: "/:scsAsID/transactions",
-			APIFunc: s.apiGetPFDManagementTransactions,
+			Method:       http.MethodGet,
+			Pattern:      "/:scsAsID/transactions",
+			ResourceName: "PFD_MANAGEMENT_TRANSACTIONS",
+			APIFunc:      s.apiGetPFDManagementTransactions,
 		},
 		{
-			Method:  http.MethodPost,
-			Pattern: "/:scsAsID/transactions",
-			APIFunc: s.apiPostPFDManagementTransactions,
+			Method:       http.MethodPost,
+			Pattern:      "/:scsAsID/transactions",
+			ResourceName: "PFD_MANAGEMENT_TRANSACTIONS",
+			APIFunc:      s.apiPostPFDManagementTransactions,
 		},
 		{
-			Method:  http.MethodDelete,
-			Pattern: "/:scsAsID/transactions",
-			APIFunc: s.apiDeletePFDManagementTransactions,
+			Method:       http.MethodDelete,
+			Pattern:      "/:scsAsID/transactions",
+			ResourceName: "PFD_MANAGEMENT_TRANSACTIONS",
+			APIFunc:      s.apiDeletePFDManagementTransactions,
 		},
 		{
-			Method:  http.MethodGet,
-			Pattern: "/:scsAsID/transactions/:transID",
-			APIFunc: s.apiGetIndividualPFDManagementTransaction,
+			Method:       http.MethodGet,
+			Pattern:      "/:scsAsID/transactions/:transID",
+			ResourceName: "INDIVIDUAL_PFD_MANAGEMENT_TRANSACTION",
+			APIFunc:      s.apiGetIndividualPFDManagementTransaction,
 		},
 		{
-			Method:  http.MethodPut,
-			Pattern: "/:scsAsID/transactions/:transID",
-			APIFunc: s.apiPutIndividualPFDManagementTransaction,
+			Method:       http.MethodPut,
+			Pattern:      "/:scsAsID/transactions/:transID",
+			ResourceName: "INDIVIDUAL_PFD_MANAGEMENT_TRANSACTION",
+			APIFunc:      s.apiPutIndividualPFDManagementTransaction,
 		},
 		{
-			Method:  http.MethodDelete,
-			Pattern: "/:scsAsID/transactions/:transID",
-			APIFunc: s.apiDeleteIndividualPFDManagementTransaction,
+			Method:       http.MethodDelete,
+			Pattern:      "/:scsAsID/transactions/:transID",
+			ResourceName: "INDIVIDUAL_PFD_MANAGEMENT_TRANSACTION",
+			APIFunc:      s.apiDeleteIndividualPFDManagementTransaction,
 		},
 		{
-			Method:  http.MethodGet,
-			Pattern: "/:scsAsID/transactions/:transID/applications/:appID",
-			APIFunc: s.apiGetIndividualApplicationPFDManagement,
+			Method:       http.MethodGet,
+			Pattern:      "/:scsAsID/transactions/:transID/applications/:appID",
+			ResourceName: "INDIVIDUAL_APPLICATION_PFD_MANAGEMENT",
+			APIFunc:      s.apiGetIndividualApplicationPFDManagement,
 		},
 		{
-			Method:  http.MethodDelete,
-			Pattern: "/:scsAsID/transactions/:transID/applications/:appID",
-			APIFunc: s.apiDeleteIndividualApplicationPFDManagement,
+			Method:       http.MethodDelete,
+			Pattern:      "/:scsAsID/transactions/:transID/applications/:appID",
+			ResourceName: "INDIVIDUAL_APPLICATION_PFD_MANAGEMENT",
+			APIFunc:      s.apiDeleteIndividualApplicationPFDManagement,
 		},
 		{
-			Method:  http.MethodPut,
-			Pattern: "/:scsAsID/transactions/:transID/applications/:appID",
-			APIFunc: s.apiPutIndividualApplicationPFDManagement,
+			Method:       http.MethodPut,
+			Pattern:      "/:scsAsID/transactions/:transID/applications/:appID",
+			ResourceName: "INDIVIDUAL_APPLICATION_PFD_MANAGEMENT",
+			APIFunc:      s.apiPutIndividualApplicationPFDManagement,
 		},
 		{
-			Method:  http.MethodPatch,
-			Pattern: "/:scsAsID/transactions/:transID/applications/:appID",
-			APIFunc: s.apiPatchIndividualApplicationPFDManagement,
+			Method:       http.MethodPatch,
+			Pattern:      "/:scsAsID/transactions/:transID/applications/:appID",
+			ResourceName: "INDIVIDUAL_APPLICATION_PFD_MANAGEMENT",
+			APIFunc:      s.apiPatchIndividualApplicationPFDManagement,
 		},
 	}
 }
 
 func (s *Server) apiGetPFDManagementTransactions(gc *gin.Context) {
-	s.Processor().GetPFDManagementTransactions(gc, gc.Param("scsAsID"))
+	pg, err := parsePage(gc)
+	if err != nil {
+		logger.SBILog.Errorf("Parse Query Parameters error: %+v", err)
+		gc.JSON(http.StatusBadRequest,
+			openapi.ProblemDetailsMalformedReqSyntax(err.Error()))
+		return
+	}
+
+	s.Processor().GetPFDManagementTransactions(gc, gc.Param("scsAsID"), pg)
 }
 
 func (s *Server) apiPostPFDManagementTransactions(gc *gin.Context) {
@@ -86,7 +105,17 @@ func (s *Server) apiPostPFDManagementTransactions(gc *gin.Context) {
 		return
 	}
 
-	s.Processor().PostPFDManagementTransactions(gc, gc.Param("scsAsID"), &pfdMng)
+	// The expiry requested by AF is carried as a vendor-specific attribute of the resource
+	var resExpiry processor.VendorSpecificExpiry
+	err = openapi.Deserialize(&resExpiry, reqBody, "application/json")
+	if err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		gc.JSON(http.StatusBadRequest,
+			openapi.ProblemDetailsMalformedReqSyntax(err.Error()))
+		return
+	}
+
+	s.Processor().PostPFDManagementTransactions(gc, gc.Param("scsAsID"), &pfdMng, resExpiry.RequestedExpiry())
 }
 
 func (s *Server) apiDeletePFDManagementTransactions(gc *gin.Context) {
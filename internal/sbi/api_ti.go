@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/sbi/processor"
 	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
 	"github.com/gin-gonic/gin"
@@ -12,41 +13,71 @@ import (
 func (s *Server) getTrafficInfluenceRoutes() []Route {
 	return []Route{
 		{
-			Method:  http.MethodGet,
-			Pattern: "/:afID/subscriptions",
-			APIFunc: s.apiGetTrafficInfluenceSubscription,
+			Method:       http.MethodGet,
+			Pattern:      "/:afID/subscriptions",
+			ResourceName: "TRAFFIC_INFLUENCE_SUBSCRIPTIONS",
+			APIFunc:      s.apiGetTrafficInfluenceSubscription,
 		},
 		{
-			Method:  http.MethodPost,
-			Pattern: "/:afID/subscriptions",
-			APIFunc: s.apiPostTrafficInfluenceSubscription,
+			Method:       http.MethodPost,
+			Pattern:      "/:afID/subscriptions",
+			ResourceName: "TRAFFIC_INFLUENCE_SUBSCRIPTIONS",
+			APIFunc:      s.apiPostTrafficInfluenceSubscription,
 		},
 		{
-			Method:  http.MethodGet,
-			Pattern: "/:afID/subscriptions/:subID",
-			APIFunc: s.apiGetIndividualTrafficInfluenceSubscription,
+			Method:       http.MethodGet,
+			Pattern:      "/:afID/subscriptions/:subID",
+			ResourceName: "INDIVIDUAL_TRAFFIC_INFLUENCE_SUBSCRIPTION",
+			APIFunc:      s.apiGetIndividualTrafficInfluenceSubscription,
 		},
 		{
-			Method:  http.MethodPut,
-			Pattern: "/:afID/subscriptions/:subID",
-			APIFunc: s.apiPutIndividualTrafficInfluenceSubscription,
+			Method:       http.MethodPut,
+			Pattern:      "/:afID/subscriptions/:subID",
+			ResourceName: "INDIVIDUAL_TRAFFIC_INFLUENCE_SUBSCRIPTION",
+			APIFunc:      s.apiPutIndividualTrafficInfluenceSubscription,
 		},
 		{
-			Method:  http.MethodPatch,
-			Pattern: "/:afID/subscriptions/:subID",
-			APIFunc: s.apiPatchIndividualTrafficInfluenceSubscription,
+			Method:       http.MethodPatch,
+			Pattern:      "/:afID/subscriptions/:subID",
+			ResourceName: "INDIVIDUAL_TRAFFIC_INFLUENCE_SUBSCRIPTION",
+			APIFunc:      s.apiPatchIndividualTrafficInfluenceSubscription,
 		},
 		{
-			Method:  http.MethodDelete,
-			Pattern: "/:afID/subscriptions/:subID",
-			APIFunc: s.apiDeleteIndividualTrafficInfluenceSubscription,
+			Method:       http.MethodDelete,
+			Pattern:      "/:afID/subscriptions/:subID",
+			ResourceName: "INDIVIDUAL_TRAFFIC_INFLUENCE_SUBSCRIPTION",
+			APIFunc:      s.apiDeleteIndividualTrafficInfluenceSubscription,
 		},
 	}
 }
 
 func (s *Server) apiGetTrafficInfluenceSubscription(gc *gin.Context) {
+	filter := &processor.TrafficInfluSubFilter{
+		Dnn:      gc.Query("dnn"),
+		AfAppId:  gc.Query("af-app-id"),
+		Gpsi:     gc.Query("gpsi"),
+		Ipv4Addr: gc.Query("ipv4-addr"),
+	}
+	if snssai := gc.Query("snssai"); snssai != "" {
+		filter.Snssai = new(models.Snssai)
+		if err := openapi.Deserialize(filter.Snssai, []byte(snssai), "application/json"); err != nil {
+			logger.SBILog.Errorf("Deserialize snssai error: %+v", err)
+			gc.JSON(http.StatusBadRequest,
+				openapi.ProblemDetailsMalformedReqSyntax(err.Error()))
+			return
+		}
+	}
+
+	pg, err := parsePage(gc)
+	if err != nil {
+		logger.SBILog.Errorf("Parse Query Parameters error: %+v", err)
+		gc.JSON(http.StatusBadRequest,
+			openapi.ProblemDetailsMalformedReqSyntax(err.Error()))
+		return
+	}
+
 	s.Processor().GetTrafficInfluenceSubscription(
-		gc, gc.Param("afID"))
+		gc, gc.Param("afID"), filter, pg)
 }
 
 func (s *Server) apiPostTrafficInfluenceSubscription(gc *gin.Context) {
@@ -67,8 +98,18 @@ func (s *Server) apiPostTrafficInfluenceSubscription(gc *gin.Context) {
 		return
 	}
 
+	// The expiry requested by AF is carried as a vendor-specific attribute of the resource
+	var resExpiry processor.VendorSpecificExpiry
+	err = openapi.Deserialize(&resExpiry, reqBody, "application/json")
+	if err != nil {
+		logger.SBILog.Errorf("Deserialize Request Body error: %+v", err)
+		gc.JSON(http.StatusBadRequest,
+			openapi.ProblemDetailsMalformedReqSyntax(err.Error()))
+		return
+	}
+
 	s.Processor().PostTrafficInfluenceSubscription(
-		gc, gc.Param("afID"), &tiSub)
+		gc, gc.Param("afID"), &tiSub, resExpiry.RequestedExpiry())
 }
 
 func (s *Server) apiGetIndividualTrafficInfluenceSubscription(gc *gin.Context) {
@@ -0,0 +1,206 @@
+package sbi
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi/models"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// accessTokenClaims is the claims of the access token issued to AF (RFC 9068)
+type accessTokenClaims struct {
+	jwt.RegisteredClaims
+	Scope string `json:"scope"`
+}
+
+func (c *accessTokenClaims) hasScope(scope string) bool {
+	for _, s := range strings.Fields(c.Scope) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenValidator validates the JWT access tokens of AFs with the configured public keys of the issuers
+type tokenValidator struct {
+	keys   jwt.VerificationKeySet
+	parser *jwt.Parser
+}
+
+func newTokenValidator(cfg *factory.NorthboundOAuth2) (*tokenValidator, error) {
+	v := &tokenValidator{}
+	for _, path := range cfg.PublicKeys {
+		key, err := loadPublicKey(path)
+		if err != nil {
+			return nil, err
+		}
+		v.keys.Keys = append(v.keys.Keys, key)
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithAudience(cfg.Audience),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	v.parser = jwt.NewParser(opts...)
+	return v, nil
+}
+
+// loadPublicKey loads an RSA, ECDSA or Ed25519 public key, or the public key of a certificate, from a PEM file
+func loadPublicKey(path string) (jwt.VerificationKey, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read public key[%s] failed: %w", path, err)
+	}
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(pem); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseECPublicKeyFromPEM(pem); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseEdPublicKeyFromPEM(pem); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("public key[%s] is not an RSA, ECDSA or Ed25519 key in PEM", path)
+}
+
+func (v *tokenValidator) validate(tokenString string) (*accessTokenClaims, error) {
+	claims := &accessTokenClaims{}
+	_, err := v.parser.ParseWithClaims(tokenString, claims, func(*jwt.Token) (interface{}, error) {
+		return v.keys, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// oauth2Authentication validates the bearer access token of the request before the Processor is called,
+// the subject of the token is bound to the AF identified by the path parameter afIDParam.
+// An empty afIDParam means the subject is not bound, e.g. for OAM.
+// A nil validator means the requests are not authenticated.
+func oauth2Authentication(v *tokenValidator, serviceName, afIDParam string) gin.HandlerFunc {
+	return func(gc *gin.Context) {
+		if v == nil {
+			gc.Next()
+			return
+		}
+
+		auth := gc.GetHeader("Authorization")
+		tokenString, ok := strings.CutPrefix(auth, "Bearer ")
+		if !ok || tokenString == "" {
+			rejectToken(gc, http.StatusUnauthorized, "invalid_request", "Absent of bearer access token")
+			return
+		}
+
+		claims, err := v.validate(tokenString)
+		if err != nil {
+			rejectToken(gc, http.StatusUnauthorized, "invalid_token", "Invalid access token: "+err.Error())
+			return
+		}
+		if !claims.hasScope(serviceName) {
+			rejectToken(gc, http.StatusForbidden, "insufficient_scope",
+				fmt.Sprintf("Scope of access token doesn't include %s", serviceName))
+			return
+		}
+		if afID := gc.Param(afIDParam); afIDParam != "" && claims.Subject != afID {
+			rejectToken(gc, http.StatusForbidden, "invalid_token",
+				fmt.Sprintf("Access token is issued to AF[%s], not AF[%s]", claims.Subject, afID))
+			return
+		}
+		gc.Next()
+	}
+}
+
+// rejectToken responds the error of RFC 6750 clause 3 with ProblemDetails
+func rejectToken(gc *gin.Context, status int, errCode, detail string) {
+	logger.SBILog.Warnln(detail)
+	gc.Header("WWW-Authenticate", fmt.Sprintf("Bearer error=%q", errCode))
+	gc.AbortWithStatusJSON(status, &models.ProblemDetails{
+		Title:  http.StatusText(status),
+		Status: int32(status),
+		Detail: detail,
+	})
+}
+
+// newClientAuthTLSConfig returns the TLS config verifying the client certificates of AFs with the CA file
+func newClientAuthTLSConfig(tlsConfig *tls.Config, clientAuth, clientCA string) (*tls.Config, error) {
+	pem, err := os.ReadFile(clientCA)
+	if err != nil {
+		return nil, fmt.Errorf("read client CA[%s] failed: %w", clientCA, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate in client CA[%s]", clientCA)
+	}
+
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	tlsConfig.ClientCAs = pool
+	switch clientAuth {
+	case factory.TlsClientAuthVerifyIfGiven:
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	case factory.TlsClientAuthRequire:
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("client auth[%s] is not supported", clientAuth)
+	}
+	return tlsConfig, nil
+}
+
+// certAfIdentity maps the field of the client certificate to the AF identity
+func certAfIdentity(cert *x509.Certificate, identityField string) string {
+	switch identityField {
+	case factory.TlsClientIdentityDNS:
+		if len(cert.DNSNames) > 0 {
+			return cert.DNSNames[0]
+		}
+	case factory.TlsClientIdentityURI:
+		if len(cert.URIs) > 0 {
+			return cert.URIs[0].String()
+		}
+	default:
+		return cert.Subject.CommonName
+	}
+	return ""
+}
+
+// certAuthentication binds the AF identity of the verified client certificate to the AF identified by
+// the path parameter afIDParam. The request without a client certificate is left to the token validation.
+// An empty afIDParam means any verified client certificate is accepted, e.g. for OAM.
+// An empty identityField means the client certificates are not verified.
+func certAuthentication(identityField, afIDParam string) gin.HandlerFunc {
+	return func(gc *gin.Context) {
+		if identityField == "" || afIDParam == "" ||
+			gc.Request.TLS == nil || len(gc.Request.TLS.VerifiedChains) == 0 {
+			gc.Next()
+			return
+		}
+
+		cert := gc.Request.TLS.VerifiedChains[0][0]
+		if certAfID, afID := certAfIdentity(cert, identityField), gc.Param(afIDParam); certAfID != afID {
+			detail := fmt.Sprintf("Client certificate is issued to AF[%s], not AF[%s]", certAfID, afID)
+			logger.SBILog.Warnln(detail)
+			gc.AbortWithStatusJSON(http.StatusForbidden, &models.ProblemDetails{
+				Title:  "Forbidden",
+				Status: http.StatusForbidden,
+				Detail: detail,
+			})
+			return
+		}
+		gc.Next()
+	}
+}
@@ -0,0 +1,158 @@
+package sbi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/gin-gonic/gin"
+)
+
+// afRequestScope is the resources a request of AF acts on
+type afRequestScope struct {
+	extAppIDs []string
+	dnn       string
+	snssai    *models.Snssai
+	// allDnns and allSnssais are set if the request acts on any DNN or S-NSSAI, e.g. the traffic influence
+	// created without dnn or snssai, or the PFDs, which apply to all of them.
+	allDnns    bool
+	allSnssais bool
+}
+
+// afRequestScopeFunc gets the scope from the path parameters and the request body.
+// A malformed body is left to the API handler, which responds 400 for it.
+type afRequestScopeFunc func(gc *gin.Context, reqBody []byte) *afRequestScope
+
+// afAuthorization checks the request of the AF identified by the path parameter afIDParam against
+// its AF profile before the Processor is called. A violation is rejected with 403 Forbidden.
+func (s *Server) afAuthorization(serviceName, afIDParam string, scopeFunc afRequestScopeFunc) gin.HandlerFunc {
+	return func(gc *gin.Context) {
+		cfg := s.Config()
+		if !cfg.AfAuthorizationEnabled() {
+			gc.Next()
+			return
+		}
+
+		afID := gc.Param(afIDParam)
+		afProfile, ok := cfg.AfProfile(afID)
+		if !ok {
+			forbidAf(gc, fmt.Sprintf("AF[%s] is not authorized", afID))
+			return
+		}
+		if !afProfile.AllowService(serviceName) {
+			forbidAf(gc, fmt.Sprintf("AF[%s] is not authorized for service[%s]", afID, serviceName))
+			return
+		}
+
+		var reqBody []byte
+		if gc.Request.Body != nil {
+			var err error
+			reqBody, err = gc.GetRawData()
+			if err != nil {
+				logger.SBILog.Errorf("Get Request Body error: %+v", err)
+				gc.AbortWithStatusJSON(http.StatusInternalServerError,
+					openapi.ProblemDetailsSystemFailure(err.Error()))
+				return
+			}
+			// Restore the body for the API handler
+			gc.Request.Body = io.NopCloser(bytes.NewReader(reqBody))
+		}
+
+		scope := scopeFunc(gc, reqBody)
+		for _, appID := range scope.extAppIDs {
+			if !afProfile.AllowExternalAppId(appID) {
+				forbidAf(gc, fmt.Sprintf("AF[%s] is not authorized for externalAppId[%s]", afID, appID))
+				return
+			}
+		}
+		if scope.allDnns && !afProfile.AllowAllDnns() {
+			forbidAf(gc, fmt.Sprintf("AF[%s] is only authorized for dnns%v", afID, afProfile.Dnns))
+			return
+		}
+		if scope.allSnssais && !afProfile.AllowAllSnssais() {
+			forbidAf(gc, fmt.Sprintf("AF[%s] is only authorized for snssais%v", afID, afProfile.Snssais))
+			return
+		}
+		if scope.dnn != "" && !afProfile.AllowDnn(scope.dnn) {
+			forbidAf(gc, fmt.Sprintf("AF[%s] is not authorized for dnn[%s]", afID, scope.dnn))
+			return
+		}
+		if scope.snssai != nil && !afProfile.AllowSnssai(scope.snssai) {
+			forbidAf(gc, fmt.Sprintf("AF[%s] is not authorized for snssai[%d-%s]",
+				afID, scope.snssai.Sst, scope.snssai.Sd))
+			return
+		}
+		gc.Next()
+	}
+}
+
+func forbidAf(gc *gin.Context, detail string) {
+	logger.SBILog.Warnln(detail)
+	gc.AbortWithStatusJSON(http.StatusForbidden, &models.ProblemDetails{
+		Title:  "Forbidden",
+		Status: http.StatusForbidden,
+		Detail: detail,
+	})
+}
+
+// trafficInfluScope gets the scope of the subscription created by POST or replaced by PUT, which applies to
+// any DNN or S-NSSAI if dnn or snssai is absent. PATCH can't change them, but the ones present in its body
+// are checked all the same.
+func trafficInfluScope(gc *gin.Context, reqBody []byte) *afRequestScope {
+	scope := &afRequestScope{}
+	if len(reqBody) == 0 {
+		return scope
+	}
+	var tiSub models.NefTrafficInfluSub
+	if err := json.Unmarshal(reqBody, &tiSub); err != nil {
+		return scope
+	}
+	if tiSub.AfAppId != "" {
+		scope.extAppIDs = append(scope.extAppIDs, tiSub.AfAppId)
+	}
+	scope.dnn = tiSub.Dnn
+	scope.snssai = tiSub.Snssai
+	if method := gc.Request.Method; method == http.MethodPost || method == http.MethodPut {
+		scope.allDnns = tiSub.Dnn == ""
+		scope.allSnssais = tiSub.Snssai == nil
+	}
+	return scope
+}
+
+// pfdMngScope gets the scope of the PFD transaction or application. The PFDs provisioned by POST, PUT and PATCH
+// are not limited to a DNN or S-NSSAI, so they are only allowed for the AF not restricted on them.
+func pfdMngScope(gc *gin.Context, reqBody []byte) *afRequestScope {
+	scope := &afRequestScope{}
+	switch gc.Request.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		scope.allDnns = true
+		scope.allSnssais = true
+	}
+	if appID := gc.Param("appID"); appID != "" {
+		scope.extAppIDs = append(scope.extAppIDs, appID)
+		var pfdData models.PfdData
+		if len(reqBody) > 0 && json.Unmarshal(reqBody, &pfdData) == nil && pfdData.ExternalAppId != "" {
+			scope.extAppIDs = append(scope.extAppIDs, pfdData.ExternalAppId)
+		}
+		return scope
+	}
+
+	var pfdMng models.PfdManagement
+	if len(reqBody) == 0 || json.Unmarshal(reqBody, &pfdMng) != nil {
+		return scope
+	}
+	for appID, pfdData := range pfdMng.PfdDatas {
+		scope.extAppIDs = append(scope.extAppIDs, appID)
+		if pfdData.ExternalAppId != "" && pfdData.ExternalAppId != appID {
+			scope.extAppIDs = append(scope.extAppIDs, pfdData.ExternalAppId)
+		}
+	}
+	sort.Strings(scope.extAppIDs)
+	return scope
+}
@@ -0,0 +1,170 @@
+package sbi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type testNef struct {
+	nef
+	cfg *factory.Config
+}
+
+func (n *testNef) Config() *factory.Config {
+	return n.cfg
+}
+
+func newAfAuthorizationTestServer() *Server {
+	return &Server{
+		nef: &testNef{
+			cfg: &factory.Config{
+				Configuration: &factory.Configuration{
+					AfProfiles: []factory.AfProfile{
+						{
+							AfId:     "af1",
+							Services: []string{factory.ServiceTraffInflu, factory.ServicePfdMng},
+							Dnns:     []string{"internet"},
+							Snssais: []models.Snssai{
+								{Sst: 1, Sd: "010203"},
+							},
+						},
+						{
+							AfId:     "af2",
+							Services: []string{factory.ServiceTraffInflu, factory.ServicePfdMng},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestAfAuthorization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := newAfAuthorizationTestServer()
+	okHandler := func(gc *gin.Context) {
+		gc.Status(http.StatusOK)
+	}
+
+	router := gin.New()
+	tiGroup := router.Group("/3gpp-traffic-influence/v1")
+	tiGroup.Use(s.afAuthorization(factory.ServiceTraffInflu, "afID", trafficInfluScope))
+	tiGroup.POST("/:afID/subscriptions", okHandler)
+	tiGroup.PUT("/:afID/subscriptions/:subID", okHandler)
+	tiGroup.PATCH("/:afID/subscriptions/:subID", okHandler)
+	pfdGroup := router.Group("/3gpp-pfd-management/v1")
+	pfdGroup.Use(s.afAuthorization(factory.ServicePfdMng, "scsAsID", pfdMngScope))
+	pfdGroup.GET("/:scsAsID/transactions", okHandler)
+	pfdGroup.POST("/:scsAsID/transactions", okHandler)
+	pfdGroup.PATCH("/:scsAsID/transactions/:transID/applications/:appID", okHandler)
+
+	testCases := []struct {
+		description    string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{
+			description:    "TC1: Subscription in the allowed dnn and snssai, should be allowed",
+			method:         http.MethodPost,
+			path:           "/3gpp-traffic-influence/v1/af1/subscriptions",
+			body:           `{"dnn":"internet","snssai":{"sst":1,"sd":"010203"}}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description:    "TC2: Subscription without dnn by AF restricted on dnns, should be forbidden",
+			method:         http.MethodPost,
+			path:           "/3gpp-traffic-influence/v1/af1/subscriptions",
+			body:           `{"snssai":{"sst":1,"sd":"010203"}}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "TC3: Subscription without snssai by AF restricted on snssais, should be forbidden",
+			method:         http.MethodPost,
+			path:           "/3gpp-traffic-influence/v1/af1/subscriptions",
+			body:           `{"dnn":"internet"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "TC4: Subscription replaced without dnn by AF restricted on dnns, should be forbidden",
+			method:         http.MethodPut,
+			path:           "/3gpp-traffic-influence/v1/af1/subscriptions/1",
+			body:           `{"snssai":{"sst":1,"sd":"010203"}}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "TC5: Subscription in a disallowed dnn, should be forbidden",
+			method:         http.MethodPost,
+			path:           "/3gpp-traffic-influence/v1/af1/subscriptions",
+			body:           `{"dnn":"ims","snssai":{"sst":1,"sd":"010203"}}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "TC6: Subscription patched without dnn and snssai, should be allowed",
+			method:         http.MethodPatch,
+			path:           "/3gpp-traffic-influence/v1/af1/subscriptions/1",
+			body:           `{"appReloInd":true}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description:    "TC7: Subscription patched with a disallowed dnn, should be forbidden",
+			method:         http.MethodPatch,
+			path:           "/3gpp-traffic-influence/v1/af1/subscriptions/1",
+			body:           `{"dnn":"ims"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "TC8: Subscription without dnn and snssai by unrestricted AF, should be allowed",
+			method:         http.MethodPost,
+			path:           "/3gpp-traffic-influence/v1/af2/subscriptions",
+			body:           `{"afAppId":"app1"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description:    "TC9: PFDs provisioned by AF restricted on dnns and snssais, should be forbidden",
+			method:         http.MethodPost,
+			path:           "/3gpp-pfd-management/v1/af1/transactions",
+			body:           `{"pfdDatas":{"app1":{"externalAppId":"app1"}}}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "TC10: PFDs patched by AF restricted on dnns and snssais, should be forbidden",
+			method:         http.MethodPatch,
+			path:           "/3gpp-pfd-management/v1/af1/transactions/1/applications/app1",
+			body:           `{"externalAppId":"app1"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			description:    "TC11: PFDs read by AF restricted on dnns and snssais, should be allowed",
+			method:         http.MethodGet,
+			path:           "/3gpp-pfd-management/v1/af1/transactions",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			description:    "TC12: PFDs provisioned by unrestricted AF, should be allowed",
+			method:         http.MethodPost,
+			path:           "/3gpp-pfd-management/v1/af2/transactions",
+			body:           `{"pfdDatas":{"app1":{"externalAppId":"app1"}}}`,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			httpRecorder := httptest.NewRecorder()
+
+			router.ServeHTTP(httpRecorder, req)
+			require.Equal(t, tc.expectedStatus, httpRecorder.Code)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package sbi
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// capifInvocationLog records the invocation of the northbound API after it's handled,
+// the logs are reported to CAPIF periodically. The API invoker is identified by the path parameter afIDParam.
+func (s *Server) capifInvocationLog(serviceName, uriPrefix, afIDParam string) gin.HandlerFunc {
+	return func(gc *gin.Context) {
+		if s.Config().Capif() == nil {
+			gc.Next()
+			return
+		}
+
+		invocationTime := time.Now()
+		gc.Next()
+		s.Processor().RecordApiInvocation(gc.Param(afIDParam), serviceName,
+			strings.TrimPrefix(gc.FullPath(), uriPrefix), s.Config().SbiUri()+gc.Request.URL.Path,
+			gc.Request.Method, gc.Writer.Status(), invocationTime)
+	}
+}
+
+// capifAuthorization checks whether the API invoker identified by the path parameter afIDParam
+// is authorized by CAPIF to invoke the northbound API
+func (s *Server) capifAuthorization(serviceName, afIDParam string) gin.HandlerFunc {
+	return func(gc *gin.Context) {
+		if s.Config().Capif() == nil {
+			gc.Next()
+			return
+		}
+
+		if rsp := s.Processor().AuthorizeApiInvoker(gc.Param(afIDParam), serviceName, time.Now()); rsp != nil {
+			gc.AbortWithStatusJSON(rsp.Status, rsp.Body)
+			return
+		}
+		gc.Next()
+	}
+}
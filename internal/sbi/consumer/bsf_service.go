@@ -0,0 +1,153 @@
+package consumer
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/bsf/Management"
+	"github.com/free5gc/openapi/models"
+)
+
+// PcfBindingQuery holds the UE identities used to retrieve the PCF binding from BSF
+type PcfBindingQuery struct {
+	Ipv4Addr   string
+	Ipv6Prefix string
+	MacAddr48  string
+	Supi       string
+	Gpsi       string
+	Dnn        string
+	Snssai     *models.Snssai
+}
+
+type nbsfService struct {
+	consumer *Consumer
+	selector *nfSelector
+
+	mu      sync.RWMutex
+	clients map[string]*Management.APIClient
+}
+
+func (s *nbsfService) getClient(uri string) *Management.APIClient {
+	s.mu.RLock()
+	if client, ok := s.clients[uri]; ok {
+		defer s.mu.RUnlock()
+		return client
+	} else {
+		configuration := Management.NewConfiguration()
+		configuration.SetBasePath(uri)
+		configuration.SetHTTPClient(s.consumer.httpClient)
+		cli := Management.NewAPIClient(configuration)
+
+		s.mu.RUnlock()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.clients[uri] = cli
+		return cli
+	}
+}
+
+func (s *nbsfService) getBsfMgmtUri() (string, error) {
+	return s.selector.selectUri(nil)
+}
+
+// TS 29.521 v17 5.3.2.3.1
+// GetPcfPaUriByBinding returns the Npcf_PolicyAuthorization apiRoot of the PCF
+// which holds the PDU session binding of the UE.
+func (s *nbsfService) GetPcfPaUriByBinding(query *PcfBindingQuery) (int, interface{}, string) {
+	var (
+		err     error
+		rspCode int
+		rspBody interface{}
+		result  *Management.GetPCFBindingsResponse
+	)
+
+	uri, err := s.getBsfMgmtUri()
+	if err != nil {
+		rspCode, rspBody = handleAPIServiceNoResponse(err)
+		return rspCode, rspBody, ""
+	}
+	client := s.getClient(uri)
+
+	ctx, _, err := s.selector.requestCtx(nil)
+	if err != nil {
+		rspCode, rspBody = handleAPIServiceNoResponse(err)
+		return rspCode, rspBody, ""
+	}
+
+	getPcfBindingsReq := &Management.GetPCFBindingsRequest{
+		Snssai: query.Snssai,
+	}
+	if query.Ipv4Addr != "" {
+		getPcfBindingsReq.Ipv4Addr = &query.Ipv4Addr
+	}
+	if query.Ipv6Prefix != "" {
+		getPcfBindingsReq.Ipv6Prefix = &query.Ipv6Prefix
+	}
+	if query.MacAddr48 != "" {
+		getPcfBindingsReq.MacAddr48 = &query.MacAddr48
+	}
+	if query.Supi != "" {
+		getPcfBindingsReq.Supi = &query.Supi
+	}
+	if query.Gpsi != "" {
+		getPcfBindingsReq.Gpsi = &query.Gpsi
+	}
+	if query.Dnn != "" {
+		getPcfBindingsReq.Dnn = &query.Dnn
+	}
+	result, err = client.PCFBindingsCollectionApi.GetPCFBindings(ctx, getPcfBindingsReq)
+
+	if err == nil && result != nil {
+		pcfUri := s.getPcfBindingUri(&result.PcfBinding)
+		if pcfUri == "" {
+			// No binding for the UE
+			return http.StatusNoContent, nil, ""
+		}
+		return http.StatusOK, &result.PcfBinding, pcfUri
+	}
+
+	if err != nil {
+		if apiErr, ok := err.(openapi.GenericOpenAPIError); ok {
+			if pd, ok := apiErr.ErrorModel.(Management.GetPCFBindingsError); ok {
+				rspCode = int(pd.ProblemDetails.Status)
+				rspBody = &pd.ProblemDetails
+				return rspCode, rspBody, ""
+			}
+		}
+	}
+
+	rspCode, rspBody = handleAPIServiceNoResponse(err)
+	return rspCode, rspBody, ""
+}
+
+// getPcfBindingUri derives the PCF apiRoot from the binding. The apiRoot of the PCF instance discovered
+// from NRF is preferred, since the binding doesn't provide the URI scheme of the PCF, otherwise the PCF is
+// assumed to use the same URI scheme as NEF.
+func (s *nbsfService) getPcfBindingUri(binding *models.PcfBinding) string {
+	if binding.PcfId != "" {
+		if uri := s.consumer.Context().NfInstanceUri(
+			models.ServiceName_NPCF_POLICYAUTHORIZATION, binding.PcfId); uri != "" {
+			return uri
+		}
+	}
+
+	scheme := models.UriScheme(s.consumer.Config().SbiScheme())
+	var port int32
+	for _, point := range binding.PcfIpEndPoints {
+		if point.Ipv4Address != "" {
+			return getUriFromIpEndPoint(scheme, point.Ipv4Address, point.Port)
+		}
+		if port == 0 {
+			port = point.Port
+		}
+	}
+	if binding.PcfFqdn != "" {
+		if port != 0 {
+			return string(scheme) + "://" + binding.PcfFqdn + ":" + strconv.Itoa(int(port))
+		}
+		return string(scheme) + "://" + binding.PcfFqdn
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+package consumer
+
+import "time"
+
+// The data types of CAPIF used by API exposing function (TS 29.222 v17),
+// only the attributes used by NEF are defined.
+
+type CapifProtocol string
+
+// TS 29.222 v17 8.2.4.3.6
+const (
+	CapifProtocolHttp11 CapifProtocol = "HTTP_1_1"
+	CapifProtocolHttp2  CapifProtocol = "HTTP_2"
+)
+
+type CapifDataFormat string
+
+// TS 29.222 v17 8.2.4.3.7
+const CapifDataFormatJson CapifDataFormat = "JSON"
+
+type CapifSecurityMethod string
+
+// TS 29.222 v17 8.2.4.3.3
+const (
+	CapifSecurityMethodPsk   CapifSecurityMethod = "PSK"
+	CapifSecurityMethodPki   CapifSecurityMethod = "PKI"
+	CapifSecurityMethodOauth CapifSecurityMethod = "OAUTH"
+)
+
+type CapifCommunicationType string
+
+// TS 29.222 v17 8.2.4.3.4
+const (
+	CapifCommTypeRequestResponse CapifCommunicationType = "REQUEST_RESPONSE"
+	CapifCommTypeSubscribeNotify CapifCommunicationType = "SUBSCRIBE_NOTIFY"
+)
+
+// ServiceAPIDescription is the TS 29.222 v17 8.2.4.2.2 data type
+type ServiceAPIDescription struct {
+	ApiName     string       `json:"apiName"`
+	ApiId       string       `json:"apiId,omitempty"`
+	AefProfiles []AefProfile `json:"aefProfiles,omitempty"`
+	Description string       `json:"description,omitempty"`
+}
+
+// AefProfile is the TS 29.222 v17 8.2.4.2.3 data type
+type AefProfile struct {
+	AefId                 string                 `json:"aefId"`
+	Versions              []CapifVersion         `json:"versions"`
+	Protocol              CapifProtocol          `json:"protocol,omitempty"`
+	DataFormat            CapifDataFormat        `json:"dataFormat,omitempty"`
+	SecurityMethods       []CapifSecurityMethod  `json:"securityMethods,omitempty"`
+	InterfaceDescriptions []InterfaceDescription `json:"interfaceDescriptions,omitempty"`
+}
+
+// CapifVersion is the TS 29.222 v17 8.2.4.2.4 data type Version
+type CapifVersion struct {
+	ApiVersion string          `json:"apiVersion"`
+	Resources  []CapifResource `json:"resources,omitempty"`
+}
+
+// CapifResource is the TS 29.222 v17 8.2.4.2.5 data type Resource
+type CapifResource struct {
+	ResourceName string                 `json:"resourceName"`
+	CommType     CapifCommunicationType `json:"commType"`
+	Uri          string                 `json:"uri"`
+	Operations   []string               `json:"operations,omitempty"`
+}
+
+// InterfaceDescription is the TS 29.222 v17 8.2.4.2.6 data type
+type InterfaceDescription struct {
+	Ipv4Addr        string                `json:"ipv4Addr,omitempty"`
+	Port            int                   `json:"port,omitempty"`
+	SecurityMethods []CapifSecurityMethod `json:"securityMethods,omitempty"`
+}
+
+// ServiceSecurity is the TS 29.222 v17 8.5.4.2.2 data type
+type ServiceSecurity struct {
+	SecurityInfo            []SecurityInformation `json:"securityInfo"`
+	NotificationDestination string                `json:"notificationDestination,omitempty"`
+}
+
+// SecurityInformation is the TS 29.222 v17 8.5.4.2.3 data type
+type SecurityInformation struct {
+	AefId               string                `json:"aefId,omitempty"`
+	ApiId               string                `json:"apiId,omitempty"`
+	PrefSecurityMethods []CapifSecurityMethod `json:"prefSecurityMethods"`
+	SelSecurityMethod   CapifSecurityMethod   `json:"selSecurityMethod,omitempty"`
+}
+
+// InvocationLog is the TS 29.222 v17 8.7.4.2.2 data type
+type InvocationLog struct {
+	AefId        string     `json:"aefId"`
+	ApiInvokerId string     `json:"apiInvokerId"`
+	Logs         []CapifLog `json:"logs"`
+}
+
+// CapifLog is the TS 29.222 v17 8.7.4.2.3 data type Log
+type CapifLog struct {
+	ApiName        string        `json:"apiName"`
+	ApiId          string        `json:"apiId"`
+	ApiVersion     string        `json:"apiVersion"`
+	ResourceName   string        `json:"resourceName"`
+	Uri            string        `json:"uri,omitempty"`
+	Protocol       CapifProtocol `json:"protocol"`
+	Operation      string        `json:"operation,omitempty"`
+	Result         string        `json:"result"`
+	InvocationTime *time.Time    `json:"invocationTime,omitempty"`
+}
@@ -0,0 +1,124 @@
+package consumer
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/openapi/models"
+)
+
+const (
+	CapifPublishResUriPrefix  = "/published-apis/v1"
+	CapifSecurityResUriPrefix = "/capif-security/v1"
+	CapifLoggingResUriPrefix  = "/api-invocation-logs/v1"
+)
+
+// ncapifService is the client of the CAPIF core function used by NEF as API publishing function
+// and API exposing function (TS 29.222)
+type ncapifService struct {
+	consumer *Consumer
+
+	client *http.Client
+}
+
+func (s *ncapifService) capifUri() string {
+	if capif := s.consumer.Config().Capif(); capif != nil {
+		return capif.Uri
+	}
+	return ""
+}
+
+// PublishServiceAPI publishes the service API (TS 29.222 v17 8.2.2.2.3.1),
+// the published ServiceAPIDescription with the apiId allocated by CAPIF is returned on success.
+func (s *ncapifService) PublishServiceAPI(apfID string, desc *ServiceAPIDescription) (int, interface{}) {
+	uri := s.capifUri() + CapifPublishResUriPrefix + "/" + url.PathEscape(apfID) + "/service-apis"
+	var published ServiceAPIDescription
+	rspCode, rspBody := s.send(http.MethodPost, uri, desc, &published)
+	if rspCode == http.StatusCreated {
+		return rspCode, &published
+	}
+	return rspCode, rspBody
+}
+
+// UnpublishServiceAPI deletes the published service API (TS 29.222 v17 8.2.2.3.3.3)
+func (s *ncapifService) UnpublishServiceAPI(apfID, apiID string) (int, interface{}) {
+	uri := s.capifUri() + CapifPublishResUriPrefix + "/" + url.PathEscape(apfID) +
+		"/service-apis/" + url.PathEscape(apiID)
+	return s.send(http.MethodDelete, uri, nil, nil)
+}
+
+// GetTrustedInvoker obtains the security information of the API invoker (TS 29.222 v17 8.5.2.2.3.1),
+// 404 means the API invoker is not authorized by CAPIF.
+func (s *ncapifService) GetTrustedInvoker(apiInvokerID string) (int, interface{}) {
+	uri := s.capifUri() + CapifSecurityResUriPrefix + "/trustedInvokers/" + url.PathEscape(apiInvokerID) +
+		"?authenticationInfo=false&authorizationInfo=true"
+	var servSecurity ServiceSecurity
+	rspCode, rspBody := s.send(http.MethodGet, uri, nil, &servSecurity)
+	if rspCode == http.StatusOK {
+		return rspCode, &servSecurity
+	}
+	return rspCode, rspBody
+}
+
+// ReportInvocationLog stores the API invocation logs in CAPIF (TS 29.222 v17 8.7.2.2.3.1)
+func (s *ncapifService) ReportInvocationLog(aefID string, invocationLog *InvocationLog) (int, interface{}) {
+	uri := s.capifUri() + CapifLoggingResUriPrefix + "/" + url.PathEscape(aefID) + "/logs"
+	return s.send(http.MethodPost, uri, invocationLog, nil)
+}
+
+// send returns the response status and body of CAPIF, the body is decoded into result on success
+// or into ProblemDetails on failure.
+func (s *ncapifService) send(method, uri string, reqBody, result interface{}) (int, interface{}) {
+	var body io.Reader
+	if reqBody != nil {
+		b, err := json.Marshal(reqBody)
+		if err != nil {
+			return handleAPIServiceNoResponse(err)
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, uri, body)
+	if err != nil {
+		return handleAPIServiceNoResponse(err)
+	}
+	if reqBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	rsp, err := s.client.Do(req)
+	if err != nil {
+		return handleAPIServiceNoResponse(err)
+	}
+	defer func() {
+		if rspCloseErr := rsp.Body.Close(); rspCloseErr != nil {
+			logger.ConsumerLog.Errorf("Response body cannot close: %+v", rspCloseErr)
+		}
+	}()
+
+	rspBody, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return handleAPIServiceNoResponse(err)
+	}
+	if rsp.StatusCode < http.StatusOK || rsp.StatusCode >= http.StatusMultipleChoices {
+		pd := &models.ProblemDetails{
+			Status: int32(rsp.StatusCode),
+		}
+		if len(rspBody) > 0 {
+			if err = json.Unmarshal(rspBody, pd); err != nil {
+				pd.Detail = string(rspBody)
+			}
+		}
+		return rsp.StatusCode, pd
+	}
+	if result != nil && len(rspBody) > 0 {
+		if err = json.Unmarshal(rspBody, result); err != nil {
+			return handleAPIServiceNoResponse(err)
+		}
+	}
+	return rsp.StatusCode, nil
+}
@@ -1,14 +1,19 @@
 package consumer
 
 import (
+	"net/http"
+
 	nef_context "github.com/free5gc/nef/internal/context"
 	"github.com/free5gc/nef/internal/logger"
 	"github.com/free5gc/nef/pkg/app"
 	"github.com/free5gc/nef/pkg/factory"
 	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/bsf/Management"
+	"github.com/free5gc/openapi/models"
 	"github.com/free5gc/openapi/nrf/NFDiscovery"
 	"github.com/free5gc/openapi/nrf/NFManagement"
 	"github.com/free5gc/openapi/pcf/PolicyAuthorization"
+	"github.com/free5gc/openapi/udm/SubscriberDataManagement"
 	"github.com/free5gc/openapi/udr/DataRepository"
 )
 
@@ -22,10 +27,17 @@ type nef interface {
 type Consumer struct {
 	nef
 
+	httpClient   *http.Client  // client of the requests to NRF/PCF/UDR/UDM/BSF, bounded by sbiClient config
+	directClient *http.Client  // client of the requests not through SCP, i.e. to CAPIF and the notified NFs/AFs
+	scp          *scpTransport // nil if the requests are not sent through SCP
+
 	// consumer services
 	*nnrfService
 	*npcfService
 	*nudrService
+	*nudmService
+	*nbsfService
+	*ncapifService
 }
 
 func NewConsumer(nef nef) (*Consumer, error) {
@@ -33,6 +45,21 @@ func NewConsumer(nef nef) (*Consumer, error) {
 		nef: nef,
 	}
 
+	sbiClientCfg := nef.Config().SbiClient()
+	base := newBaseTransport(sbiClientCfg)
+	transport := base
+	if scpCfg := nef.Config().Scp(); scpCfg != nil {
+		scp, err := newScpTransport(scpCfg.Uri)
+		if err != nil {
+			return nil, err
+		}
+		scp.base = base
+		c.scp = scp
+		transport = scp
+	}
+	c.httpClient = &http.Client{Transport: newSbiTransport(sbiClientCfg, transport)}
+	c.directClient = &http.Client{Transport: newSbiTransport(sbiClientCfg, base)}
+
 	c.nnrfService = &nnrfService{
 		consumer:        c,
 		nfDiscClients:   make(map[string]*NFDiscovery.APIClient),
@@ -41,16 +68,41 @@ func NewConsumer(nef nef) (*Consumer, error) {
 
 	c.npcfService = &npcfService{
 		consumer: c,
+		selector: newNfSelector(c, models.ServiceName_NPCF_POLICYAUTHORIZATION, models.NrfNfManagementNfType_PCF),
 		clients:  make(map[string]*PolicyAuthorization.APIClient),
 	}
 
 	c.nudrService = &nudrService{
 		consumer: c,
+		selector: newNfSelector(c, models.ServiceName_NUDR_DR, models.NrfNfManagementNfType_UDR),
 		clients:  make(map[string]*DataRepository.APIClient),
 	}
+
+	c.nudmService = &nudmService{
+		consumer: c,
+		selector: newNfSelector(c, models.ServiceName_NUDM_SDM, models.NrfNfManagementNfType_UDM),
+		clients:  make(map[string]*SubscriberDataManagement.APIClient),
+	}
+
+	c.nbsfService = &nbsfService{
+		consumer: c,
+		selector: newNfSelector(c, models.ServiceName_NBSF_MANAGEMENT, models.NrfNfManagementNfType_BSF),
+		clients:  make(map[string]*Management.APIClient),
+	}
+
+	c.ncapifService = &ncapifService{
+		consumer: c,
+		client:   c.directClient,
+	}
 	return c, nil
 }
 
+// DirectHTTPClient returns the client bounded by sbiClient config for the requests not through SCP,
+// e.g. the notifications to AFs and SMFs
+func (c *Consumer) DirectHTTPClient() *http.Client {
+	return c.directClient
+}
+
 func handleAPIServiceNoResponse(err error) (int, interface{}) {
 	detail := "server no response"
 	if err != nil {
@@ -0,0 +1,300 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	nef_context "github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/openapi/models"
+	"github.com/free5gc/openapi/nrf/NFDiscovery"
+)
+
+const (
+	// NfInstanceFailureBackoff is how long an NF instance is not preferred after it failed
+	NfInstanceFailureBackoff = 30 * time.Second
+	// defaultNfCapacity is used if NRF provides no capacity of the NF instance
+	defaultNfCapacity = 100
+)
+
+// NfDiscoveryQuery holds the optional parameters of NF discovery, so that the NF instances serving
+// the S-NSSAI/DNN/SUPI (e.g. a slice-specific PCF or UDR) are selected
+type NfDiscoveryQuery struct {
+	Snssai *models.Snssai
+	Dnn    string
+	Supi   string
+}
+
+// nfSelector selects the NF instances providing the NF service among the ones discovered from NRF.
+// The NF instances in the same locality as NEF and with the highest priority are preferred,
+// and the requests are spread to them by weighted round-robin, where the weight is derived from
+// capacity and load. The other NF instances are used for failover.
+type nfSelector struct {
+	consumer *Consumer
+	srvName  models.ServiceName
+	nfType   models.NrfNfManagementNfType
+
+	mu             sync.Mutex
+	currentWeights map[string]int64     // URI -> current weight of smooth weighted round-robin
+	failedAt       map[string]time.Time // URI -> time of the last failure
+}
+
+func newNfSelector(consumer *Consumer, srvName models.ServiceName, nfType models.NrfNfManagementNfType) *nfSelector {
+	return &nfSelector{
+		consumer:       consumer,
+		srvName:        srvName,
+		nfType:         nfType,
+		currentWeights: make(map[string]int64),
+		failedAt:       make(map[string]time.Time),
+	}
+}
+
+func (s *nfSelector) discoveryKey(query *NfDiscoveryQuery) nef_context.NfDiscoveryKey {
+	key := nef_context.NfDiscoveryKey{
+		SrvName:           s.srvName,
+		PreferredLocality: s.consumer.Config().Locality(),
+	}
+	if query != nil {
+		if query.Snssai != nil {
+			key.Sst = query.Snssai.Sst
+			key.Sd = query.Snssai.Sd
+		}
+		key.Dnn = query.Dnn
+		key.Supi = query.Supi
+	}
+	return key
+}
+
+// nfInstances returns the NF instances discovered with the query, they're cached for the validity period
+// provided by NRF. The NF instances discovered without the query are used if none matches the query.
+func (s *nfSelector) nfInstances(query *NfDiscoveryQuery) ([]*nef_context.NfInstance, error) {
+	key := s.discoveryKey(query)
+	nfInstances := s.consumer.Context().NfInstances(key)
+	if len(nfInstances) > 0 {
+		return nfInstances, nil
+	}
+
+	localVarOptionals := NFDiscovery.SearchNFInstancesRequest{
+		ServiceNames: []models.ServiceName{
+			s.srvName,
+		},
+	}
+	if key.Sst != 0 {
+		localVarOptionals.Snssais = []models.Snssai{
+			{Sst: key.Sst, Sd: key.Sd},
+		}
+	}
+	if dnn := key.Dnn; dnn != "" {
+		localVarOptionals.Dnn = &dnn
+	}
+	if supi := key.Supi; supi != "" {
+		localVarOptionals.Supi = &supi
+	}
+	if locality := key.PreferredLocality; locality != "" {
+		localVarOptionals.PreferredLocality = &locality
+	}
+	nfInstances, validityPeriod, err := s.consumer.SearchNFInstances(s.consumer.Config().NrfUri(),
+		s.srvName, s.nfType, models.NrfNfManagementNfType_NEF, &localVarOptionals)
+	if err != nil {
+		if query != nil {
+			logger.ConsumerLog.Warnf("No %s is discovered for %+v, discover without it", s.srvName, *query)
+			return s.nfInstances(nil)
+		}
+		logger.ConsumerLog.Debugf("Search NF Instances failed")
+		return nil, err
+	}
+	s.consumer.Context().SetNfInstances(key, nfInstances, validityPeriod)
+	return nfInstances, nil
+}
+
+// delegated returns true if the NF discovery is delegated to SCP (Model D)
+func (s *nfSelector) delegated() bool {
+	scpCfg := s.consumer.Config().Scp()
+	return s.consumer.scp != nil && scpCfg != nil && scpCfg.DelegatedDiscovery()
+}
+
+// discoveryHeaders are the 3gpp-Sbi-Discovery-* headers for SCP to discover the NF instance with the query
+func (s *nfSelector) discoveryHeaders(query *NfDiscoveryQuery) http.Header {
+	key := s.discoveryKey(query)
+	header := http.Header{}
+	header.Set(HeaderSbiDiscoveryPrefix+"target-nf-type", string(s.nfType))
+	header.Set(HeaderSbiDiscoveryPrefix+"requester-nf-type", string(models.NrfNfManagementNfType_NEF))
+	header.Set(HeaderSbiDiscoveryPrefix+"service-names", string(s.srvName))
+	if key.Sst != 0 {
+		// The value is the JSON of the array as the query parameter of NF discovery (TS 29.510 6.2.3.2.3.1)
+		if snssais, err := json.Marshal([]models.Snssai{{Sst: key.Sst, Sd: key.Sd}}); err == nil {
+			header.Set(HeaderSbiDiscoveryPrefix+"snssais", string(snssais))
+		}
+	}
+	if key.Dnn != "" {
+		header.Set(HeaderSbiDiscoveryPrefix+"dnn", key.Dnn)
+	}
+	if key.Supi != "" {
+		header.Set(HeaderSbiDiscoveryPrefix+"supi", key.Supi)
+	}
+	if key.PreferredLocality != "" {
+		header.Set(HeaderSbiDiscoveryPrefix+"preferred-locality", key.PreferredLocality)
+	}
+	return header
+}
+
+// requestCtx returns the context of the request to the NF service with the OAuth2 token,
+// and the discovery parameters with the query if the NF discovery is delegated to SCP
+func (s *nfSelector) requestCtx(query *NfDiscoveryQuery) (context.Context, *models.ProblemDetails, error) {
+	ctx, pd, err := s.consumer.Context().GetTokenCtx(s.srvName, s.nfType)
+	if err != nil {
+		return ctx, pd, err
+	}
+	if s.delegated() {
+		ctx = withScpDiscovery(ctx, s.discoveryHeaders(query))
+	}
+	return ctx, nil, nil
+}
+
+// selectUris returns the URIs of the NF instances in the order they should be tried,
+// it's the apiRoot of SCP if the NF discovery is delegated to SCP.
+func (s *nfSelector) selectUris(query *NfDiscoveryQuery) ([]string, error) {
+	if s.delegated() {
+		return []string{s.consumer.scp.apiRoot()}, nil
+	}
+
+	nfInstances, err := s.nfInstances(query)
+	if err != nil {
+		return nil, err
+	}
+
+	locality := s.consumer.Config().Locality()
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var healthy, failed []*nef_context.NfInstance
+	for _, nfInst := range nfInstances {
+		if failedAt, ok := s.failedAt[nfInst.Uri]; ok && now.Sub(failedAt) < NfInstanceFailureBackoff {
+			failed = append(failed, nfInst)
+		} else {
+			healthy = append(healthy, nfInst)
+		}
+	}
+	if len(healthy) == 0 {
+		// All failed, they're tried as if none failed
+		healthy, failed = failed, nil
+	}
+
+	localRank := func(nfInst *nef_context.NfInstance) int {
+		if locality == "" || nfInst.Locality == locality {
+			return 0
+		}
+		return 1
+	}
+	sort.SliceStable(healthy, func(i, j int) bool {
+		if ri, rj := localRank(healthy[i]), localRank(healthy[j]); ri != rj {
+			return ri < rj
+		}
+		if healthy[i].Priority != healthy[j].Priority {
+			return healthy[i].Priority < healthy[j].Priority
+		}
+		return nfInstanceWeight(healthy[i]) > nfInstanceWeight(healthy[j])
+	})
+
+	// The preferred NF instances are the ones with the same locality rank and priority as the first one
+	numPreferred := 1
+	for numPreferred < len(healthy) &&
+		localRank(healthy[numPreferred]) == localRank(healthy[0]) &&
+		healthy[numPreferred].Priority == healthy[0].Priority {
+		numPreferred++
+	}
+	// The failed NF instances are tried last
+	candidates := make([]*nef_context.NfInstance, 0, len(nfInstances))
+	candidates = append(append(candidates, healthy...), failed...)
+	picked := s.pickWeightedRoundRobin(candidates[:numPreferred])
+
+	uris := make([]string, 0, len(candidates))
+	uris = append(uris, candidates[picked].Uri)
+	for i, nfInst := range candidates {
+		if i != picked {
+			uris = append(uris, nfInst.Uri)
+		}
+	}
+	return uris, nil
+}
+
+// selectUri returns the URI of the NF instance to use if the request can't fail over
+func (s *nfSelector) selectUri(query *NfDiscoveryQuery) (string, error) {
+	uris, err := s.selectUris(query)
+	if err != nil {
+		return "", err
+	}
+	return uris[0], nil
+}
+
+// pickWeightedRoundRobin returns the index of the NF instance picked by smooth weighted round-robin,
+// s.mu is locked by the caller.
+func (s *nfSelector) pickWeightedRoundRobin(nfInstances []*nef_context.NfInstance) int {
+	var total int64
+	picked := 0
+	for i, nfInst := range nfInstances {
+		weight := nfInstanceWeight(nfInst)
+		total += weight
+		s.currentWeights[nfInst.Uri] += weight
+		if s.currentWeights[nfInst.Uri] > s.currentWeights[nfInstances[picked].Uri] {
+			picked = i
+		}
+	}
+	s.currentWeights[nfInstances[picked].Uri] -= total
+	return picked
+}
+
+// do calls fn with the URIs of the NF instances in order, it fails over to the next NF instance
+// if fn gets 5xx or no response. The status and body are zero if no NF instance is discovered.
+func (s *nfSelector) do(query *NfDiscoveryQuery, fn func(uri string) (int, interface{})) (int, interface{}) {
+	var (
+		rspCode int
+		rspBody interface{}
+	)
+
+	uris, err := s.selectUris(query)
+	if err != nil {
+		return rspCode, rspBody
+	}
+	for _, uri := range uris {
+		rspCode, rspBody = fn(uri)
+		if rspCode < http.StatusInternalServerError {
+			s.succeeded(uri)
+			return rspCode, rspBody
+		}
+		logger.ConsumerLog.Warnf("%s[%s] failed with status[%d], try next NF instance", s.srvName, uri, rspCode)
+		s.failed(uri)
+	}
+	return rspCode, rspBody
+}
+
+func (s *nfSelector) succeeded(uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.failedAt, uri)
+}
+
+func (s *nfSelector) failed(uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failedAt[uri] = time.Now()
+}
+
+// nfInstanceWeight is the spare capacity of the NF instance, at least 1 so that it's still selectable
+func nfInstanceWeight(nfInst *nef_context.NfInstance) int64 {
+	capacity := int64(nfInst.Capacity)
+	if capacity <= 0 {
+		capacity = defaultNfCapacity
+	}
+	weight := capacity * int64(100-nfInst.Load) / 100
+	if weight < 1 {
+		weight = 1
+	}
+	return weight
+}
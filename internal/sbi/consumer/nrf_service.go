@@ -3,7 +3,6 @@ package consumer
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
@@ -80,7 +79,7 @@ func (s *nnrfService) getNFDiscoveryClient(uri string) *NFDiscovery.APIClient {
 	} else {
 		configuration := NFDiscovery.NewConfiguration()
 		configuration.SetBasePath(uri)
-		configuration.SetHTTPClient(http.DefaultClient)
+		configuration.SetHTTPClient(s.consumer.httpClient)
 		cli := NFDiscovery.NewAPIClient(configuration)
 
 		s.nfDiscMu.RUnlock()
@@ -99,6 +98,7 @@ func (s *nnrfService) getNFManagementClient(uri string) *NFManagement.APIClient
 	} else {
 		configuration := NFManagement.NewConfiguration()
 		configuration.SetBasePath(uri)
+		configuration.SetHTTPClient(s.consumer.httpClient)
 		cli := NFManagement.NewAPIClient(configuration)
 
 		s.nfMngmntMu.RUnlock()
@@ -156,6 +156,7 @@ func (s *nnrfService) RegisterNFInstance(ctx context.Context, nefCtx *nef_contex
 			if oauth2 && s.consumer.Context().Config().NrfCertPem() == "" {
 				logger.CfgLog.Error("OAuth2 enable but no nrfCertPem provided in config.")
 			}
+			s.consumer.Context().SetHeartBeatTimer(nf.HeartBeatTimer)
 			finish = true
 		}
 	}
@@ -173,14 +174,110 @@ func (s *nnrfService) buildNfProfile() (
 
 	cfg := s.consumer.Config()
 	profile.Ipv4Addresses = append(profile.Ipv4Addresses, cfg.SbiRegisterIP())
-	nfServices := cfg.NFServices()
+	nfServices := cfg.NFServices(s.consumer.Context().ServiceList())
 	if len(nfServices) == 0 {
 		return nil, fmt.Errorf("buildNfProfile err: NFServices is Empty")
 	}
 	profile.NfServices = nfServices
+	profile.Load = s.consumer.Context().Load()
+	profile.Locality = cfg.Locality()
 	return profile, nil
 }
 
+// UpdateNFInstance patches the NF profile registered in NRF (TS 29.510 5.2.2.3),
+// the NF profile is returned if NRF replies it.
+func (s *nnrfService) UpdateNFInstance(patchItems []models.PatchItem) (
+	nfProfile *models.NrfNfManagementNfProfile, problemDetails *models.ProblemDetails, err error,
+) {
+	ctx, pd, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NNRF_NFM, models.NrfNfManagementNfType_NRF)
+	if err != nil {
+		return nil, pd, err
+	}
+
+	client := s.getNFManagementClient(s.consumer.Config().NrfUri())
+
+	nfInstanceId := s.consumer.Context().NfInstID()
+	req := &NFManagement.UpdateNFInstanceRequest{
+		NfInstanceID: &nfInstanceId,
+		PatchItem:    patchItems,
+	}
+
+	res, err := client.NFInstanceIDDocumentApi.UpdateNFInstance(ctx, req)
+	if err != nil {
+		switch apiErr := err.(type) {
+		// API error
+		case openapi.GenericOpenAPIError:
+			switch errModel := apiErr.Model().(type) {
+			case NFManagement.UpdateNFInstanceError:
+				problemDetails = &errModel.ProblemDetails
+			case error:
+				problemDetails = openapi.ProblemDetailsSystemFailure(errModel.Error())
+			default:
+				err = openapi.ReportError("openapi error")
+			}
+		case error:
+			problemDetails = openapi.ProblemDetailsSystemFailure(apiErr.Error())
+		default:
+			err = openapi.ReportError("server no response")
+		}
+		return nil, problemDetails, err
+	}
+	if res != nil && res.NrfNfManagementNfProfile.NfInstanceId != "" {
+		// 200 OK, otherwise 204 No Content without the NF profile
+		nfProfile = &res.NrfNfManagementNfProfile
+	}
+	return nfProfile, nil, nil
+}
+
+// SendHeartbeat reports NEF is still operative with its current load (TS 29.510 5.2.2.3.2),
+// NRF replies 404 if NEF is not registered, e.g. NRF restarted or NEF was deregistered due to missed heartbeats.
+func (s *nnrfService) SendHeartbeat() (*models.ProblemDetails, error) {
+	patchItems := []models.PatchItem{
+		{
+			Op:    models.PatchOperation_REPLACE,
+			Path:  "/nfStatus",
+			Value: models.NrfNfManagementNfStatus_REGISTERED,
+		},
+		{
+			// "add" replaces the load if it exists
+			Op:    models.PatchOperation_ADD,
+			Path:  "/load",
+			Value: s.consumer.Context().Load(),
+		},
+	}
+	nfProfile, pd, err := s.UpdateNFInstance(patchItems)
+	if pd != nil || err != nil {
+		return pd, err
+	}
+	if nfProfile != nil && nfProfile.HeartBeatTimer > 0 {
+		s.consumer.Context().SetHeartBeatTimer(nfProfile.HeartBeatTimer)
+	}
+	return nil, nil
+}
+
+// UpdateNfProfile pushes the current services and load to NRF without registering again
+func (s *nnrfService) UpdateNfProfile() (*models.ProblemDetails, error) {
+	nfProfile, err := s.buildNfProfile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build NRF profile: %+v", err)
+	}
+
+	patchItems := []models.PatchItem{
+		{
+			Op:    models.PatchOperation_REPLACE,
+			Path:  "/nfServices",
+			Value: nfProfile.NfServices,
+		},
+		{
+			Op:    models.PatchOperation_ADD,
+			Path:  "/load",
+			Value: nfProfile.Load,
+		},
+	}
+	_, pd, err := s.UpdateNFInstance(patchItems)
+	return pd, err
+}
+
 func (s *nnrfService) DeregisterNFInstance() (problemDetails *models.ProblemDetails, err error) {
 	logger.ConsumerLog.Infof("DeregisterNFInstance")
 
@@ -218,18 +315,116 @@ func (s *nnrfService) DeregisterNFInstance() (problemDetails *models.ProblemDeta
 	return problemDetails, err
 }
 
+// CreateSubscription subscribes to the status of the NF instances of nfType (TS 29.510 5.2.2.5),
+// the subscriptionId allocated by NRF is returned.
+func (s *nnrfService) CreateSubscription(nfType models.NrfNfManagementNfType, notifUri string) (
+	subscriptionID string, problemDetails *models.ProblemDetails, err error,
+) {
+	ctx, pd, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NNRF_NFM, models.NrfNfManagementNfType_NRF)
+	if err != nil {
+		return "", pd, err
+	}
+
+	client := s.getNFManagementClient(s.consumer.Config().NrfUri())
+
+	req := &NFManagement.CreateSubscriptionRequest{
+		NrfNfManagementSubscriptionData: &models.NrfNfManagementSubscriptionData{
+			NfStatusNotificationUri: notifUri,
+			ReqNfInstanceId:         s.consumer.Context().NfInstID(),
+			SubscrCond: &models.SubscrCond{
+				NfType: string(nfType),
+			},
+			ReqNotifEvents: []models.NotificationEventType{
+				models.NotificationEventType_DEREGISTERED,
+				models.NotificationEventType_PROFILE_CHANGED,
+			},
+			ReqNfType: models.NrfNfManagementNfType_NEF,
+		},
+	}
+
+	res, err := client.SubscriptionsCollectionApi.CreateSubscription(ctx, req)
+	if err != nil {
+		switch apiErr := err.(type) {
+		// API error
+		case openapi.GenericOpenAPIError:
+			switch errModel := apiErr.Model().(type) {
+			case NFManagement.CreateSubscriptionError:
+				problemDetails = &errModel.ProblemDetails
+			case error:
+				problemDetails = openapi.ProblemDetailsSystemFailure(errModel.Error())
+			default:
+				err = openapi.ReportError("openapi error")
+			}
+		case error:
+			problemDetails = openapi.ProblemDetailsSystemFailure(apiErr.Error())
+		default:
+			err = openapi.ReportError("server no response")
+		}
+		return "", problemDetails, err
+	}
+	if res == nil {
+		return "", nil, openapi.ReportError("server no response")
+	}
+
+	subscriptionID = res.NrfNfManagementSubscriptionData.SubscriptionId
+	if subscriptionID == "" {
+		// The subscriptionId is the last segment of the Location
+		subscriptionID = res.Location[strings.LastIndex(res.Location, "/")+1:]
+	}
+	return subscriptionID, nil, nil
+}
+
+// RemoveSubscription unsubscribes from the status of NF instances (TS 29.510 5.2.2.6)
+func (s *nnrfService) RemoveSubscription(subscriptionID string) (
+	problemDetails *models.ProblemDetails, err error,
+) {
+	ctx, pd, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NNRF_NFM, models.NrfNfManagementNfType_NRF)
+	if err != nil {
+		return pd, err
+	}
+
+	client := s.getNFManagementClient(s.consumer.Config().NrfUri())
+
+	req := &NFManagement.RemoveSubscriptionRequest{
+		SubscriptionID: &subscriptionID,
+	}
+
+	_, err = client.SubscriptionIDDocumentApi.RemoveSubscription(ctx, req)
+	if err != nil {
+		switch apiErr := err.(type) {
+		// API error
+		case openapi.GenericOpenAPIError:
+			switch errModel := apiErr.Model().(type) {
+			case NFManagement.RemoveSubscriptionError:
+				problemDetails = &errModel.ProblemDetails
+			case error:
+				problemDetails = openapi.ProblemDetailsSystemFailure(errModel.Error())
+			default:
+				err = openapi.ReportError("openapi error")
+			}
+		case error:
+			problemDetails = openapi.ProblemDetailsSystemFailure(apiErr.Error())
+		default:
+			err = openapi.ReportError("server no response")
+		}
+	}
+	return problemDetails, err
+}
+
+// SearchNFInstances returns all the NF instances providing the NF service srvName,
+// and the validity period of the result, 0 if NRF doesn't provide it.
 func (s *nnrfService) SearchNFInstances(nrfUri string, srvName models.ServiceName, targetNfType,
 	requestNfType models.NrfNfManagementNfType, param *NFDiscovery.SearchNFInstancesRequest,
-) (*models.NrfNfDiscoveryNfProfile, string, error) {
+) ([]*nef_context.NfInstance, time.Duration, error) {
 	client := s.getNFDiscoveryClient(nrfUri)
 
 	if client == nil {
-		return nil, "", openapi.ReportError("nrf not found")
+		return nil, 0, openapi.ReportError("nrf not found")
 	}
 
 	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NNRF_DISC, models.NrfNfManagementNfType_NRF)
 	if err != nil {
-		return nil, "", err
+		return nil, 0, err
 	}
 
 	param.TargetNfType = &targetNfType
@@ -238,38 +433,62 @@ func (s *nnrfService) SearchNFInstances(nrfUri string, srvName models.ServiceNam
 	var result *models.SearchResult
 	if err != nil {
 		logger.ConsumerLog.Errorf("SearchNFInstances failed: %+v", err)
-		return nil, "", err
+		return nil, 0, err
 	}
 	if res != nil {
 		result = &res.SearchResult
 	}
 
-	nfProf, uri, err := getProfileAndUri(result, srvName)
+	nfInstances, err := getNfInstances(result, srvName)
 	if err != nil {
 		logger.ConsumerLog.Errorf("%s", err.Error())
-		return nil, "", err
+		return nil, 0, err
 	}
-	return nfProf, uri, nil
+	return nfInstances, time.Duration(result.ValidityPeriod) * time.Second, nil
 }
 
-func getProfileAndUri(resp *models.SearchResult, srvName models.ServiceName) (
-	*models.NrfNfDiscoveryNfProfile, string, error,
+// getNfInstances returns the NF instances with the registered NF service srvName
+func getNfInstances(resp *models.SearchResult, srvName models.ServiceName) (
+	[]*nef_context.NfInstance, error,
 ) {
-	// select the first ServiceName
-	// TODO: select base on other info
-	var profile *models.NrfNfDiscoveryNfProfile
-	var uri string
-	for _, nfProfile := range resp.NfInstances {
-		profile = &nfProfile
-		uri = searchNFServiceUri(nfProfile, srvName, models.NfServiceStatus_REGISTERED)
-		if uri != "" {
-			break
+	var nfInstances []*nef_context.NfInstance
+	if resp != nil {
+		for _, nfProfile := range resp.NfInstances {
+			uri := searchNFServiceUri(nfProfile, srvName, models.NfServiceStatus_REGISTERED)
+			if uri == "" {
+				continue
+			}
+			nfInst := &nef_context.NfInstance{
+				NfInstID: nfProfile.NfInstanceId,
+				Uri:      uri,
+				Priority: nfProfile.Priority,
+				Capacity: nfProfile.Capacity,
+				Load:     nfProfile.Load,
+				Locality: nfProfile.Locality,
+			}
+			// TS 29.510 6.1.6.2.3: priority/capacity/load of NF service take precedence over the ones of NF profile
+			for _, service := range nfProfile.NfServices {
+				if service.ServiceName != srvName || service.NfServiceStatus != models.NfServiceStatus_REGISTERED {
+					continue
+				}
+				if service.Priority != 0 {
+					nfInst.Priority = service.Priority
+				}
+				if service.Capacity != 0 {
+					nfInst.Capacity = service.Capacity
+				}
+				if service.Load != 0 {
+					nfInst.Load = service.Load
+				}
+				break
+			}
+			nfInstances = append(nfInstances, nfInst)
 		}
 	}
-	if uri == "" {
-		return nil, "", fmt.Errorf("no uri for %s found", srvName)
+	if len(nfInstances) == 0 {
+		return nil, fmt.Errorf("no uri for %s found", srvName)
 	}
-	return profile, uri, nil
+	return nfInstances, nil
 }
 
 // searchNFServiceUri returns NF Uri derived from NfProfile with corresponding service
@@ -3,16 +3,18 @@ package consumer
 import (
 	"net/http"
 	"reflect"
+	"strings"
 	"sync"
 
 	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
-	"github.com/free5gc/openapi/nrf/NFDiscovery"
 	"github.com/free5gc/openapi/pcf/PolicyAuthorization"
 )
 
 type npcfService struct {
 	consumer *Consumer
+	selector *nfSelector
 
 	mu      sync.RWMutex
 	clients map[string]*PolicyAuthorization.APIClient
@@ -26,7 +28,7 @@ func (s *npcfService) getClient(uri string) *PolicyAuthorization.APIClient {
 	} else {
 		configuration := PolicyAuthorization.NewConfiguration()
 		configuration.SetBasePath(uri)
-		configuration.SetHTTPClient(http.DefaultClient)
+		configuration.SetHTTPClient(s.consumer.httpClient)
 		cli := PolicyAuthorization.NewAPIClient(configuration)
 
 		s.mu.RUnlock()
@@ -37,32 +39,16 @@ func (s *npcfService) getClient(uri string) *PolicyAuthorization.APIClient {
 	}
 }
 
-func (s *npcfService) getPcfPolicyAuthUri() (string, error) {
-	uri := s.consumer.Context().PcfPaUri()
-	if uri == "" {
-		localVarOptionals := NFDiscovery.SearchNFInstancesRequest{
-			ServiceNames: []models.ServiceName{
-				models.ServiceName_NPCF_POLICYAUTHORIZATION,
-			},
-		}
-		logger.ConsumerLog.Infoln(s.consumer.Config().NrfUri())
-		_, sUri, err := s.consumer.SearchNFInstances(
-			s.consumer.Config().NrfUri(),
-			models.ServiceName_NPCF_POLICYAUTHORIZATION,
-			models.NrfNfManagementNfType_PCF,
-			models.NrfNfManagementNfType_NEF,
-			&localVarOptionals,
-		)
-		if err == nil {
-			s.consumer.Context().SetPcfPaUri(sUri)
-		}
-		logger.ConsumerLog.Debugf("Search NF Instances failed")
-		return sUri, err
+// selectPcfPolicyAuthUri returns the given PCF apiRoot (e.g. the one bound to the UE by BSF),
+// or the one discovered from NRF if it is not given.
+func (s *npcfService) selectPcfPolicyAuthUri(pcfUri string) (string, error) {
+	if pcfUri != "" {
+		return pcfUri, nil
 	}
-	return uri, nil
+	return s.selector.selectUri(nil)
 }
 
-func (s *npcfService) GetAppSession(appSessionId string) (int, interface{}) {
+func (s *npcfService) GetAppSession(pcfUri, appSessionId string) (int, interface{}) {
 	var (
 		err     error
 		rspCode int
@@ -70,14 +56,13 @@ func (s *npcfService) GetAppSession(appSessionId string) (int, interface{}) {
 		rsp     *PolicyAuthorization.GetAppSessionResponse
 	)
 
-	uri, err := s.getPcfPolicyAuthUri()
+	uri, err := s.selectPcfPolicyAuthUri(pcfUri)
 	if err != nil {
 		return rspCode, rspBody
 	}
 	client := s.getClient(uri)
 
-	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NPCF_POLICYAUTHORIZATION,
-		models.NrfNfManagementNfType_PCF)
+	ctx, _, err := s.selector.requestCtx(nil)
 	if err != nil {
 		return rspCode, rspBody
 	}
@@ -92,58 +77,75 @@ func (s *npcfService) GetAppSession(appSessionId string) (int, interface{}) {
 		rspCode = http.StatusOK
 		rspBody = rsp.AppSessionContext
 	} else {
-		rspCode, rspBody = handleAPIServiceNoResponse(err)
+		rspCode, rspBody = handlePolicyAuthorizationError(err)
 	}
 
 	return rspCode, rspBody
 }
 
-func (s *npcfService) PostAppSessions(asc *models.AppSessionContext) (int, interface{}, string) {
-	var (
-		err       error
-		rspCode   int
-		rspBody   interface{}
-		appSessID string
-		rsp       *PolicyAuthorization.PostAppSessionsResponse
-	)
+// PostAppSessions creates the app session in the given PCF, or fails over among the PCFs discovered from NRF
+// with the query if it is not given. The apiRoot of the PCF which creates the app session is returned as well.
+func (s *npcfService) PostAppSessions(pcfUri string, asc *models.AppSessionContext, query *NfDiscoveryQuery) (
+	int, interface{}, string, string,
+) {
+	var appSessID, locationApiRoot string
+	postAppSessions := func(uri string) (int, interface{}) {
+		var (
+			err     error
+			rspCode int
+			rspBody interface{}
+			rsp     *PolicyAuthorization.PostAppSessionsResponse
+		)
 
-	uri, err := s.getPcfPolicyAuthUri()
-	if err != nil {
-		return rspCode, rspBody, appSessID
-	}
-	client := s.getClient(uri)
+		client := s.getClient(uri)
 
-	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NPCF_POLICYAUTHORIZATION,
-		models.NrfNfManagementNfType_PCF)
-	if err != nil {
-		return rspCode, rspBody, appSessID
-	}
+		ctx, _, err := s.selector.requestCtx(query)
+		if err != nil {
+			return rspCode, rspBody
+		}
 
-	req := &PolicyAuthorization.PostAppSessionsRequest{
-		AppSessionContext: asc,
-	}
-	rsp, err = client.ApplicationSessionsCollectionApi.PostAppSessions(ctx, req)
+		req := &PolicyAuthorization.PostAppSessionsRequest{
+			AppSessionContext: asc,
+		}
+		rsp, err = client.ApplicationSessionsCollectionApi.PostAppSessions(ctx, req)
 
-	if rsp != nil {
-		if reflect.DeepEqual(rsp.AppSessionContext, models.AppSessionContext{}) {
-			rspCode = http.StatusSeeOther
+		if rsp != nil {
+			if reflect.DeepEqual(rsp.AppSessionContext, models.AppSessionContext{}) {
+				rspCode = http.StatusSeeOther
+			} else {
+				rspCode = http.StatusCreated
+				rspBody = rsp.AppSessionContext
+				// The Location is {apiRoot}/npcf-policyauthorization/v1/app-sessions/{appSessionId}
+				appSessID = rsp.Location[strings.LastIndex(rsp.Location, "/")+1:]
+				if i := strings.Index(rsp.Location, "/npcf-policyauthorization/"); i > 0 {
+					locationApiRoot = rsp.Location[:i]
+				}
+				logger.ConsumerLog.Debugf("PostAppSessions RspData: %+v", rsp.AppSessionContext)
+			}
 		} else {
-			rspCode = http.StatusCreated
-			rspBody = rsp.AppSessionContext
-			appSessID = rsp.Location
-			logger.ConsumerLog.Debugf("PostAppSessions RspData: %+v", rsp.AppSessionContext)
+			rspCode, rspBody = handleAPIServiceNoResponse(err)
 		}
-	} else {
-		rspCode, rspBody = handleAPIServiceNoResponse(err)
+		return rspCode, rspBody
 	}
 
-	return rspCode, rspBody, appSessID
+	if pcfUri != "" {
+		rspCode, rspBody := postAppSessions(pcfUri)
+		return rspCode, rspBody, appSessID, pcfUri
+	}
+	rspCode, rspBody := s.selector.do(query, func(uri string) (int, interface{}) {
+		pcfUri = uri
+		return postAppSessions(uri)
+	})
+	if s.selector.delegated() && locationApiRoot != "" {
+		// The PCF discovered by SCP is only known from the Location
+		pcfUri = locationApiRoot
+	}
+	return rspCode, rspBody, appSessID, pcfUri
 }
 
 func (s *npcfService) PutAppSession(
-	appSessionId string,
+	pcfUri, appSessionId string,
 	ascUpdateData *models.AppSessionContextUpdateData,
-	asc *models.AppSessionContext,
 ) (int, interface{}, string) {
 	var (
 		err     error
@@ -153,14 +155,13 @@ func (s *npcfService) PutAppSession(
 		modRsp  *PolicyAuthorization.ModAppSessionResponse
 	)
 
-	uri, err := s.getPcfPolicyAuthUri()
+	uri, err := s.selectPcfPolicyAuthUri(pcfUri)
 	if err != nil {
 		return rspCode, rspBody, appSessionId
 	}
 	client := s.getClient(uri)
 
-	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NPCF_POLICYAUTHORIZATION,
-		models.NrfNfManagementNfType_PCF)
+	ctx, _, err := s.selector.requestCtx(nil)
 	if err != nil {
 		return rspCode, rspBody, appSessionId
 	}
@@ -187,20 +188,20 @@ func (s *npcfService) PutAppSession(
 			} else {
 				rspCode = http.StatusOK
 				rspBody = modRsp.AppSessionContext
-				logger.ConsumerLog.Debugf("PostAppSessions RspData: %+v", rsp.AppSessionContext)
+				logger.ConsumerLog.Debugf("PutAppSession RspData: %+v", modRsp.AppSessionContext)
 			}
 		} else {
-			rspCode, rspBody = handleAPIServiceNoResponse(err)
+			rspCode, rspBody = handlePolicyAuthorizationError(err)
 		}
 	} else {
 		// API Service Internal Error or Server No Response
-		rspCode, rspBody = handleAPIServiceNoResponse(err)
+		rspCode, rspBody = handlePolicyAuthorizationError(err)
 	}
 
 	return rspCode, rspBody, appSessionId
 }
 
-func (s *npcfService) PatchAppSession(appSessionId string,
+func (s *npcfService) PatchAppSession(pcfUri, appSessionId string,
 	ascUpdateData *models.AppSessionContextUpdateData,
 ) (int, interface{}) {
 	var (
@@ -210,14 +211,13 @@ func (s *npcfService) PatchAppSession(appSessionId string,
 		rsp     *PolicyAuthorization.ModAppSessionResponse
 	)
 
-	uri, err := s.getPcfPolicyAuthUri()
+	uri, err := s.selectPcfPolicyAuthUri(pcfUri)
 	if err != nil {
 		return rspCode, rspBody
 	}
 	client := s.getClient(uri)
 
-	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NPCF_POLICYAUTHORIZATION,
-		models.NrfNfManagementNfType_PCF)
+	ctx, _, err := s.selector.requestCtx(nil)
 	if err != nil {
 		return rspCode, rspBody
 	}
@@ -242,7 +242,7 @@ func (s *npcfService) PatchAppSession(appSessionId string,
 	return rspCode, rspBody
 }
 
-func (s *npcfService) DeleteAppSession(appSessionId string) (int, interface{}) {
+func (s *npcfService) DeleteAppSession(pcfUri, appSessionId string) (int, interface{}) {
 	var (
 		err     error
 		rspCode int
@@ -250,7 +250,7 @@ func (s *npcfService) DeleteAppSession(appSessionId string) (int, interface{}) {
 		rsp     *PolicyAuthorization.DeleteAppSessionResponse
 	)
 
-	uri, err := s.getPcfPolicyAuthUri()
+	uri, err := s.selectPcfPolicyAuthUri(pcfUri)
 	if err != nil {
 		return rspCode, rspBody
 	}
@@ -260,8 +260,7 @@ func (s *npcfService) DeleteAppSession(appSessionId string) (int, interface{}) {
 	// 	EventsSubscReqData: optional.NewInterface(models.EventsSubscReqData{}),
 	// }
 
-	ctx, _, err := s.consumer.Context().GetTokenCtx(models.ServiceName_NPCF_POLICYAUTHORIZATION,
-		models.NrfNfManagementNfType_PCF)
+	ctx, _, err := s.selector.requestCtx(nil)
 	if err != nil {
 		return rspCode, rspBody
 	}
@@ -291,3 +290,16 @@ func (s *npcfService) DeleteAppSession(appSessionId string) (int, interface{}) {
 
 	return rspCode, rspBody
 }
+
+// handlePolicyAuthorizationError returns the ProblemDetails replied by PCF if any
+func handlePolicyAuthorizationError(err error) (int, interface{}) {
+	if apiErr, ok := err.(openapi.GenericOpenAPIError); ok {
+		switch errModel := apiErr.ErrorModel.(type) {
+		case PolicyAuthorization.GetAppSessionError:
+			return int(errModel.ProblemDetails.Status), &errModel.ProblemDetails
+		case PolicyAuthorization.ModAppSessionError:
+			return int(errModel.ProblemDetails.Status), &errModel.ProblemDetails
+		}
+	}
+	return handleAPIServiceNoResponse(err)
+}
@@ -0,0 +1,273 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/pkg/factory"
+)
+
+const (
+	// defaultRetryBackoff is the backoff before the first retry if it's not configured
+	defaultRetryBackoff = 100 * time.Millisecond
+	// defaultCircuitBreakerOpenTime is how long an open circuit breaker fails the requests if it's not configured
+	defaultCircuitBreakerOpenTime = 30 * time.Second
+)
+
+// ErrCircuitBreakerOpen is returned without sending the request while the target NF keeps failing,
+// so that another NF instance is tried without waiting for the timeout
+var ErrCircuitBreakerOpen = errors.New("circuit breaker of the target NF is open")
+
+// newBaseTransport returns the transport pooling the connections to the NFs as configured,
+// nil means http.DefaultTransport is used
+func newBaseTransport(cfg *factory.SbiClient) http.RoundTripper {
+	if cfg.MaxIdleConnsPerHost == 0 && !cfg.Http2 {
+		return nil
+	}
+	defaultTransport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return nil
+	}
+	t := defaultTransport.Clone()
+	if cfg.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	}
+	if cfg.Http2 {
+		// The requests are multiplexed on a connection per NF, h2c with prior knowledge on http URIs
+		t.Protocols = new(http.Protocols)
+		t.Protocols.SetHTTP2(true)
+		t.Protocols.SetUnencryptedHTTP2(true)
+	}
+	return t
+}
+
+// sbiTransport bounds each request to the NF services with the timeout of the service,
+// retries the idempotent ones with jittered exponential backoff on no response or 502/503/504,
+// and breaks the circuit to the target NF after consecutive failures.
+type sbiTransport struct {
+	cfg  *factory.SbiClient
+	base http.RoundTripper // http.DefaultTransport if nil
+
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker // target NF (see breakerTarget) -> circuit breaker
+}
+
+func newSbiTransport(cfg *factory.SbiClient, base http.RoundTripper) *sbiTransport {
+	return &sbiTransport{
+		cfg:      cfg,
+		base:     base,
+		breakers: make(map[string]*circuitBreaker),
+	}
+}
+
+func (t *sbiTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	target := breakerTarget(req)
+	if !t.allow(target) {
+		return nil, ErrCircuitBreakerOpen
+	}
+
+	maxRetries := 0
+	if isIdempotent(req.Method) && (req.Body == nil || req.Body == http.NoBody || req.GetBody != nil) {
+		maxRetries = t.cfg.MaxRetries
+	}
+	timeout := t.cfg.ServiceTimeout(serviceNameOf(req.URL.Path))
+
+	for attempt := 0; ; attempt++ {
+		rsp, err := t.roundTrip(req, attempt, timeout)
+		failed := err != nil || isRetryableStatus(rsp.StatusCode)
+		if !failed || attempt >= maxRetries || req.Context().Err() != nil {
+			t.record(target, failed)
+			return rsp, err
+		}
+
+		if err != nil {
+			logger.ConsumerLog.Warnf("%s %s failed: %+v, retry %d/%d", req.Method, req.URL, err, attempt+1, maxRetries)
+		} else {
+			logger.ConsumerLog.Warnf("%s %s failed with status[%d], retry %d/%d",
+				req.Method, req.URL, rsp.StatusCode, attempt+1, maxRetries)
+			// The connection is reused once the body is read
+			_, _ = io.Copy(io.Discard, rsp.Body)
+			_ = rsp.Body.Close()
+		}
+
+		timer := time.NewTimer(t.retryBackoff(attempt))
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			t.record(target, true)
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// roundTrip sends the request once within the timeout, which is over when the response body is closed
+func (t *sbiTransport) roundTrip(req *http.Request, attempt int, timeout time.Duration) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	// A RoundTripper shouldn't modify the request
+	r := req.Clone(ctx)
+	if attempt > 0 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		r.Body = body
+	}
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	rsp, err := base.RoundTrip(r)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	rsp.Body = &cancelBody{ReadCloser: rsp.Body, cancel: cancel}
+	return rsp, nil
+}
+
+// retryBackoff doubles the backoff on each retry, half of it is random so that the retries are spread
+func (t *sbiTransport) retryBackoff(attempt int) time.Duration {
+	backoff := defaultRetryBackoff
+	if t.cfg.RetryBackoff > 0 {
+		backoff = time.Duration(t.cfg.RetryBackoff) * time.Millisecond
+	}
+	backoff <<= min(attempt, 10)
+	return backoff/2 + rand.N(backoff/2+1)
+}
+
+func (t *sbiTransport) allow(target string) bool {
+	if t.cfg.CircuitBreakerThreshold == 0 {
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b, ok := t.breakers[target]
+	if !ok {
+		return true
+	}
+	openTime := defaultCircuitBreakerOpenTime
+	if t.cfg.CircuitBreakerOpenTime > 0 {
+		openTime = time.Duration(t.cfg.CircuitBreakerOpenTime) * time.Second
+	}
+	return b.allow(time.Now(), openTime)
+}
+
+func (t *sbiTransport) record(target string, failed bool) {
+	if t.cfg.CircuitBreakerThreshold == 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b, ok := t.breakers[target]
+	if !ok {
+		if !failed {
+			return
+		}
+		b = &circuitBreaker{}
+		t.breakers[target] = b
+	}
+	if b.record(time.Now(), failed, t.cfg.CircuitBreakerThreshold) {
+		logger.ConsumerLog.Warnf("Circuit breaker of %s is open after %d consecutive failures", target, b.failures)
+	}
+	if !failed {
+		delete(t.breakers, target)
+	}
+}
+
+// breakerTarget identifies the target NF of the request for the circuit breaker, i.e. its apiRoot.
+// In Model D, where the request is addressed to SCP itself, the target NF is the one SCP discovers with
+// the 3gpp-Sbi-Discovery-* parameters, so that a failing NF doesn't break the circuit to SCP for the others.
+func breakerTarget(req *http.Request) string {
+	if apiRoot := req.Header.Get(HeaderSbiTargetApiRoot); apiRoot != "" {
+		return apiRoot
+	}
+	apiRoot := req.URL.Scheme + "://" + req.URL.Host
+	discovery, ok := req.Context().Value(scpDiscoveryCtxKey{}).(http.Header)
+	if !ok || len(discovery) == 0 {
+		return apiRoot
+	}
+	params := make([]string, 0, len(discovery))
+	for name, values := range discovery {
+		params = append(params, name+"="+strings.Join(values, ","))
+	}
+	sort.Strings(params)
+	return apiRoot + "?" + strings.Join(params, "&")
+}
+
+// circuitBreaker is closed (openedAt is zero), open, or half-open once the open time is over,
+// where a request is tried and the others still fail until it succeeds or fails.
+type circuitBreaker struct {
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func (b *circuitBreaker) allow(now time.Time, openTime time.Duration) bool {
+	if b.openedAt.IsZero() {
+		return true
+	}
+	if b.probing || now.Sub(b.openedAt) < openTime {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record returns true if the circuit breaker is opened by the failure
+func (b *circuitBreaker) record(now time.Time, failed bool, threshold int) bool {
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		b.openedAt = time.Time{}
+		return false
+	}
+	b.failures++
+	if b.failures < threshold {
+		return false
+	}
+	b.openedAt = now
+	return true
+}
+
+// cancelBody cancels the context of the request when the response body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func isRetryableStatus(status int) bool {
+	return status == http.StatusBadGateway || status == http.StatusServiceUnavailable ||
+		status == http.StatusGatewayTimeout
+}
+
+// serviceNameOf returns the service name of the request, i.e. the first segment of the path (TS 29.501 4.4.1)
+func serviceNameOf(path string) string {
+	name, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return name
+}
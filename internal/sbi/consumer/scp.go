@@ -0,0 +1,72 @@
+package consumer
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Headers of indirect communication (TS 29.500 v17 5.2.3.2)
+const (
+	HeaderSbiTargetApiRoot   = "3gpp-Sbi-Target-apiRoot"
+	HeaderSbiDiscoveryPrefix = "3gpp-Sbi-Discovery-"
+)
+
+type scpDiscoveryCtxKey struct{}
+
+// withScpDiscovery lets SCP discover the target NF instance of the request with the discovery parameters
+func withScpDiscovery(ctx context.Context, discovery http.Header) context.Context {
+	return context.WithValue(ctx, scpDiscoveryCtxKey{}, discovery)
+}
+
+// scpTransport sends the requests to SCP instead of the target NF (TS 29.500 v17 6.10).
+// The apiRoot of the target NF is conveyed in 3gpp-Sbi-Target-apiRoot, unless the request is
+// already addressed to SCP for the delegated discovery, where 3gpp-Sbi-Discovery-* are conveyed.
+type scpTransport struct {
+	scpUri *url.URL
+	base   http.RoundTripper // http.DefaultTransport if nil
+}
+
+func newScpTransport(scpUri string) (*scpTransport, error) {
+	u, err := url.Parse(scpUri)
+	if err != nil {
+		return nil, err
+	}
+	return &scpTransport{scpUri: u}, nil
+}
+
+// apiRoot is the one of SCP itself, the requests to it are routed by the delegated discovery
+func (t *scpTransport) apiRoot() string {
+	return t.scpUri.Scheme + "://" + t.scpUri.Host
+}
+
+func (t *scpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper shouldn't modify the request
+	req = req.Clone(req.Context())
+
+	if req.URL.Host != t.scpUri.Host {
+		req.Header.Set(HeaderSbiTargetApiRoot, req.URL.Scheme+"://"+req.URL.Host)
+	}
+	if discovery, ok := req.Context().Value(scpDiscoveryCtxKey{}).(http.Header); ok {
+		for name, values := range discovery {
+			req.Header[name] = values
+		}
+	}
+
+	// The apiPrefix of SCP is prepended to the path of the target NF
+	prefix := strings.TrimSuffix(t.scpUri.Path, "/")
+	req.URL.Scheme = t.scpUri.Scheme
+	req.URL.Host = t.scpUri.Host
+	req.URL.Path = prefix + req.URL.Path
+	if req.URL.RawPath != "" {
+		req.URL.RawPath = prefix + req.URL.RawPath
+	}
+	req.Host = ""
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,146 @@
+package consumer
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/udm/SubscriberDataManagement"
+)
+
+type nudmService struct {
+	consumer *Consumer
+	selector *nfSelector
+
+	mu      sync.RWMutex
+	clients map[string]*SubscriberDataManagement.APIClient
+}
+
+func (s *nudmService) getClient(uri string) *SubscriberDataManagement.APIClient {
+	s.mu.RLock()
+	if client, ok := s.clients[uri]; ok {
+		defer s.mu.RUnlock()
+		return client
+	} else {
+		configuration := SubscriberDataManagement.NewConfiguration()
+		configuration.SetBasePath(uri)
+		configuration.SetHTTPClient(s.consumer.httpClient)
+		cli := SubscriberDataManagement.NewAPIClient(configuration)
+
+		s.mu.RUnlock()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.clients[uri] = cli
+		return cli
+	}
+}
+
+func (s *nudmService) getUdmSdmUri() (string, error) {
+	return s.selector.selectUri(nil)
+}
+
+// TS 29.503 v17 6.1.3.23.3.1
+// GetIntGroupID translates the externalGroupId to the internalGroupId,
+// the result is cached in NEF context once it is retrieved from UDM.
+func (s *nudmService) GetIntGroupID(extGroupID string) (int, interface{}, string) {
+	var (
+		err     error
+		rspCode int
+		rspBody interface{}
+		result  *SubscriberDataManagement.GetGroupIdentifiersResponse
+	)
+
+	if intGroupID, ok := s.consumer.Context().GetIntGroupID(extGroupID); ok {
+		return http.StatusOK, nil, intGroupID
+	}
+
+	uri, err := s.getUdmSdmUri()
+	if err != nil {
+		rspCode, rspBody = handleAPIServiceNoResponse(err)
+		return rspCode, rspBody, ""
+	}
+	client := s.getClient(uri)
+
+	ctx, _, err := s.selector.requestCtx(nil)
+	if err != nil {
+		rspCode, rspBody = handleAPIServiceNoResponse(err)
+		return rspCode, rspBody, ""
+	}
+
+	getGroupIdentifiersReq := &SubscriberDataManagement.GetGroupIdentifiersRequest{
+		ExtGroupId: &extGroupID,
+	}
+	result, err = client.GroupIdentifiersApi.GetGroupIdentifiers(ctx, getGroupIdentifiersReq)
+
+	if err == nil && result != nil {
+		intGroupID := result.UdmSdmGroupIdentifiers.IntGroupId
+		if intGroupID == "" {
+			pd := openapi.ProblemDetailsSystemFailure("No internalGroupId in UDM response")
+			return int(pd.Status), pd, ""
+		}
+		s.consumer.Context().SetIntGroupID(extGroupID, intGroupID)
+		return http.StatusOK, &result.UdmSdmGroupIdentifiers, intGroupID
+	}
+
+	if err != nil {
+		if apiErr, ok := err.(openapi.GenericOpenAPIError); ok {
+			if pd, ok := apiErr.ErrorModel.(SubscriberDataManagement.GetGroupIdentifiersError); ok {
+				rspCode = int(pd.ProblemDetails.Status)
+				rspBody = &pd.ProblemDetails
+				return rspCode, rspBody, ""
+			}
+		}
+	}
+
+	rspCode, rspBody = handleAPIServiceNoResponse(err)
+	return rspCode, rspBody, ""
+}
+
+// TS 29.503 v17 6.1.3.22.3.1
+func (s *nudmService) GetSupiByGpsi(gpsi string) (int, interface{}, string) {
+	var (
+		err     error
+		rspCode int
+		rspBody interface{}
+		result  *SubscriberDataManagement.GetSupiOrGpsiResponse
+	)
+
+	uri, err := s.getUdmSdmUri()
+	if err != nil {
+		rspCode, rspBody = handleAPIServiceNoResponse(err)
+		return rspCode, rspBody, ""
+	}
+	client := s.getClient(uri)
+
+	ctx, _, err := s.selector.requestCtx(nil)
+	if err != nil {
+		rspCode, rspBody = handleAPIServiceNoResponse(err)
+		return rspCode, rspBody, ""
+	}
+
+	getSupiOrGpsiReq := &SubscriberDataManagement.GetSupiOrGpsiRequest{
+		UeId: &gpsi,
+	}
+	result, err = client.GPSIToSUPITranslationOrSUPIToGPSITranslationApi.GetSupiOrGpsi(ctx, getSupiOrGpsiReq)
+
+	if err == nil && result != nil {
+		if result.IdTranslationResult.Supi == "" {
+			pd := openapi.ProblemDetailsSystemFailure("No supi in UDM response")
+			return int(pd.Status), pd, ""
+		}
+		return http.StatusOK, &result.IdTranslationResult, result.IdTranslationResult.Supi
+	}
+
+	if err != nil {
+		if apiErr, ok := err.(openapi.GenericOpenAPIError); ok {
+			if pd, ok := apiErr.ErrorModel.(SubscriberDataManagement.GetSupiOrGpsiError); ok {
+				rspCode = int(pd.ProblemDetails.Status)
+				rspBody = &pd.ProblemDetails
+				return rspCode, rspBody, ""
+			}
+		}
+	}
+
+	rspCode, rspBody = handleAPIServiceNoResponse(err)
+	return rspCode, rspBody, ""
+}
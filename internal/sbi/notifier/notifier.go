@@ -1,13 +1,20 @@
 package notifier
 
+import "net/http"
+
 type Notifier struct {
-	PfdChangeNotifier *PfdChangeNotifier
+	PfdChangeNotifier    *PfdChangeNotifier
+	TrafficInfluNotifier *TrafficInfluNotifier
 }
 
-func NewNotifier() (*Notifier, error) {
+// NewNotifier returns the notifiers sending the notifications with client
+func NewNotifier(client *http.Client) (*Notifier, error) {
 	var err error
 	n := &Notifier{}
-	if n.PfdChangeNotifier, err = NewPfdChangeNotifier(); err != nil {
+	if n.PfdChangeNotifier, err = NewPfdChangeNotifier(client); err != nil {
+		return nil, err
+	}
+	if n.TrafficInfluNotifier, err = NewTrafficInfluNotifier(client); err != nil {
 		return nil, err
 	}
 	return n, nil
@@ -15,6 +15,7 @@ import (
 )
 
 type PfdChangeNotifier struct {
+	httpClient          *http.Client
 	clientPfdManagement *PFDmanagement.APIClient
 	mu                  sync.RWMutex
 
@@ -29,8 +30,9 @@ type PfdNotifyContext struct {
 	subIdToChangedAppIDs map[string][]string
 }
 
-func NewPfdChangeNotifier() (*PfdChangeNotifier, error) {
+func NewPfdChangeNotifier(client *http.Client) (*PfdChangeNotifier, error) {
 	return &PfdChangeNotifier{
+		httpClient:    client,
 		appIdToSubIDs: make(map[string]map[string]bool),
 		subIdToURI:    make(map[string]string),
 	}, nil
@@ -45,7 +47,7 @@ func (n *PfdChangeNotifier) initPfdManagementApiClient() {
 	}
 
 	config := PFDmanagement.NewConfiguration()
-	config.SetHTTPClient(http.DefaultClient)
+	config.SetHTTPClient(n.httpClient)
 	n.clientPfdManagement = PFDmanagement.NewAPIClient(config)
 }
 
@@ -0,0 +1,130 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/openapi/models"
+	"github.com/sirupsen/logrus"
+)
+
+// SubscribedEventSubscriptionExpired is not defined in TS 29.522, it is sent by NEF when all tempValidities
+// or the granted expiry of the subscription have expired, only to the AFs configured to receive it.
+const SubscribedEventSubscriptionExpired models.SubscribedEvent = "SUBSCRIPTION_EXPIRED"
+
+type AfResultStatus string
+
+// TS 29.508 v17 5.6.3.9
+const (
+	AfResultStatusSuccess             AfResultStatus = "SUCCESS"
+	AfResultStatusTemporaryCongestion AfResultStatus = "TEMPORARY_CONGESTION"
+	AfResultStatusRelocNoAllowed      AfResultStatus = "RELOC_NO_ALLOWED"
+	AfResultStatusOther               AfResultStatus = "OTHER"
+)
+
+// TS 29.508 v17 5.6.2.30
+type AfResultInfo struct {
+	AfStatus     AfResultStatus          `json:"afStatus"`
+	TrafficRoute *models.RouteToLocation `json:"trafficRoute,omitempty"`
+	UpBuffInd    bool                    `json:"upBuffInd,omitempty"`
+}
+
+// AfAckInfo is the TS 29.522 v17 5.4.2.3.3 data type sent by the AF to the afAckUri
+type AfAckInfo struct {
+	AfTransId string       `json:"afTransId,omitempty"`
+	AckResult AfResultInfo `json:"ackResult"`
+	Gpsi      string       `json:"gpsi,omitempty"`
+}
+
+// AckOfNotify is the TS 29.508 v17 5.6.2.29 data type sent to the SMF's ackUri
+type AckOfNotify struct {
+	NotifId   string       `json:"notifId"`
+	AckResult AfResultInfo `json:"ackResult"`
+	Gpsi      string       `json:"gpsi,omitempty"`
+}
+
+// TrafficInfluNotifier sends the notifications to the AFs and the AF acknowledgements to the SMFs,
+// a request is bounded by the timeout of sbiClient config, so that one not responding doesn't hold
+// the goroutine forever.
+type TrafficInfluNotifier struct {
+	client *http.Client
+}
+
+func NewTrafficInfluNotifier(client *http.Client) (*TrafficInfluNotifier, error) {
+	return &TrafficInfluNotifier{
+		client: client,
+	}, nil
+}
+
+// Notify sends the notifications to the AF asynchronously, the result is logged with the given entry
+func (n *TrafficInfluNotifier) Notify(log *logrus.Entry, notifURI string, notifs []models.EventNotification) {
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				// Only this notification is lost, NEF keeps serving
+				logger.TrafInfluLog.Errorf("panic: %v\n%s", p, string(debug.Stack()))
+			}
+		}()
+
+		for i := range notifs {
+			if err := n.send(notifURI, &notifs[i]); err != nil {
+				log.Errorf("Notify AF[%s] failed: %+v", notifURI, err)
+				continue
+			}
+			log.Infof("Notify AF[%s] event[%s] successfully", notifURI, notifs[i].SubscribedEvent)
+		}
+	}()
+}
+
+// ForwardAfAck sends the AF acknowledgement to the SMF asynchronously, the result is logged with the given entry
+func (n *TrafficInfluNotifier) ForwardAfAck(log *logrus.Entry, ackURI string, ack *AckOfNotify) {
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				// Only this notification is lost, NEF keeps serving
+				logger.TrafInfluLog.Errorf("panic: %v\n%s", p, string(debug.Stack()))
+			}
+		}()
+
+		if err := n.send(ackURI, ack); err != nil {
+			log.Errorf("Forward AF ack to SMF[%s] failed: %+v", ackURI, err)
+			return
+		}
+		log.Infof("Forward AF ack[%s] to SMF[%s] successfully", ack.AckResult.AfStatus, ackURI)
+	}()
+}
+
+func (n *TrafficInfluNotifier) send(uri string, body interface{}) error {
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, uri, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	rsp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if rspCloseErr := rsp.Body.Close(); rspCloseErr != nil {
+			logger.TrafInfluLog.Errorf("Response body cannot close: %+v", rspCloseErr)
+		}
+	}()
+
+	if rsp.StatusCode < http.StatusOK || rsp.StatusCode >= http.StatusMultipleChoices {
+		rspBody, _ := io.ReadAll(rsp.Body)
+		return fmt.Errorf("unexpected status[%d]: %s", rsp.StatusCode, string(rspBody))
+	}
+	return nil
+}
@@ -4,6 +4,8 @@ import (
 	"net/http"
 
 	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/sbi/notifier"
+	"github.com/free5gc/nef/pkg/factory"
 	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
 	"github.com/gin-gonic/gin"
@@ -22,10 +24,116 @@ func (p *Processor) SmfNotification(
 		return
 	}
 
-	af.Mu.RLock()
-	defer af.Mu.RUnlock()
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
 
-	// TODO: Notify AF
+	if sub.TiSub == nil || sub.TiSub.NotificationDestination == "" {
+		sub.Log.Warnln("No notificationDestination, SMF notification is not forwarded to AF")
+		c.JSON(http.StatusNoContent, nil)
+		return
+	}
+
+	afAckUri := ""
+	if sub.TiSub.AfAckInd && eeNotif.AckUri != "" {
+		// The AF acknowledgement is relayed to the SMF by NEF
+		sub.SmfAckUri = eeNotif.AckUri
+		afAckUri = p.genAfAckUri(sub.NotifCorreID)
+		p.Context().SaveAf(af)
+	}
+
+	notifs := convertSmfEventNotifsToEventNotifications(sub.TiSub, eeNotif.EventNotifs, afAckUri)
+	if len(notifs) == 0 {
+		sub.Log.Warnln("No UP path change event in SMF notification")
+	} else {
+		p.Notifier().TrafficInfluNotifier.Notify(sub.Log, sub.TiSub.NotificationDestination, notifs)
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func convertSmfEventNotifsToEventNotifications(
+	tiSub *models.NefTrafficInfluSub,
+	eventNotifs []models.SmfEventExposureEventNotification,
+	afAckUri string,
+) []models.EventNotification {
+	notifs := make([]models.EventNotification, 0, len(eventNotifs))
+	for _, eventNotif := range eventNotifs {
+		if eventNotif.Event != models.SmfEvent_UP_PATH_CH {
+			continue
+		}
+		gpsi := eventNotif.Gpsi
+		if gpsi == "" {
+			gpsi = tiSub.Gpsi
+		}
+		ueMac := eventNotif.UeMac
+		if ueMac == "" {
+			ueMac = tiSub.MacAddr
+		}
+		notifs = append(notifs, models.EventNotification{
+			AfTransId:          tiSub.AfTransId,
+			DnaiChgType:        eventNotif.DnaiChgType,
+			SourceTrafficRoute: eventNotif.SourceTraRouting,
+			SubscribedEvent:    models.SubscribedEvent_UP_PATH_CHANGE,
+			TargetTrafficRoute: eventNotif.TargetTraRouting,
+			SourceDnai:         eventNotif.SourceDnai,
+			TargetDnai:         eventNotif.TargetDnai,
+			Gpsi:               gpsi,
+			SrcUeIpv4Addr:      eventNotif.SourceUeIpv4Addr,
+			SrcUeIpv6Prefix:    eventNotif.SourceUeIpv6Prefix,
+			TgtUeIpv4Addr:      eventNotif.TargetUeIpv4Addr,
+			TgtUeIpv6Prefix:    eventNotif.TargetUeIpv6Prefix,
+			UeMac:              ueMac,
+			AfAckUri:           afAckUri,
+		})
+	}
+	return notifs
+}
+
+func (p *Processor) AfAckNotification(
+	c *gin.Context,
+	notifCorreID string,
+	afAckInfo *notifier.AfAckInfo,
+) {
+	logger.TrafInfluLog.Infof("AfAckNotification - NotifCorreID[%s]", notifCorreID)
+
+	if afAckInfo.AckResult.AfStatus == "" {
+		pd := openapi.ProblemDetailsMalformedReqSyntax("Absent of AfAckInfo.ackResult.afStatus")
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+
+	af, sub := p.Context().FindAfSub(notifCorreID)
+	if sub == nil {
+		pd := openapi.ProblemDetailsDataNotFound("Subscrption is not found")
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	if sub.SmfAckUri == "" {
+		pd := openapi.ProblemDetailsDataNotFound("No notification is waiting for AF acknowledgement")
+		c.JSON(http.StatusNotFound, pd)
+		return
+	}
+
+	gpsi := afAckInfo.Gpsi
+	if gpsi == "" && sub.TiSub != nil {
+		gpsi = sub.TiSub.Gpsi
+	}
+	p.Notifier().TrafficInfluNotifier.ForwardAfAck(sub.Log, sub.SmfAckUri, &notifier.AckOfNotify{
+		NotifId:   sub.NotifCorreID,
+		AckResult: afAckInfo.AckResult,
+		Gpsi:      gpsi,
+	})
+	sub.SmfAckUri = ""
+	p.Context().SaveAf(af)
+
+	c.JSON(http.StatusNoContent, nil)
+}
 
-	c.JSON(http.StatusOK, nil)
+func (p *Processor) genAfAckUri(notifCorreID string) string {
+	// E.g. https://localhost:29505/nnef-callback/v1/notification/af-ack/{notifCorreID}
+	return p.Config().ServiceUri(factory.ServiceNefCallback) + "/notification/af-ack/" + notifCorreID
 }
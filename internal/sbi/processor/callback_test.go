@@ -0,0 +1,273 @@
+package processor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/free5gc/nef/internal/sbi/notifier"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestSmfNotification(t *testing.T) {
+	// Only the AF mock is removed, the NRF and UDR stubs registered in TestMain are kept
+	defer gock.Remove(initAFNotificationStub("http://af1NotifURI"))
+
+	// `afNotifChan` is used to pass the notification requests to AF intercepted by gock.
+	afNotifChan := make(chan *http.Request)
+	gock.Observe(func(request *http.Request, mock gock.Mock) {
+		if strings.Contains(request.URL.String(), "af1NotifURI") {
+			afNotifChan <- request
+		}
+	})
+	defer gock.Observe(nil)
+
+	tiSub := tiSub3ForAf1
+	tiSub.AfTransId = "afTrans1"
+	tiSub.DnaiChgType = models.DnaiChangeType_EARLY_LATE
+	tiSub.NotificationDestination = "http://af1NotifURI/notify"
+	tiSub.AfAckInd = true
+
+	nefCtx := nefApp.Context()
+	af1 := nefCtx.NewAf("af1")
+	af1.Mu.Lock()
+	afSub1 := af1.NewSub(nefCtx.NewCorreID(), &tiSub)
+	afSub1.AppSessID = "12345"
+	af1.Subs[afSub1.SubID] = afSub1
+	af1.Mu.Unlock()
+	nefCtx.AddAf(af1)
+	defer func() {
+		nefCtx.DeleteAf(af1.AfID)
+		nefCtx.ResetCorreID()
+	}()
+
+	upPathChNotif := models.SmfEventExposureEventNotification{
+		Event:       models.SmfEvent_UP_PATH_CH,
+		DnaiChgType: models.DnaiChangeType_EARLY,
+		SourceDnai:  "mec",
+		TargetDnai:  "mec5",
+		SourceTraRouting: &models.RouteToLocation{
+			Dnai: "mec",
+		},
+		TargetTraRouting: &models.RouteToLocation{
+			Dnai: "mec5",
+		},
+		SourceUeIpv4Addr: "10.60.0.10",
+		TargetUeIpv4Addr: "10.60.0.11",
+	}
+
+	testCases := []struct {
+		description          string
+		eeNotif              *models.NsmfEventExposureNotification
+		expectedResponse     *HandlerResponse
+		expectedNotification *models.EventNotification
+	}{
+		{
+			description: "TC1: Subscription found, should forward UP path change to AF",
+			eeNotif: &models.NsmfEventExposureNotification{
+				NotifId: afSub1.NotifCorreID,
+				EventNotifs: []models.SmfEventExposureEventNotification{
+					upPathChNotif,
+				},
+				AckUri: "http://smf1AckURI/ack",
+			},
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusNoContent,
+			},
+			expectedNotification: &models.EventNotification{
+				AfTransId:          "afTrans1",
+				DnaiChgType:        models.DnaiChangeType_EARLY,
+				SourceTrafficRoute: upPathChNotif.SourceTraRouting,
+				SubscribedEvent:    models.SubscribedEvent_UP_PATH_CHANGE,
+				TargetTrafficRoute: upPathChNotif.TargetTraRouting,
+				SourceDnai:         "mec",
+				TargetDnai:         "mec5",
+				SrcUeIpv4Addr:      "10.60.0.10",
+				TgtUeIpv4Addr:      "10.60.0.11",
+				AfAckUri: nefApp.Config().ServiceUri(factory.ServiceNefCallback) +
+					"/notification/af-ack/" + afSub1.NotifCorreID,
+			},
+		},
+		{
+			description: "TC2: Subscription not found, should return ProblemDetails",
+			eeNotif: &models.NsmfEventExposureNotification{
+				NotifId: "100",
+				EventNotifs: []models.SmfEventExposureEventNotification{
+					upPathChNotif,
+				},
+			},
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusNotFound,
+				Body: &models.ProblemDetails{
+					Status: http.StatusNotFound,
+					Title:  "Data not found",
+					Detail: "Subscrption is not found",
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			httpRecorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(httpRecorder)
+
+			nefApp.Processor().SmfNotification(c, tc.eeNotif)
+			require.Equal(t, tc.expectedResponse.Status, httpRecorder.Code)
+			assertJSONBodyEqual(t, tc.expectedResponse.Body, httpRecorder.Body.Bytes())
+
+			if tc.expectedNotification == nil {
+				return
+			}
+			select {
+			case r := <-afNotifChan:
+				var notif models.EventNotification
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&notif))
+				require.Equal(t, *tc.expectedNotification, notif)
+			case <-time.After(3 * time.Second):
+				t.Fatal("AF notification is not received")
+			}
+		})
+	}
+}
+
+func TestAfAckNotification(t *testing.T) {
+	defer gock.Remove(initSMFAckStub("http://smf1AckURI"))
+
+	// `smfAckChan` is used to pass the acknowledgement requests to SMF intercepted by gock.
+	smfAckChan := make(chan *http.Request)
+	gock.Observe(func(request *http.Request, mock gock.Mock) {
+		if strings.Contains(request.URL.String(), "smf1AckURI") {
+			smfAckChan <- request
+		}
+	})
+	defer gock.Observe(nil)
+
+	tiSub := tiSub3ForAf1
+	tiSub.AfAckInd = true
+
+	nefCtx := nefApp.Context()
+	af1 := nefCtx.NewAf("af1")
+	af1.Mu.Lock()
+	afSub1 := af1.NewSub(nefCtx.NewCorreID(), &tiSub)
+	afSub1.AppSessID = "12345"
+	afSub1.SmfAckUri = "http://smf1AckURI/ack"
+	af1.Subs[afSub1.SubID] = afSub1
+	af1.Mu.Unlock()
+	nefCtx.AddAf(af1)
+	defer func() {
+		nefCtx.DeleteAf(af1.AfID)
+		nefCtx.ResetCorreID()
+	}()
+
+	afAckInfo := &notifier.AfAckInfo{
+		AckResult: notifier.AfResultInfo{
+			AfStatus: notifier.AfResultStatusSuccess,
+		},
+	}
+
+	testCases := []struct {
+		description      string
+		notifCorreID     string
+		afAckInfo        *notifier.AfAckInfo
+		expectedResponse *HandlerResponse
+		expectedAck      *notifier.AckOfNotify
+	}{
+		{
+			description:  "TC1: Pending notification found, should forward AF ack to SMF",
+			notifCorreID: afSub1.NotifCorreID,
+			afAckInfo:    afAckInfo,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusNoContent,
+			},
+			expectedAck: &notifier.AckOfNotify{
+				NotifId:   afSub1.NotifCorreID,
+				AckResult: afAckInfo.AckResult,
+				Gpsi:      tiSub.Gpsi,
+			},
+		},
+		{
+			description:  "TC2: No pending notification, should return ProblemDetails",
+			notifCorreID: afSub1.NotifCorreID,
+			afAckInfo:    afAckInfo,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusNotFound,
+				Body: &models.ProblemDetails{
+					Status: http.StatusNotFound,
+					Title:  "Data not found",
+					Detail: "No notification is waiting for AF acknowledgement",
+				},
+			},
+		},
+		{
+			description:  "TC3: Subscription not found, should return ProblemDetails",
+			notifCorreID: "100",
+			afAckInfo:    afAckInfo,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusNotFound,
+				Body: &models.ProblemDetails{
+					Status: http.StatusNotFound,
+					Title:  "Data not found",
+					Detail: "Subscrption is not found",
+				},
+			},
+		},
+		{
+			description:  "TC4: Absent of afStatus, should return ProblemDetails",
+			notifCorreID: afSub1.NotifCorreID,
+			afAckInfo:    &notifier.AfAckInfo{},
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusBadRequest,
+				Body: &models.ProblemDetails{
+					Status: http.StatusBadRequest,
+					Title:  "Malformed request syntax",
+					Detail: "Absent of AfAckInfo.ackResult.afStatus",
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			httpRecorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(httpRecorder)
+
+			nefApp.Processor().AfAckNotification(c, tc.notifCorreID, tc.afAckInfo)
+			require.Equal(t, tc.expectedResponse.Status, httpRecorder.Code)
+			assertJSONBodyEqual(t, tc.expectedResponse.Body, httpRecorder.Body.Bytes())
+
+			if tc.expectedAck == nil {
+				return
+			}
+			select {
+			case r := <-smfAckChan:
+				var ack notifier.AckOfNotify
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&ack))
+				require.Equal(t, *tc.expectedAck, ack)
+			case <-time.After(3 * time.Second):
+				t.Fatal("AF ack is not received by SMF")
+			}
+		})
+	}
+}
+
+func initSMFAckStub(ackURI string) gock.Mock {
+	return gock.New(ackURI).
+		Post("/ack").
+		Persist().
+		Reply(http.StatusNoContent).Mock
+}
+
+func initAFNotificationStub(notifyURI string) gock.Mock {
+	return gock.New(notifyURI).
+		Post("/notify").
+		Persist().
+		Reply(http.StatusNoContent).Mock
+}
@@ -0,0 +1,366 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	nef_context "github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/sbi/consumer"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi/models"
+)
+
+const (
+	CapifApiVersion = "v1"
+	// The API invoker authorized by CAPIF is not checked again within the cache time
+	CapifAuthorizationCacheTime = time.Minute
+	// The oldest logs are dropped if CAPIF can't receive them in time
+	CapifMaxPendingLogs = 10000
+)
+
+var (
+	_ nef_context.InvocationLogReporter = &Processor{}
+	_ nef_context.ServiceAPIPublisher   = &Processor{}
+)
+
+// capifServiceAPI is a northbound API published to CAPIF,
+// a new northbound API is published by adding it to capifServiceAPIs and registering its routes.
+type capifServiceAPI struct {
+	serviceName string
+	description string
+}
+
+var capifServiceAPIs = []capifServiceAPI{
+	{
+		serviceName: factory.ServiceTraffInflu,
+		description: "TS 29.522 TrafficInfluence API",
+	},
+	{
+		serviceName: factory.ServicePfdMng,
+		description: "TS 29.122 PfdManagement API",
+	},
+}
+
+// CapifRoute is a route of a northbound API published to CAPIF,
+// the routes with the same resource name are published as one resource with their methods as operations.
+type CapifRoute struct {
+	ResourceName string
+	Method       string
+	Pattern      string // route pattern relative to the API root, e.g. "/:afID/subscriptions"
+}
+
+type capifServiceResource struct {
+	name       string
+	pattern    string
+	operations []string
+}
+
+var routeParamRegexp = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+// capifAef is the state of NEF as API exposing function of CAPIF
+type capifAef struct {
+	mu         sync.Mutex
+	apiIDs     map[string]string              // serviceName -> apiId allocated by CAPIF
+	authorized map[string]time.Time           // apiInvokerId + serviceName -> time until authorized
+	logs       map[string][]consumer.CapifLog // apiInvokerId -> logs not reported yet
+	numLogs    int
+}
+
+func newCapifAef() *capifAef {
+	return &capifAef{
+		apiIDs:     make(map[string]string),
+		authorized: make(map[string]time.Time),
+		logs:       make(map[string][]consumer.CapifLog),
+	}
+}
+
+// SetCapifRoutes sets the route table of the northbound API, which is published to CAPIF.
+// It shall be called before the server is run.
+func (p *Processor) SetCapifRoutes(serviceName string, routes []CapifRoute) {
+	p.capifRoutes[serviceName] = routes
+}
+
+// capifResources groups the routes of the northbound API into resources in the order of the route table
+func (p *Processor) capifResources(serviceName string) []capifServiceResource {
+	var resources []capifServiceResource
+	index := make(map[string]int)
+	for _, route := range p.capifRoutes[serviceName] {
+		if route.ResourceName == "" {
+			continue
+		}
+		i, ok := index[route.ResourceName]
+		if !ok {
+			i = len(resources)
+			index[route.ResourceName] = i
+			resources = append(resources, capifServiceResource{
+				name:    route.ResourceName,
+				pattern: route.Pattern,
+			})
+		}
+		resources[i].operations = append(resources[i].operations, route.Method)
+	}
+	return resources
+}
+
+func (p *Processor) newServiceAPIDescription(api *capifServiceAPI, aefID string) *consumer.ServiceAPIDescription {
+	cfg := p.Config()
+	version := consumer.CapifVersion{
+		ApiVersion: CapifApiVersion,
+	}
+	for _, r := range p.capifResources(api.serviceName) {
+		version.Resources = append(version.Resources, consumer.CapifResource{
+			ResourceName: r.name,
+			CommType:     consumer.CapifCommTypeRequestResponse,
+			// e.g. "/{afID}/subscriptions"
+			Uri:        routeParamRegexp.ReplaceAllString(r.pattern, "{$1}"),
+			Operations: r.operations,
+		})
+	}
+
+	var secMethods []consumer.CapifSecurityMethod
+	if cfg.NorthboundOAuth2() != nil {
+		secMethods = append(secMethods, consumer.CapifSecurityMethodOauth)
+	}
+	if clientAuth, _ := cfg.TlsClientAuth(); clientAuth != factory.TlsClientAuthNone {
+		secMethods = append(secMethods, consumer.CapifSecurityMethodPki)
+	}
+
+	return &consumer.ServiceAPIDescription{
+		ApiName:     api.serviceName,
+		Description: api.description,
+		AefProfiles: []consumer.AefProfile{
+			{
+				AefId:           aefID,
+				Versions:        []consumer.CapifVersion{version},
+				Protocol:        capifProtocol(cfg),
+				DataFormat:      consumer.CapifDataFormatJson,
+				SecurityMethods: secMethods,
+				InterfaceDescriptions: []consumer.InterfaceDescription{
+					{
+						Ipv4Addr:        cfg.SbiRegisterIP(),
+						Port:            cfg.SbiPort(),
+						SecurityMethods: secMethods,
+					},
+				},
+			},
+		},
+	}
+}
+
+func capifProtocol(cfg *factory.Config) consumer.CapifProtocol {
+	if cfg.SbiScheme() == "https" {
+		return consumer.CapifProtocolHttp2
+	}
+	return consumer.CapifProtocolHttp11
+}
+
+// PublishServiceAPIs publishes the northbound APIs to CAPIF, so that API invokers can discover them.
+// The APIs already published are skipped, so it can be retried until all of them are published.
+func (p *Processor) PublishServiceAPIs() error {
+	capif := p.Config().Capif()
+	if capif == nil {
+		return nil
+	}
+
+	var errs []error
+	for i := range capifServiceAPIs {
+		api := &capifServiceAPIs[i]
+		p.capif.mu.Lock()
+		_, ok := p.capif.apiIDs[api.serviceName]
+		p.capif.mu.Unlock()
+		if ok {
+			continue
+		}
+
+		desc := p.newServiceAPIDescription(api, capif.AefId)
+		rspCode, rspBody := p.Consumer().PublishServiceAPI(capif.ApfId, desc)
+		if rspCode != http.StatusCreated {
+			errs = append(errs, fmt.Errorf("publish API[%s] failed: status[%d], body[%+v]",
+				api.serviceName, rspCode, rspBody))
+			continue
+		}
+		published := rspBody.(*consumer.ServiceAPIDescription)
+
+		p.capif.mu.Lock()
+		p.capif.apiIDs[api.serviceName] = published.ApiId
+		p.capif.mu.Unlock()
+		logger.MainLog.Infof("API[%s] is published to CAPIF with apiId[%s]", api.serviceName, published.ApiId)
+	}
+	return errors.Join(errs...)
+}
+
+// UnpublishServiceAPIs removes the published northbound APIs from CAPIF
+func (p *Processor) UnpublishServiceAPIs() {
+	capif := p.Config().Capif()
+	if capif == nil {
+		return
+	}
+
+	p.capif.mu.Lock()
+	defer p.capif.mu.Unlock()
+
+	for serviceName, apiID := range p.capif.apiIDs {
+		rspCode, rspBody := p.Consumer().UnpublishServiceAPI(capif.ApfId, apiID)
+		if rspCode != http.StatusNoContent && rspCode != http.StatusNotFound {
+			logger.MainLog.Errorf("Unpublish API[%s] failed: status[%d], body[%+v]", serviceName, rspCode, rspBody)
+			continue
+		}
+		delete(p.capif.apiIDs, serviceName)
+	}
+}
+
+// AuthorizeApiInvoker checks whether the API invoker (i.e. the AF) is authorized by CAPIF to invoke the service,
+// the result is cached for CapifAuthorizationCacheTime.
+func (p *Processor) AuthorizeApiInvoker(apiInvokerID, serviceName string, now time.Time) *HandlerResponse {
+	capif := p.Config().Capif()
+	if capif == nil {
+		return nil
+	}
+
+	key := apiInvokerID + "/" + serviceName
+	p.capif.mu.Lock()
+	until, ok := p.capif.authorized[key]
+	apiID := p.capif.apiIDs[serviceName]
+	p.capif.mu.Unlock()
+	if ok && now.Before(until) {
+		return nil
+	}
+
+	rspCode, rspBody := p.Consumer().GetTrustedInvoker(apiInvokerID)
+	switch rspCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return newForbiddenResponse(fmt.Sprintf("API invoker[%s] is not authorized by CAPIF", apiInvokerID))
+	default:
+		return &HandlerResponse{rspCode, nil, rspBody}
+	}
+
+	for _, secInfo := range rspBody.(*consumer.ServiceSecurity).SecurityInfo {
+		if secInfo.AefId != "" && secInfo.AefId != capif.AefId {
+			continue
+		}
+		if secInfo.ApiId != "" && secInfo.ApiId != apiID {
+			continue
+		}
+		p.capif.mu.Lock()
+		p.capif.authorized[key] = now.Add(CapifAuthorizationCacheTime)
+		p.capif.mu.Unlock()
+		return nil
+	}
+	return newForbiddenResponse(fmt.Sprintf("API invoker[%s] is not authorized by CAPIF for API[%s]",
+		apiInvokerID, serviceName))
+}
+
+func newForbiddenResponse(detail string) *HandlerResponse {
+	pd := &models.ProblemDetails{
+		Title:  "Forbidden",
+		Status: http.StatusForbidden,
+		Detail: detail,
+	}
+	return &HandlerResponse{http.StatusForbidden, nil, pd}
+}
+
+// RecordApiInvocation keeps the log of an invocation of the northbound API until it's reported to CAPIF.
+// The pattern is the route pattern relative to the API root.
+func (p *Processor) RecordApiInvocation(
+	apiInvokerID, serviceName, pattern, uri, method string,
+	status int,
+	invocationTime time.Time,
+) {
+	if p.Config().Capif() == nil {
+		return
+	}
+
+	var resourceName string
+	for _, route := range p.capifRoutes[serviceName] {
+		if route.Pattern == pattern {
+			resourceName = route.ResourceName
+		}
+	}
+
+	p.capif.mu.Lock()
+	defer p.capif.mu.Unlock()
+
+	apiID, ok := p.capif.apiIDs[serviceName]
+	if !ok || resourceName == "" {
+		// The API or the resource is not published
+		return
+	}
+	if p.capif.numLogs >= CapifMaxPendingLogs {
+		p.dropOldestInvocationLog()
+	}
+	p.capif.logs[apiInvokerID] = append(p.capif.logs[apiInvokerID], consumer.CapifLog{
+		ApiName:        serviceName,
+		ApiId:          apiID,
+		ApiVersion:     CapifApiVersion,
+		ResourceName:   resourceName,
+		Uri:            uri,
+		Protocol:       capifProtocol(p.Config()),
+		Operation:      method,
+		Result:         strconv.Itoa(status),
+		InvocationTime: &invocationTime,
+	})
+	p.capif.numLogs++
+}
+
+// dropOldestInvocationLog drops the oldest log, p.capif.mu shall be locked by the caller
+func (p *Processor) dropOldestInvocationLog() {
+	var oldestInvokerID string
+	var oldest *time.Time
+	for invokerID, logs := range p.capif.logs {
+		if oldest == nil || logs[0].InvocationTime.Before(*oldest) {
+			oldestInvokerID, oldest = invokerID, logs[0].InvocationTime
+		}
+	}
+	if oldest == nil {
+		return
+	}
+	if logs := p.capif.logs[oldestInvokerID][1:]; len(logs) > 0 {
+		p.capif.logs[oldestInvokerID] = logs
+	} else {
+		delete(p.capif.logs, oldestInvokerID)
+	}
+	p.capif.numLogs--
+}
+
+// ReportInvocationLogs reports the pending API invocation logs to CAPIF,
+// the logs of an API invoker are kept for next report if CAPIF fails to receive them.
+func (p *Processor) ReportInvocationLogs() {
+	capif := p.Config().Capif()
+	if capif == nil {
+		return
+	}
+
+	p.capif.mu.Lock()
+	pending := p.capif.logs
+	p.capif.logs = make(map[string][]consumer.CapifLog)
+	p.capif.numLogs = 0
+	p.capif.mu.Unlock()
+
+	for apiInvokerID, logs := range pending {
+		rspCode, rspBody := p.Consumer().ReportInvocationLog(capif.AefId, &consumer.InvocationLog{
+			AefId:        capif.AefId,
+			ApiInvokerId: apiInvokerID,
+			Logs:         logs,
+		})
+		if rspCode == http.StatusCreated || rspCode == http.StatusOK || rspCode == http.StatusNoContent {
+			continue
+		}
+		logger.MainLog.Errorf("Report %d invocation logs of API invoker[%s] failed: status[%d], body[%+v]",
+			len(logs), apiInvokerID, rspCode, rspBody)
+
+		p.capif.mu.Lock()
+		p.capif.logs[apiInvokerID] = append(logs, p.capif.logs[apiInvokerID]...)
+		p.capif.numLogs += len(logs)
+		for p.capif.numLogs > CapifMaxPendingLogs {
+			p.dropOldestInvocationLog()
+		}
+		p.capif.mu.Unlock()
+	}
+}
@@ -0,0 +1,295 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/free5gc/nef/internal/sbi/consumer"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi/models"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+const capifUri = "http://127.0.0.30:8000"
+
+func setCapifConfig() func() {
+	cfg := nefApp.Config()
+	cfg.Configuration.Capif = &factory.Capif{
+		Uri:   capifUri,
+		ApfId: "apf1",
+		AefId: "aef1",
+	}
+	// The route tables are registered by the server, which isn't run in the tests
+	p := nefApp.Processor()
+	p.SetCapifRoutes(factory.ServiceTraffInflu, []CapifRoute{
+		{"TRAFFIC_INFLUENCE_SUBSCRIPTIONS", http.MethodGet, "/:afID/subscriptions"},
+		{"TRAFFIC_INFLUENCE_SUBSCRIPTIONS", http.MethodPost, "/:afID/subscriptions"},
+		{"INDIVIDUAL_TRAFFIC_INFLUENCE_SUBSCRIPTION", http.MethodGet, "/:afID/subscriptions/:subID"},
+		{"INDIVIDUAL_TRAFFIC_INFLUENCE_SUBSCRIPTION", http.MethodPut, "/:afID/subscriptions/:subID"},
+		{"INDIVIDUAL_TRAFFIC_INFLUENCE_SUBSCRIPTION", http.MethodPatch, "/:afID/subscriptions/:subID"},
+		{"INDIVIDUAL_TRAFFIC_INFLUENCE_SUBSCRIPTION", http.MethodDelete, "/:afID/subscriptions/:subID"},
+	})
+	p.SetCapifRoutes(factory.ServicePfdMng, []CapifRoute{
+		{"PFD_MANAGEMENT_TRANSACTIONS", http.MethodGet, "/:scsAsID/transactions"},
+		{"PFD_MANAGEMENT_TRANSACTIONS", http.MethodPost, "/:scsAsID/transactions"},
+	})
+	return func() {
+		cfg.Configuration.Capif = nil
+		p.capif = newCapifAef()
+		p.capifRoutes = make(map[string][]CapifRoute)
+	}
+}
+
+func TestPublishServiceAPIs(t *testing.T) {
+	defer setCapifConfig()()
+	defer gock.Remove(initCapifPublishStub(factory.ServiceTraffInflu, "api1"))
+	defer gock.Remove(initCapifPublishStub(factory.ServicePfdMng, "api2"))
+
+	// `published` is used to pass the ServiceAPIDescriptions intercepted by gock.
+	published := make(map[string]consumer.ServiceAPIDescription)
+	gock.Observe(func(request *http.Request, mock gock.Mock) {
+		var desc consumer.ServiceAPIDescription
+		require.NoError(t, json.NewDecoder(request.Body).Decode(&desc))
+		published[desc.ApiName] = desc
+	})
+	defer gock.Observe(nil)
+
+	require.NoError(t, nefApp.Processor().PublishServiceAPIs())
+	require.Equal(t, map[string]string{
+		factory.ServiceTraffInflu: "api1",
+		factory.ServicePfdMng:     "api2",
+	}, nefApp.Processor().capif.apiIDs)
+
+	require.Equal(t, consumer.ServiceAPIDescription{
+		ApiName:     factory.ServiceTraffInflu,
+		Description: "TS 29.522 TrafficInfluence API",
+		AefProfiles: []consumer.AefProfile{
+			{
+				AefId: "aef1",
+				Versions: []consumer.CapifVersion{
+					{
+						ApiVersion: CapifApiVersion,
+						Resources: []consumer.CapifResource{
+							{
+								ResourceName: "TRAFFIC_INFLUENCE_SUBSCRIPTIONS",
+								CommType:     consumer.CapifCommTypeRequestResponse,
+								Uri:          "/{afID}/subscriptions",
+								Operations:   []string{http.MethodGet, http.MethodPost},
+							},
+							{
+								ResourceName: "INDIVIDUAL_TRAFFIC_INFLUENCE_SUBSCRIPTION",
+								CommType:     consumer.CapifCommTypeRequestResponse,
+								Uri:          "/{afID}/subscriptions/{subID}",
+								Operations: []string{
+									http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete,
+								},
+							},
+						},
+					},
+				},
+				Protocol:   consumer.CapifProtocolHttp11,
+				DataFormat: consumer.CapifDataFormatJson,
+				InterfaceDescriptions: []consumer.InterfaceDescription{
+					{
+						Ipv4Addr: "127.0.0.5",
+						Port:     8000,
+					},
+				},
+			},
+		},
+	}, published[factory.ServiceTraffInflu])
+	require.Contains(t, published, factory.ServicePfdMng)
+}
+
+func TestRunServiceAPIPublisher(t *testing.T) {
+	defer setCapifConfig()()
+
+	// CAPIF fails to publish the PfdManagement API at first, only it should be published again
+	defer gock.Remove(initCapifPublishStub(factory.ServiceTraffInflu, "api1"))
+	failedMock := gock.New(capifUri).
+		Post(consumer.CapifPublishResUriPrefix + "/apf1/service-apis").
+		BodyString(`"apiName":"` + factory.ServicePfdMng + `"`).
+		Reply(http.StatusServiceUnavailable).
+		JSON(models.ProblemDetails{Status: http.StatusServiceUnavailable}).Mock
+	defer gock.Remove(failedMock)
+	// Matched after the failed one is consumed
+	defer gock.Remove(initCapifPublishStub(factory.ServicePfdMng, "api2"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	nefApp.Context().RunServiceAPIPublisher(ctx, &wg, nefApp.Processor())
+
+	wg.Wait()
+	require.True(t, failedMock.Done())
+	require.Equal(t, map[string]string{
+		factory.ServiceTraffInflu: "api1",
+		factory.ServicePfdMng:     "api2",
+	}, nefApp.Processor().capif.apiIDs)
+}
+
+func TestAuthorizeApiInvoker(t *testing.T) {
+	defer setCapifConfig()()
+	nefApp.Processor().capif.apiIDs[factory.ServiceTraffInflu] = "api1"
+
+	now := time.Now()
+	testCases := []struct {
+		description      string
+		apiInvokerID     string
+		capifStatus      int
+		securityInfo     []consumer.SecurityInformation
+		now              time.Time
+		expectedResponse *HandlerResponse
+	}{
+		{
+			description:  "TC1: API invoker authorized for the API, should be allowed",
+			apiInvokerID: "af1",
+			capifStatus:  http.StatusOK,
+			securityInfo: []consumer.SecurityInformation{
+				{
+					AefId:               "aef1",
+					ApiId:               "api1",
+					PrefSecurityMethods: []consumer.CapifSecurityMethod{consumer.CapifSecurityMethodOauth},
+				},
+			},
+			now: now,
+		},
+		{
+			description:  "TC2: API invoker authorized recently, should be allowed without asking CAPIF",
+			apiInvokerID: "af1",
+			now:          now.Add(CapifAuthorizationCacheTime / 2),
+		},
+		{
+			description:  "TC3: API invoker authorized for other API only, should return 403",
+			apiInvokerID: "af2",
+			capifStatus:  http.StatusOK,
+			securityInfo: []consumer.SecurityInformation{
+				{
+					AefId:               "aef1",
+					ApiId:               "api2",
+					PrefSecurityMethods: []consumer.CapifSecurityMethod{consumer.CapifSecurityMethodOauth},
+				},
+			},
+			now: now,
+			expectedResponse: newForbiddenResponse(
+				"API invoker[af2] is not authorized by CAPIF for API[" + factory.ServiceTraffInflu + "]"),
+		},
+		{
+			description:      "TC4: API invoker unknown to CAPIF, should return 403",
+			apiInvokerID:     "af3",
+			capifStatus:      http.StatusNotFound,
+			now:              now,
+			expectedResponse: newForbiddenResponse("API invoker[af3] is not authorized by CAPIF"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			var mock gock.Mock
+			if tc.capifStatus != 0 {
+				mock = initCapifTrustedInvokerStub(tc.apiInvokerID, tc.capifStatus, tc.securityInfo)
+				defer gock.Remove(mock)
+			}
+			rsp := nefApp.Processor().AuthorizeApiInvoker(tc.apiInvokerID, factory.ServiceTraffInflu, tc.now)
+			require.Equal(t, tc.expectedResponse, rsp)
+			if mock != nil {
+				require.True(t, mock.Done())
+			}
+		})
+	}
+}
+
+func TestReportInvocationLogs(t *testing.T) {
+	defer setCapifConfig()()
+	nefApp.Processor().capif.apiIDs[factory.ServicePfdMng] = "api2"
+
+	invocationTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := nefApp.Processor()
+	p.RecordApiInvocation("af1", factory.ServicePfdMng, "/:scsAsID/transactions",
+		"http://127.0.0.5:8000/3gpp-pfd-management/v1/af1/transactions", http.MethodPost,
+		http.StatusCreated, invocationTime)
+	// Not published, should not be recorded
+	p.RecordApiInvocation("af1", factory.ServiceTraffInflu, "/:afID/subscriptions",
+		"http://127.0.0.5:8000/3gpp-traffic-influence/v1/af1/subscriptions", http.MethodPost,
+		http.StatusCreated, invocationTime)
+
+	expectedLog := consumer.InvocationLog{
+		AefId:        "aef1",
+		ApiInvokerId: "af1",
+		Logs: []consumer.CapifLog{
+			{
+				ApiName:        factory.ServicePfdMng,
+				ApiId:          "api2",
+				ApiVersion:     CapifApiVersion,
+				ResourceName:   "PFD_MANAGEMENT_TRANSACTIONS",
+				Uri:            "http://127.0.0.5:8000/3gpp-pfd-management/v1/af1/transactions",
+				Protocol:       consumer.CapifProtocolHttp11,
+				Operation:      http.MethodPost,
+				Result:         "201",
+				InvocationTime: &invocationTime,
+			},
+		},
+	}
+
+	// CAPIF fails to receive the logs, should be kept for next report
+	mock := initCapifLoggingStub(http.StatusInternalServerError)
+	defer gock.Remove(mock)
+	p.ReportInvocationLogs()
+	require.True(t, mock.Done())
+	require.Equal(t, 1, p.capif.numLogs)
+
+	// `reported` is used to pass the InvocationLog intercepted by gock.
+	var reported consumer.InvocationLog
+	gock.Observe(func(request *http.Request, mock gock.Mock) {
+		require.NoError(t, json.NewDecoder(request.Body).Decode(&reported))
+	})
+	defer gock.Observe(nil)
+
+	mock = initCapifLoggingStub(http.StatusCreated)
+	defer gock.Remove(mock)
+	p.ReportInvocationLogs()
+	require.True(t, mock.Done())
+	require.Equal(t, 0, p.capif.numLogs)
+	require.Equal(t, expectedLog, reported)
+}
+
+func initCapifPublishStub(apiName, apiID string) gock.Mock {
+	return gock.New(capifUri).
+		Post(consumer.CapifPublishResUriPrefix + "/apf1/service-apis").
+		BodyString(`"apiName":"` + apiName + `"`).
+		Reply(http.StatusCreated).
+		JSON(consumer.ServiceAPIDescription{
+			ApiName: apiName,
+			ApiId:   apiID,
+		}).Mock
+}
+
+func initCapifTrustedInvokerStub(
+	apiInvokerID string, status int, securityInfo []consumer.SecurityInformation,
+) gock.Mock {
+	rsp := gock.New(capifUri).
+		Get(consumer.CapifSecurityResUriPrefix + "/trustedInvokers/" + apiInvokerID).
+		Reply(status)
+	if status == http.StatusOK {
+		rsp.JSON(consumer.ServiceSecurity{
+			SecurityInfo:            securityInfo,
+			NotificationDestination: "http://invoker/notify",
+		})
+	} else {
+		rsp.JSON(models.ProblemDetails{
+			Status: int32(status),
+		})
+	}
+	return rsp.Mock
+}
+
+func initCapifLoggingStub(status int) gock.Mock {
+	return gock.New(capifUri).
+		Post(consumer.CapifLoggingResUriPrefix + "/aef1/logs").
+		Reply(status).Mock
+}
@@ -0,0 +1,122 @@
+package processor
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	nef_context "github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+)
+
+var _ nef_context.ExpiryHandler = &Processor{}
+
+// ResourceExpiry is the lifetime of a TrafficInfluSub or PfdManagement, which is not defined by TS 29.522.
+// It's carried as the vendor-specific attribute "vendorSpecific-000000" of the resource (TS 29.500 clause 6.6.3),
+// e.g. {"vendorSpecific-000000": {"expiry": "2025-01-01T00:00:00Z"}}. In the creation request it is the expiry
+// requested by AF, in the responses it is the expiry granted by NEF. AFs not aware of it ignore the attribute
+// as an unknown one.
+type ResourceExpiry struct {
+	Expiry *time.Time `json:"expiry,omitempty"`
+}
+
+// VendorSpecificExpiry holds the vendor-specific attribute of ResourceExpiry.
+// 000000 stands for the vendor ID, since NEF has no IANA-assigned enterprise code.
+type VendorSpecificExpiry struct {
+	ResourceExpiry *ResourceExpiry `json:"vendorSpecific-000000,omitempty"`
+}
+
+// RequestedExpiry returns nil if AF doesn't request an expiry
+func (v *VendorSpecificExpiry) RequestedExpiry() *time.Time {
+	if v.ResourceExpiry == nil {
+		return nil
+	}
+	return v.ResourceExpiry.Expiry
+}
+
+func newVendorSpecificExpiry(expiry *time.Time) VendorSpecificExpiry {
+	if expiry == nil {
+		return VendorSpecificExpiry{}
+	}
+	return VendorSpecificExpiry{
+		ResourceExpiry: &ResourceExpiry{Expiry: expiry},
+	}
+}
+
+type trafficInfluSubWithExpiry struct {
+	*models.NefTrafficInfluSub
+	VendorSpecificExpiry
+}
+
+type pfdManagementWithExpiry struct {
+	*models.PfdManagement
+	VendorSpecificExpiry
+}
+
+func newTrafficInfluSubWithExpiry(afSub *nef_context.AfSubscription) *trafficInfluSubWithExpiry {
+	return &trafficInfluSubWithExpiry{
+		NefTrafficInfluSub:   afSub.TiSub,
+		VendorSpecificExpiry: newVendorSpecificExpiry(afSub.Expiry),
+	}
+}
+
+func newPfdManagementWithExpiry(
+	pfdMng *models.PfdManagement,
+	afPfdTr *nef_context.AfPfdTransaction,
+) *pfdManagementWithExpiry {
+	return &pfdManagementWithExpiry{
+		PfdManagement:        pfdMng,
+		VendorSpecificExpiry: newVendorSpecificExpiry(afPfdTr.Expiry),
+	}
+}
+
+// grantExpiry returns the expiry granted to a new resource of the service, the requested expiry is
+// shortened to the maximum lifetime and the default lifetime is used if AF doesn't request one.
+// A nil expiry means the resource never expires.
+func (p *Processor) grantExpiry(
+	serviceName string,
+	requested *time.Time,
+	now time.Time,
+) (*time.Time, *HandlerResponse) {
+	defLifetime, maxLifetime := p.Config().ResourceLifetime(serviceName)
+
+	var granted time.Time
+	switch {
+	case requested != nil:
+		if !requested.After(now) {
+			pd := openapi.ProblemDetailsMalformedReqSyntax(
+				fmt.Sprintf("Requested expiry[%s] is not in the future", requested.Format(time.RFC3339)))
+			return nil, &HandlerResponse{http.StatusBadRequest, nil, pd}
+		}
+		granted = *requested
+	case defLifetime > 0:
+		granted = now.Add(defLifetime)
+	default:
+		return nil, nil
+	}
+
+	if maxLifetime > 0 && granted.After(now.Add(maxLifetime)) {
+		granted = now.Add(maxLifetime)
+	}
+	return &granted, nil
+}
+
+func (p *Processor) ExpirePfdTrans(af *nef_context.AfData, pfdTr *nef_context.AfPfdTransaction) error {
+	pfdNotifyContext := p.Notifier().PfdChangeNotifier.NewPfdNotifyContext()
+	defer pfdNotifyContext.FlushNotifications()
+
+	for _, extAppID := range pfdTr.GetExtAppIDs() {
+		rsp := p.deletePfdDataFromUDR(extAppID)
+		if rsp != nil && rsp.Status != http.StatusNotFound {
+			return fmt.Errorf("delete PFD data of appID[%s] failed: status[%d], body[%+v]",
+				extAppID, rsp.Status, rsp.Body)
+		}
+		pfdTr.DeleteExtAppID(extAppID)
+		pfdNotifyContext.AddNotification(extAppID, &models.PfdChangeNotification{
+			ApplicationId: extAppID,
+			RemovalFlag:   true,
+		})
+	}
+	return nil
+}
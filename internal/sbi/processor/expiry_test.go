@@ -0,0 +1,283 @@
+package processor
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	nef_context "github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/nef/internal/sbi/notifier"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestGrantExpiry(t *testing.T) {
+	cfg := nefApp.Config()
+	cfg.Configuration.ResourceLifetimes = []factory.ResourceLifetime{
+		{
+			ServiceName:     factory.ServiceTraffInflu,
+			DefaultLifetime: 3600,
+			MaxLifetime:     7200,
+		},
+	}
+	defer func() {
+		cfg.Configuration.ResourceLifetimes = nil
+	}()
+
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	soon := now.Add(30 * time.Minute)
+	defaultExpiry := now.Add(time.Hour)
+	maxExpiry := now.Add(2 * time.Hour)
+	farFuture := now.Add(3 * time.Hour)
+
+	testCases := []struct {
+		description      string
+		serviceName      string
+		reqExpiry        *time.Time
+		expectedExpiry   *time.Time
+		expectedResponse *HandlerResponse
+	}{
+		{
+			description:    "TC1: No requested expiry, should grant default lifetime",
+			serviceName:    factory.ServiceTraffInflu,
+			expectedExpiry: &defaultExpiry,
+		},
+		{
+			description:    "TC2: Requested expiry within maximum lifetime, should be granted",
+			serviceName:    factory.ServiceTraffInflu,
+			reqExpiry:      &soon,
+			expectedExpiry: &soon,
+		},
+		{
+			description:    "TC3: Requested expiry beyond maximum lifetime, should be shortened",
+			serviceName:    factory.ServiceTraffInflu,
+			reqExpiry:      &farFuture,
+			expectedExpiry: &maxExpiry,
+		},
+		{
+			description: "TC4: Requested expiry in the past, should return ProblemDetails",
+			serviceName: factory.ServiceTraffInflu,
+			reqExpiry:   &past,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusBadRequest,
+				Body: openapi.ProblemDetailsMalformedReqSyntax(
+					"Requested expiry[" + past.Format(time.RFC3339) + "] is not in the future"),
+			},
+		},
+		{
+			description: "TC5: No lifetime configured for the service, should never expire",
+			serviceName: factory.ServicePfdMng,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			expiry, rsp := nefApp.Processor().grantExpiry(tc.serviceName, tc.reqExpiry, now)
+			require.Equal(t, tc.expectedResponse, rsp)
+			require.Equal(t, tc.expectedExpiry, expiry)
+		})
+	}
+}
+
+func TestVendorSpecificExpiry(t *testing.T) {
+	expiry := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	reqBody := []byte(`{"afAppId":"app1","vendorSpecific-000000":{"expiry":"2025-01-01T00:00:00Z"}}`)
+
+	var resExpiry VendorSpecificExpiry
+	require.NoError(t, openapi.Deserialize(&resExpiry, reqBody, "application/json"))
+	require.Equal(t, &expiry, resExpiry.RequestedExpiry())
+
+	rspBody, err := json.Marshal(newTrafficInfluSubWithExpiry(&nef_context.AfSubscription{
+		TiSub:  &models.NefTrafficInfluSub{AfAppId: "app1"},
+		Expiry: &expiry,
+	}))
+	require.NoError(t, err)
+	require.JSONEq(t, string(reqBody), string(rspBody))
+
+	rspBody, err = json.Marshal(newTrafficInfluSubWithExpiry(&nef_context.AfSubscription{
+		TiSub: &models.NefTrafficInfluSub{AfAppId: "app1"},
+	}))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"afAppId":"app1"}`, string(rspBody))
+}
+
+func TestReapExpiredResources(t *testing.T) {
+	initNRFDiscUDRStub()
+	initUDRDrDeleteTiDataStub(http.StatusNoContent)
+	initUDRDrDeletePfdDataStub()
+	initAFNotificationStub("http://af1NotifURI")
+	defer gock.Off()
+
+	// `afNotifChan` is used to pass the notification requests to AF intercepted by gock.
+	afNotifChan := make(chan *http.Request, 1)
+	gock.Observe(func(request *http.Request, mock gock.Mock) {
+		if strings.Contains(request.URL.String(), "af1NotifURI") {
+			afNotifChan <- request
+		}
+	})
+	defer gock.Observe(nil)
+
+	cfg := nefApp.Config()
+	cfg.Configuration.SubscriptionExpiredNotifAfs = []string{"af1"}
+	defer func() {
+		cfg.Configuration.SubscriptionExpiredNotifAfs = nil
+	}()
+
+	now := time.Now()
+	recent := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+
+	expiredTiSub := tiSub1ForAf1
+	expiredTiSub.AfTransId = "afTrans1"
+	expiredTiSub.NotificationDestination = "http://af1NotifURI/notify"
+
+	liveTiSub := tiSub2ForAf1
+
+	nefCtx := nefApp.Context()
+	af1 := nefCtx.NewAf("af1")
+	af1.Mu.Lock()
+	afSub1 := af1.NewSub(nefCtx.NewCorreID(), &expiredTiSub)
+	afSub1.InfluID = uuid.New().String()
+	afSub1.Expiry = &recent
+	af1.Subs[afSub1.SubID] = afSub1
+
+	afSub2 := af1.NewSub(nefCtx.NewCorreID(), &liveTiSub)
+	afSub2.InfluID = uuid.New().String()
+	afSub2.Expiry = &future
+	af1.Subs[afSub2.SubID] = afSub2
+
+	afPfdTr1 := af1.NewPfdTrans()
+	afPfdTr1.AddExtAppID("app1")
+	afPfdTr1.Expiry = &recent
+	af1.PfdTrans[afPfdTr1.TransID] = afPfdTr1
+
+	afPfdTr2 := af1.NewPfdTrans()
+	afPfdTr2.AddExtAppID("app2")
+	af1.PfdTrans[afPfdTr2.TransID] = afPfdTr2
+	af1.Mu.Unlock()
+	nefCtx.AddAf(af1)
+	defer func() {
+		nefCtx.DeleteAf(af1.AfID)
+		nefCtx.ResetCorreID()
+	}()
+
+	nefCtx.ReapExpiredResources(now, nefApp.Processor())
+
+	af1.Mu.RLock()
+	_, ok := af1.Subs[afSub1.SubID]
+	require.False(t, ok, "expired subscription should be removed")
+	_, ok = af1.Subs[afSub2.SubID]
+	require.True(t, ok, "subscription before expiry should be kept")
+	_, ok = af1.PfdTrans[afPfdTr1.TransID]
+	require.False(t, ok, "expired PFD transaction should be removed")
+	_, ok = af1.PfdTrans[afPfdTr2.TransID]
+	require.True(t, ok, "PFD transaction without expiry should be kept")
+	af1.Mu.RUnlock()
+
+	select {
+	case r := <-afNotifChan:
+		var notif models.EventNotification
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&notif))
+		require.Equal(t, models.EventNotification{
+			AfTransId:       "afTrans1",
+			SubscribedEvent: notifier.SubscribedEventSubscriptionExpired,
+		}, notif)
+	case <-time.After(3 * time.Second):
+		t.Fatal("AF notification is not received")
+	}
+}
+
+// expiryCheckingHandler records whether the lock of AF is held when the expired resources are torn down,
+// and replaces the subscription data of replacedSub in the meantime if it's set
+type expiryCheckingHandler struct {
+	lockCheckingHandler
+	replacedSub *nef_context.AfSubscription
+}
+
+func (h *expiryCheckingHandler) ExpireTrafficInflu(af *nef_context.AfData, sub *nef_context.AfSubscription) {
+	h.check(af)
+	if h.replacedSub != nil {
+		af.Mu.Lock()
+		tiSub := *h.replacedSub.TiSub
+		tiSub.AfTransId = "afTrans2"
+		h.replacedSub.TiSub = &tiSub
+		af.Mu.Unlock()
+	}
+}
+
+func (h *expiryCheckingHandler) ExpirePfdTrans(af *nef_context.AfData, pfdTr *nef_context.AfPfdTransaction) error {
+	h.check(af)
+	for _, appID := range pfdTr.GetExtAppIDs() {
+		pfdTr.DeleteExtAppID(appID)
+	}
+	return nil
+}
+
+func TestReapExpiredResourcesWithoutAfLock(t *testing.T) {
+	now := time.Now()
+	recent := now.Add(-time.Minute)
+
+	expiredTiSub := tiSub1ForAf1
+	expiredTiSub.AfTransId = "afTrans1"
+
+	testCases := []struct {
+		description     string
+		replaced        bool
+		expectedRemoved bool
+	}{
+		{
+			description:     "TC1: Expired subscription, should be removed",
+			expectedRemoved: true,
+		},
+		{
+			description:     "TC2: Subscription replaced during expiry, should be kept until next check",
+			replaced:        true,
+			expectedRemoved: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			nefCtx := nefApp.Context()
+			af1 := nefCtx.NewAf("af1")
+			af1.Mu.Lock()
+			tiSub := expiredTiSub
+			afSub1 := af1.NewSub(nefCtx.NewCorreID(), &tiSub)
+			afSub1.InfluID = uuid.New().String()
+			afSub1.Expiry = &recent
+			af1.Subs[afSub1.SubID] = afSub1
+
+			afPfdTr1 := af1.NewPfdTrans()
+			afPfdTr1.AddExtAppID("app1")
+			afPfdTr1.Expiry = &recent
+			af1.PfdTrans[afPfdTr1.TransID] = afPfdTr1
+			af1.Mu.Unlock()
+			nefCtx.AddAf(af1)
+			defer func() {
+				nefCtx.DeleteAf(af1.AfID)
+				nefCtx.ResetCorreID()
+			}()
+
+			handler := &expiryCheckingHandler{}
+			if tc.replaced {
+				handler.replacedSub = afSub1
+			}
+			nefCtx.ReapExpiredResources(now, handler)
+
+			require.False(t, handler.lockHeld, "lock of AF should not be held during expiry")
+			af1.Mu.RLock()
+			_, ok := af1.Subs[afSub1.SubID]
+			require.Equal(t, tc.expectedRemoved, !ok)
+			_, ok = af1.PfdTrans[afPfdTr1.TransID]
+			require.False(t, ok, "expired PFD transaction should be removed")
+			af1.Mu.RUnlock()
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package processor
+
+import (
+	"fmt"
+
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+)
+
+// validateGeoZoneIds checks that every validGeoZoneId is configured in geoZones
+func (p *Processor) validateGeoZoneIds(zoneIDs []string) *HandlerResponse {
+	for _, zoneID := range zoneIDs {
+		if _, ok := p.Config().GeoZone(zoneID); !ok {
+			pd := openapi.ProblemDetailsMalformedReqSyntax(fmt.Sprintf("Unknown validGeoZoneId[%s]", zoneID))
+			return &HandlerResponse{int(pd.Status), nil, pd}
+		}
+	}
+	return nil
+}
+
+// convertGeoZonesToPresenceInfoList returns the presenceInfoList of spatial validity keyed by zone ID
+func (p *Processor) convertGeoZonesToPresenceInfoList(zoneIDs []string) map[string]models.PresenceInfo {
+	if len(zoneIDs) == 0 {
+		return nil
+	}
+
+	presenceInfoList := make(map[string]models.PresenceInfo)
+	for _, zoneID := range zoneIDs {
+		zone, ok := p.Config().GeoZone(zoneID)
+		if !ok {
+			continue
+		}
+		presenceInfoList[zoneID] = models.PresenceInfo{
+			PresenceState:    models.PresenceState_IN_AREA,
+			TrackingAreaList: zone.Tais,
+			NcgiList:         zone.Ncgis,
+		}
+	}
+	return presenceInfoList
+}
+
+// convertGeoZonesToNetworkAreaInfo returns the network area covering all the zones
+func (p *Processor) convertGeoZonesToNetworkAreaInfo(zoneIDs []string) *models.NetworkAreaInfo {
+	if len(zoneIDs) == 0 {
+		return nil
+	}
+
+	nwAreaInfo := &models.NetworkAreaInfo{}
+	for _, zoneID := range zoneIDs {
+		zone, ok := p.Config().GeoZone(zoneID)
+		if !ok {
+			continue
+		}
+		nwAreaInfo.Tais = append(nwAreaInfo.Tais, zone.Tais...)
+		nwAreaInfo.Ncgis = append(nwAreaInfo.Ncgis, zone.Ncgis...)
+	}
+	return nwAreaInfo
+}
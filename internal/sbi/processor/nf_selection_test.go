@@ -0,0 +1,82 @@
+package processor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	nef_context "github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/openapi/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestTrafficInfluenceUdrFailover(t *testing.T) {
+	// A new NEF is used, so that the UDRs below are not cached for other tests
+	app, err := newTestApp(nefApp.Config(), "")
+	require.NoError(t, err)
+	// The UDRs serving the DNN and S-NSSAI of tiSub1ForAf1
+	app.Context().SetNfInstances(nef_context.NfDiscoveryKey{
+		SrvName: models.ServiceName_NUDR_DR,
+		Sst:     1,
+		Sd:      "010203",
+		Dnn:     "internet",
+	}, []*nef_context.NfInstance{
+		{NfInstID: "udr2", Uri: "http://127.0.0.14:8000", Priority: 2},
+		{NfInstID: "udr1", Uri: "http://127.0.0.4:8000", Priority: 1},
+	}, time.Hour)
+
+	// The UDR with higher priority fails once, and it's not preferred afterwards
+	udr1Mock := gock.New("http://127.0.0.4:8000/nudr-dr/v1").
+		Put("/application-data/influenceData/.*").
+		Times(1).
+		Reply(http.StatusServiceUnavailable).Mock
+	udr2Mock := gock.New("http://127.0.0.14:8000/nudr-dr/v1").
+		Put("/application-data/influenceData/.*").
+		Times(2).
+		Reply(http.StatusNoContent).Mock
+	defer gock.Remove(udr1Mock)
+	defer gock.Remove(udr2Mock)
+
+	for i := 0; i < 2; i++ {
+		httpRecorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(httpRecorder)
+		tiSub := tiSub1ForAf1
+		app.Processor().PostTrafficInfluenceSubscription(c, "af1", &tiSub, nil)
+		require.Equal(t, http.StatusCreated, httpRecorder.Code)
+	}
+	require.Len(t, app.Context().GetAf("af1").Subs, 2)
+	require.True(t, udr1Mock.Done())
+	require.True(t, udr2Mock.Done())
+}
+
+func TestNfDiscoveryCache(t *testing.T) {
+	app, err := newTestApp(nefApp.Config(), "")
+	require.NoError(t, err)
+	nefCtx := app.Context()
+	pcfKey := nef_context.NfDiscoveryKey{
+		SrvName: models.ServiceName_NPCF_POLICYAUTHORIZATION,
+		Sst:     1,
+		Dnn:     "internet",
+	}
+	pcf1 := &nef_context.NfInstance{NfInstID: "pcf1", Uri: "http://127.0.0.7:8000"}
+
+	nefCtx.SetNfInstances(pcfKey, []*nef_context.NfInstance{pcf1}, time.Hour)
+	require.Equal(t, []*nef_context.NfInstance{pcf1}, nefCtx.NfInstances(pcfKey))
+
+	// Another DNN is discovered separately
+	otherDnnKey := pcfKey
+	otherDnnKey.Dnn = "ims"
+	require.Empty(t, nefCtx.NfInstances(otherDnnKey))
+
+	// The result is discovered again once the validity period is over
+	nefCtx.SetNfInstances(pcfKey, []*nef_context.NfInstance{pcf1}, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	require.Empty(t, nefCtx.NfInstances(pcfKey))
+
+	// The result without validity period is not cached
+	nefCtx.SetNfInstances(otherDnnKey, []*nef_context.NfInstance{pcf1}, 0)
+	require.Empty(t, nefCtx.NfInstances(otherDnnKey))
+}
@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi"
+	"github.com/free5gc/openapi/models"
+	"github.com/gin-gonic/gin"
+)
+
+// nfStatusSubscribedNfTypes are the NF types whose URIs are cached in NefContext
+var nfStatusSubscribedNfTypes = []models.NrfNfManagementNfType{
+	models.NrfNfManagementNfType_PCF,
+	models.NrfNfManagementNfType_UDR,
+	models.NrfNfManagementNfType_UDM,
+	models.NrfNfManagementNfType_BSF,
+}
+
+// nfStatusSubscriptions are the subscriptions to the NF status in NRF
+type nfStatusSubscriptions struct {
+	mu  sync.Mutex
+	ids map[models.NrfNfManagementNfType]string // nfType -> subscriptionId allocated by NRF
+}
+
+func newNfStatusSubscriptions() *nfStatusSubscriptions {
+	return &nfStatusSubscriptions{
+		ids: make(map[models.NrfNfManagementNfType]string),
+	}
+}
+
+// SubscribeNfStatus subscribes to the status of the NF types cached in NefContext after NEF is registered to NRF,
+// the previous subscriptions are replaced since NRF may have lost them, e.g. when NEF registers again.
+func (p *Processor) SubscribeNfStatus() error {
+	p.nfStatusSubs.mu.Lock()
+	defer p.nfStatusSubs.mu.Unlock()
+
+	var errs []error
+	for _, nfType := range nfStatusSubscribedNfTypes {
+		subscriptionID, pd, err := p.Consumer().CreateSubscription(nfType, p.genNfStatusNotifyUri())
+		if pd != nil || err != nil {
+			errs = append(errs, fmt.Errorf("subscribe to status of %s failed: %+v, %+v", nfType, pd, err))
+			continue
+		}
+		p.nfStatusSubs.ids[nfType] = subscriptionID
+		logger.ProcessorLog.Infof("Subscribed to status of %s with subscriptionId[%s]", nfType, subscriptionID)
+	}
+	return errors.Join(errs...)
+}
+
+func (p *Processor) UnsubscribeNfStatus() {
+	p.nfStatusSubs.mu.Lock()
+	defer p.nfStatusSubs.mu.Unlock()
+
+	for nfType, subscriptionID := range p.nfStatusSubs.ids {
+		if pd, err := p.Consumer().RemoveSubscription(subscriptionID); pd != nil || err != nil {
+			logger.ProcessorLog.Errorf("Unsubscribe from status of %s failed: %+v, %+v", nfType, pd, err)
+		}
+		delete(p.nfStatusSubs.ids, nfType)
+	}
+}
+
+// NfStatusNotification evicts the cached URIs of the NF instance deregistered or changed,
+// the NF instance is discovered from NRF again on next use.
+func (p *Processor) NfStatusNotification(c *gin.Context, notif *models.NrfNfManagementNotificationData) {
+	logger.ProcessorLog.Infof("NfStatusNotification - event[%s], nfInstanceUri[%s]", notif.Event, notif.NfInstanceUri)
+
+	if notif.NfInstanceUri == "" {
+		pd := openapi.ProblemDetailsMalformedReqSyntax("nfInstanceUri is missing")
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	nfInstID := notif.NfInstanceUri[strings.LastIndex(notif.NfInstanceUri, "/")+1:]
+
+	switch notif.Event {
+	case models.NotificationEventType_DEREGISTERED,
+		models.NotificationEventType_PROFILE_CHANGED:
+		p.Context().EvictNfInstance(nfInstID)
+	default:
+		// A newly registered NF instance is only used after the cached one is evicted
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (p *Processor) genNfStatusNotifyUri() string {
+	return p.Config().ServiceUri(factory.ServiceNefCallback) + "/notification/nf-status"
+}
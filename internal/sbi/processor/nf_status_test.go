@@ -0,0 +1,87 @@
+package processor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	nef_context "github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/openapi/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNfStatusNotification(t *testing.T) {
+	// A new NEF is used, so that the cached NF instances don't affect other tests
+	app, err := newTestApp(nefApp.Config(), "")
+	require.NoError(t, err)
+	nefCtx := app.Context()
+	pcf1 := &nef_context.NfInstance{NfInstID: "pcf1", Uri: "http://127.0.0.7:8000"}
+	pcf2 := &nef_context.NfInstance{NfInstID: "pcf2", Uri: "http://127.0.0.8:8000"}
+	udr1 := &nef_context.NfInstance{NfInstID: "udr1", Uri: "http://127.0.0.4:8000"}
+	pcfKey := nef_context.NfDiscoveryKey{SrvName: models.ServiceName_NPCF_POLICYAUTHORIZATION}
+	udrKey := nef_context.NfDiscoveryKey{SrvName: models.ServiceName_NUDR_DR}
+	nefCtx.SetNfInstances(pcfKey, []*nef_context.NfInstance{pcf1, pcf2}, time.Hour)
+	nefCtx.SetNfInstances(udrKey, []*nef_context.NfInstance{udr1}, time.Hour)
+
+	testCases := []struct {
+		description          string
+		notif                *models.NrfNfManagementNotificationData
+		expectedStatus       int
+		expectedPcfInstances []*nef_context.NfInstance
+		expectedUdrInstances []*nef_context.NfInstance
+	}{
+		{
+			description: "TC1: Profile of another NF instance is changed, the NF instances are kept",
+			notif: &models.NrfNfManagementNotificationData{
+				Event:         models.NotificationEventType_PROFILE_CHANGED,
+				NfInstanceUri: "http://127.0.0.10:8000/nnrf-nfm/v1/nf-instances/pcf3",
+			},
+			expectedStatus:       http.StatusNoContent,
+			expectedPcfInstances: []*nef_context.NfInstance{pcf1, pcf2},
+			expectedUdrInstances: []*nef_context.NfInstance{udr1},
+		},
+		{
+			description: "TC2: nfInstanceUri is missing",
+			notif: &models.NrfNfManagementNotificationData{
+				Event: models.NotificationEventType_DEREGISTERED,
+			},
+			expectedStatus:       http.StatusBadRequest,
+			expectedPcfInstances: []*nef_context.NfInstance{pcf1, pcf2},
+			expectedUdrInstances: []*nef_context.NfInstance{udr1},
+		},
+		{
+			description: "TC3: PCF is deregistered, only the PCF is evicted",
+			notif: &models.NrfNfManagementNotificationData{
+				Event:         models.NotificationEventType_DEREGISTERED,
+				NfInstanceUri: "http://127.0.0.10:8000/nnrf-nfm/v1/nf-instances/pcf1",
+			},
+			expectedStatus:       http.StatusNoContent,
+			expectedPcfInstances: []*nef_context.NfInstance{pcf2},
+			expectedUdrInstances: []*nef_context.NfInstance{udr1},
+		},
+		{
+			description: "TC4: Profile of UDR is changed, UDR is evicted",
+			notif: &models.NrfNfManagementNotificationData{
+				Event:         models.NotificationEventType_PROFILE_CHANGED,
+				NfInstanceUri: "http://127.0.0.10:8000/nnrf-nfm/v1/nf-instances/udr1",
+			},
+			expectedStatus:       http.StatusNoContent,
+			expectedPcfInstances: []*nef_context.NfInstance{pcf2},
+			expectedUdrInstances: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			httpRecorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(httpRecorder)
+			app.Processor().NfStatusNotification(c, tc.notif)
+
+			require.Equal(t, tc.expectedStatus, httpRecorder.Code)
+			require.Equal(t, tc.expectedPcfInstances, nefCtx.NfInstances(pcfKey))
+			require.Equal(t, tc.expectedUdrInstances, nefCtx.NfInstances(udrKey))
+		})
+	}
+}
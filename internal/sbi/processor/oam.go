@@ -1,11 +1,73 @@
 package processor
 
 import (
+	"fmt"
 	"net/http"
 
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi"
 	"github.com/gin-gonic/gin"
 )
 
 func (p *Processor) GetOamIndex(c *gin.Context) {
 	c.JSON(http.StatusOK, nil)
 }
+
+// NfProfileUpdate is the change of the NF profile requested by OAM,
+// the absent fields are kept unchanged.
+type NfProfileUpdate struct {
+	Load        *int32            `json:"load,omitempty"` // in percentage
+	ServiceList []factory.Service `json:"serviceList,omitempty"`
+}
+
+// UpdateNfProfile pushes the NF profile to NRF without restarting NEF
+func (p *Processor) UpdateNfProfile(c *gin.Context, update *NfProfileUpdate) {
+	if update.Load != nil {
+		if *update.Load < 0 || *update.Load > 100 {
+			pd := openapi.ProblemDetailsMalformedReqSyntax(
+				fmt.Sprintf("Invalid load[%d], should be between 0 and 100", *update.Load))
+			c.JSON(int(pd.Status), pd)
+			return
+		}
+	}
+	for _, s := range update.ServiceList {
+		if s.ServiceName != factory.ServiceNefPfd && s.ServiceName != factory.ServiceNefOam {
+			pd := openapi.ProblemDetailsMalformedReqSyntax(
+				fmt.Sprintf("Invalid serviceName[%s], should be %s or %s",
+					s.ServiceName, factory.ServiceNefPfd, factory.ServiceNefOam))
+			c.JSON(int(pd.Status), pd)
+			return
+		}
+	}
+
+	if update.Load != nil {
+		p.Context().SetLoad(*update.Load)
+	}
+	if len(update.ServiceList) > 0 {
+		p.Context().SetServiceList(update.ServiceList)
+	}
+
+	pd, err := p.Consumer().UpdateNfProfile()
+	if pd != nil {
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	if err != nil {
+		pd = openapi.ProblemDetailsSystemFailure(err.Error())
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	logger.OamLog.Infoln("NF profile is updated in NRF")
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (p *Processor) GetReconcileReport(c *gin.Context) {
+	report := p.LastReconcileReport()
+	if report == nil {
+		pd := openapi.ProblemDetailsDataNotFound("No reconciliation is done yet")
+		c.JSON(int(pd.Status), pd)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
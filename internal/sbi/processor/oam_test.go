@@ -0,0 +1,42 @@
+package processor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateNfProfile(t *testing.T) {
+	invalidLoad := int32(101)
+
+	httpRecorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().UpdateNfProfile(c, &NfProfileUpdate{
+		Load: &invalidLoad,
+	})
+
+	require.Equal(t, http.StatusBadRequest, httpRecorder.Code)
+	assertJSONBodyEqual(t, openapi.ProblemDetailsMalformedReqSyntax(
+		"Invalid load[101], should be between 0 and 100"), httpRecorder.Body.Bytes())
+	require.Equal(t, int32(0), nefApp.Context().Load())
+
+	// The services are validated before any change is taken
+	validLoad := int32(50)
+	httpRecorder = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(httpRecorder)
+	nefApp.Processor().UpdateNfProfile(c, &NfProfileUpdate{
+		Load:        &validLoad,
+		ServiceList: []factory.Service{{ServiceName: "nnef-unknown"}},
+	})
+
+	require.Equal(t, http.StatusBadRequest, httpRecorder.Code)
+	assertJSONBodyEqual(t, openapi.ProblemDetailsMalformedReqSyntax(
+		"Invalid serviceName[nnef-unknown], should be nnef-pfdmanagement or nnef-oam"), httpRecorder.Body.Bytes())
+	require.Equal(t, int32(0), nefApp.Context().Load())
+	require.Equal(t, nefApp.Config().ServiceList(), nefApp.Context().ServiceList())
+}
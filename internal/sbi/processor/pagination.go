@@ -0,0 +1,114 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Page is the cursor/limit pagination of a collection ordered by resource ID
+type Page struct {
+	Cursor string // ID of the last resource in the previous page, empty for the first page
+	Limit  int    // Maximum number of resources in a page, 0 means no limit
+}
+
+func (pg *Page) values() url.Values {
+	values := url.Values{}
+	if pg.Limit > 0 {
+		values.Set("limit", strconv.Itoa(pg.Limit))
+	}
+	return values
+}
+
+// TrafficInfluSubFilter selects the subscriptions of GET /{afId}/subscriptions,
+// an empty attribute matches all subscriptions.
+type TrafficInfluSubFilter struct {
+	Dnn      string
+	Snssai   *models.Snssai
+	AfAppId  string
+	Gpsi     string
+	Ipv4Addr string
+}
+
+func (f *TrafficInfluSubFilter) match(tiSub *models.NefTrafficInfluSub) bool {
+	if f.Dnn != "" && f.Dnn != tiSub.Dnn {
+		return false
+	}
+	if f.Snssai != nil && (tiSub.Snssai == nil ||
+		f.Snssai.Sst != tiSub.Snssai.Sst || !strings.EqualFold(f.Snssai.Sd, tiSub.Snssai.Sd)) {
+		return false
+	}
+	if f.AfAppId != "" && f.AfAppId != tiSub.AfAppId {
+		return false
+	}
+	if f.Gpsi != "" && f.Gpsi != tiSub.Gpsi {
+		return false
+	}
+	if f.Ipv4Addr != "" && f.Ipv4Addr != tiSub.Ipv4Addr {
+		return false
+	}
+	return true
+}
+
+func (f *TrafficInfluSubFilter) values() url.Values {
+	values := url.Values{}
+	if f.Dnn != "" {
+		values.Set("dnn", f.Dnn)
+	}
+	if f.Snssai != nil {
+		if snssai, err := json.Marshal(f.Snssai); err == nil {
+			values.Set("snssai", string(snssai))
+		}
+	}
+	if f.AfAppId != "" {
+		values.Set("af-app-id", f.AfAppId)
+	}
+	if f.Gpsi != "" {
+		values.Set("gpsi", f.Gpsi)
+	}
+	if f.Ipv4Addr != "" {
+		values.Set("ipv4-addr", f.Ipv4Addr)
+	}
+	return values
+}
+
+// lessID orders the numeric resource IDs allocated by AfData, e.g. "2" < "10"
+func lessID(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+// paginate sorts the IDs and returns the ones in the page,
+// hasNext indicates whether there are still IDs after the page.
+func paginate(ids []string, pg *Page) (pageIDs []string, hasNext bool) {
+	sort.Slice(ids, func(i, j int) bool {
+		return lessID(ids[i], ids[j])
+	})
+
+	start := 0
+	if pg.Cursor != "" {
+		start = sort.Search(len(ids), func(i int) bool {
+			return lessID(pg.Cursor, ids[i])
+		})
+	}
+	ids = ids[start:]
+
+	if pg.Limit > 0 && len(ids) > pg.Limit {
+		return ids[:pg.Limit], true
+	}
+	return ids, false
+}
+
+// setNextLink sets the Link header (RFC 8288) which refers to the next page of the collection
+func setNextLink(c *gin.Context, collectionURI string, values url.Values, cursor string) {
+	values.Set("cursor", cursor)
+	c.Header("Link", fmt.Sprintf("<%s?%s>; rel=\"next\"", collectionURI, values.Encode()))
+}
@@ -3,9 +3,12 @@ package processor
 import (
 	"fmt"
 	"net/http"
+	"sort"
+	"time"
 
 	nef_context "github.com/free5gc/nef/internal/context"
 	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/validator"
 	"github.com/free5gc/nef/pkg/factory"
 	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
@@ -19,9 +22,11 @@ const (
 	DetailNoExtAppID = "Absent of PfdData.ExternalAppID"
 	DetailNoPfdID    = "Absent of Pfd.PfdID"
 	DetailNoPfdInfo  = "One of FlowDescriptions, Urls or DomainNames should be provided"
+
+	DetailInvalidPfdInfo = "Invalid FlowDescriptions, Urls or DomainNames"
 )
 
-func (p *Processor) GetPFDManagementTransactions(c *gin.Context, scsAsID string) {
+func (p *Processor) GetPFDManagementTransactions(c *gin.Context, scsAsID string, pg *Page) {
 	logger.PFDManageLog.Infof("GetPFDManagementTransactions - scsAsID[%s]", scsAsID)
 
 	nefCtx := p.Context()
@@ -34,14 +39,24 @@ func (p *Processor) GetPFDManagementTransactions(c *gin.Context, scsAsID string)
 	af.Mu.RLock()
 	defer af.Mu.RUnlock()
 
-	var pfdMngs []models.PfdManagement
-	for _, afPfdTr := range af.PfdTrans {
+	transIDs := make([]string, 0, len(af.PfdTrans))
+	for transID := range af.PfdTrans {
+		transIDs = append(transIDs, transID)
+	}
+	transIDs, hasNext := paginate(transIDs, pg)
+
+	var pfdMngs []pfdManagementWithExpiry
+	for _, transID := range transIDs {
+		afPfdTr := af.PfdTrans[transID]
 		pfdMng, rsp := p.buildPfdManagement(scsAsID, afPfdTr)
 		if rsp != nil {
 			c.JSON(rsp.Status, rsp.Body)
 			return
 		}
-		pfdMngs = append(pfdMngs, *pfdMng)
+		pfdMngs = append(pfdMngs, *newPfdManagementWithExpiry(pfdMng, afPfdTr))
+	}
+	if hasNext {
+		setNextLink(c, p.genPfdManagementsURI(scsAsID), pg.values(), transIDs[len(transIDs)-1])
 	}
 
 	c.JSON(http.StatusOK, &pfdMngs)
@@ -51,11 +66,10 @@ func (p *Processor) PostPFDManagementTransactions(
 	c *gin.Context,
 	scsAsID string,
 	pfdMng *models.PfdManagement,
+	reqExpiry *time.Time,
 ) {
 	logger.PFDManageLog.Infof("PostPFDManagementTransactions - scsAsID[%s]", scsAsID)
 
-	// TODO: Authorize the AF
-
 	nefCtx := p.Context()
 	if pd := validatePfdManagement(scsAsID, "-1", pfdMng, nefCtx); pd != nil {
 		if pd.Status == http.StatusInternalServerError {
@@ -73,6 +87,12 @@ func (p *Processor) PostPFDManagementTransactions(
 		return
 	}
 
+	expiry, rsp := p.grantExpiry(factory.ServicePfdMng, reqExpiry, time.Now())
+	if rsp != nil {
+		c.JSON(rsp.Status, rsp.Body)
+		return
+	}
+
 	af.Mu.Lock()
 	defer af.Mu.Unlock()
 
@@ -82,17 +102,28 @@ func (p *Processor) PostPFDManagementTransactions(
 		c.JSON(int(pd.Status), pd)
 		return
 	}
+	afPfdTr.Expiry = expiry
 
 	pfdNotifyContext := p.Notifier().PfdChangeNotifier.NewPfdNotifyContext()
 	defer pfdNotifyContext.FlushNotifications()
 
+	// A failed application is restored in UDR by the transaction and reported in PfdReports,
+	// so only the created applications are added to afPfdTr.
+	txn := p.newPfdUdrTxn(afPfdTr.Log)
+	if rsp := txn.begin(getPfdDataAppIDs(pfdMng.PfdDatas)); rsp != nil {
+		c.JSON(rsp.Status, rsp.Body)
+		return
+	}
 	for appID, pfdData := range pfdMng.PfdDatas {
-		afPfdTr.AddExtAppID(appID)
 		pfdDataForApp := convertPfdDataToPfdDataForApp(&pfdData)
-		if pfdReport := p.storePfdDataToUDR(appID, pfdDataForApp); pfdReport != nil {
+		if rsp := txn.store(appID, pfdDataForApp); rsp != nil {
+			afPfdTr.Log.Errorf("Store appID[%s] to UDR failed: status[%d]", appID, rsp.Status)
 			delete(pfdMng.PfdDatas, appID)
-			addPfdReport(pfdMng, pfdReport)
+			addPfdReport(pfdMng, newMalfunctionPfdReport(appID))
 		} else {
+			afPfdTr.AddExtAppID(appID)
+			afPfdTr.SetAllowedDelay(appID, pfdData.AllowedDelay)
+			afPfdTr.SetCachingTime(appID, pfdData.CachingTime)
 			pfdData.Self = p.genPfdDataURI(scsAsID, afPfdTr.TransID, appID)
 			pfdMng.PfdDatas[appID] = pfdData
 			pfdNotifyContext.AddNotification(appID, &models.PfdChangeNotification{
@@ -111,11 +142,11 @@ func (p *Processor) PostPFDManagementTransactions(
 	af.PfdTrans[afPfdTr.TransID] = afPfdTr
 	afPfdTr.Log.Infoln("PFD Management Transaction is added")
 
-	nefCtx.AddAf(af)
+	nefCtx.SaveAf(af)
 
 	pfdMng.Self = p.genPfdManagementURI(scsAsID, afPfdTr.TransID)
 
-	c.JSON(http.StatusCreated, pfdMng)
+	c.JSON(http.StatusCreated, newPfdManagementWithExpiry(pfdMng, afPfdTr))
 }
 
 func (p *Processor) DeletePFDManagementTransactions(c *gin.Context, scsAsID string) {
@@ -130,16 +161,18 @@ func (p *Processor) DeletePFDManagementTransactions(c *gin.Context, scsAsID stri
 
 	af.Mu.Lock()
 	defer af.Mu.Unlock()
+	// The transactions deleted before a failure are also persisted
+	defer nefCtx.SaveAf(af)
 
 	pfdNotifyContext := p.Notifier().PfdChangeNotifier.NewPfdNotifyContext()
 	defer pfdNotifyContext.FlushNotifications()
 
 	for _, afPfdTr := range af.PfdTrans {
+		if rsp := p.deletePfdTransFromUDR(afPfdTr); rsp != nil {
+			c.JSON(rsp.Status, rsp.Body)
+			return
+		}
 		for extAppID := range afPfdTr.ExtAppIDs {
-			if rsp := p.deletePfdDataFromUDR(extAppID); rsp != nil {
-				c.JSON(rsp.Status, rsp.Body)
-				return
-			}
 			pfdNotifyContext.AddNotification(extAppID, &models.PfdChangeNotification{
 				ApplicationId: extAppID,
 				RemovalFlag:   true,
@@ -183,9 +216,14 @@ func (p *Processor) GetIndividualPFDManagementTransaction(
 		return
 	}
 
-	c.JSON(http.StatusOK, pfdMng)
+	c.JSON(http.StatusOK, newPfdManagementWithExpiry(pfdMng, afPfdTr))
 }
 
+// PutIndividualPFDManagementTransaction replaces the transaction as a whole. Unlike POST, where the
+// applications stored in UDR are created and the failed ones are reported in PfdReports, PUT is all-or-nothing:
+// if any application is rejected (e.g. APP_ID_DUPLICATED or SHORT_DELAY) or fails in UDR, the UDR content is left
+// or restored as is and 500 with PfdReports of the failed applications is returned, while the transaction keeps
+// its previous applications.
 func (p *Processor) PutIndividualPFDManagementTransaction(
 	c *gin.Context,
 	scsAsID, transID string,
@@ -194,8 +232,6 @@ func (p *Processor) PutIndividualPFDManagementTransaction(
 	logger.PFDManageLog.Infof("PutIndividualPFDManagementTransaction - scsAsID[%s], transID[%s]",
 		scsAsID, transID)
 
-	// TODO: Authorize the AF
-
 	nefCtx := p.Context()
 	if pd := validatePfdManagement(scsAsID, transID, pfdMng, nefCtx); pd != nil {
 		if pd.Status == http.StatusInternalServerError {
@@ -206,6 +242,12 @@ func (p *Processor) PutIndividualPFDManagementTransaction(
 			return
 		}
 	}
+	// An application rejected by the validation (e.g. SHORT_DELAY) fails the whole PUT, otherwise
+	// it would be taken as absent from the new PfdManagement and deleted from UDR.
+	if len(pfdMng.PfdReports) > 0 {
+		c.JSON(http.StatusInternalServerError, &pfdMng.PfdReports)
+		return
+	}
 
 	af := nefCtx.GetAf(scsAsID)
 	if af == nil {
@@ -227,6 +269,10 @@ func (p *Processor) PutIndividualPFDManagementTransaction(
 	pfdNotifyContext := p.Notifier().PfdChangeNotifier.NewPfdNotifyContext()
 	defer pfdNotifyContext.FlushNotifications()
 
+	// The transaction is replaced as a whole: if any application fails, UDR is restored
+	// and afPfdTr is left unchanged.
+	txn := p.newPfdUdrTxn(afPfdTr.Log)
+
 	// Delete PfdDataForApps in UDR with appID absent in new PfdManagement
 	deprecatedAppIDs := []string{}
 	for extAppID := range afPfdTr.ExtAppIDs {
@@ -234,43 +280,58 @@ func (p *Processor) PutIndividualPFDManagementTransaction(
 			deprecatedAppIDs = append(deprecatedAppIDs, extAppID)
 		}
 	}
+	if rsp := txn.begin(append(getPfdDataAppIDs(pfdMng.PfdDatas), deprecatedAppIDs...)); rsp != nil {
+		c.JSON(rsp.Status, rsp.Body)
+		return
+	}
 	for _, appID := range deprecatedAppIDs {
-		if rsp := p.deletePfdDataFromUDR(appID); rsp != nil {
+		if rsp := txn.delete(appID); rsp != nil {
+			txn.rollback()
 			c.JSON(rsp.Status, rsp.Body)
 			return
 		}
-		pfdNotifyContext.AddNotification(appID, &models.PfdChangeNotification{
-			ApplicationId: appID,
-			RemovalFlag:   true,
-		})
 	}
 
-	afPfdTr.DeleteAllExtAppIDs()
+	pfdDataForApps := make(map[string]*models.PfdDataForAppExt, len(pfdMng.PfdDatas))
 	for appID, pfdData := range pfdMng.PfdDatas {
-		afPfdTr.AddExtAppID(appID)
 		pfdDataForApp := convertPfdDataToPfdDataForApp(&pfdData)
-		if pfdReport := p.storePfdDataToUDR(appID, pfdDataForApp); pfdReport != nil {
-			delete(pfdMng.PfdDatas, appID)
-			addPfdReport(pfdMng, pfdReport)
-		} else {
-			pfdData.Self = p.genPfdDataURI(scsAsID, afPfdTr.TransID, appID)
-			pfdMng.PfdDatas[appID] = pfdData
-			pfdNotifyContext.AddNotification(appID, &models.PfdChangeNotification{
-				ApplicationId: appID,
-				Pfds:          pfdDataForApp.Pfds,
-			})
+		if rsp := txn.store(appID, pfdDataForApp); rsp != nil {
+			afPfdTr.Log.Errorf("Store appID[%s] to UDR failed: status[%d]", appID, rsp.Status)
+			addPfdReport(pfdMng, newMalfunctionPfdReport(appID))
+			continue
 		}
+		pfdDataForApps[appID] = pfdDataForApp
 	}
-	if len(pfdMng.PfdDatas) == 0 {
-		// The PFDs for all applications were not created successfully.
+	if len(pfdDataForApps) != len(pfdMng.PfdDatas) {
+		txn.rollback()
 		// PfdReport is included with detailed information.
 		c.JSON(http.StatusInternalServerError, &pfdMng.PfdReports)
 		return
 	}
 
+	for _, appID := range deprecatedAppIDs {
+		pfdNotifyContext.AddNotification(appID, &models.PfdChangeNotification{
+			ApplicationId: appID,
+			RemovalFlag:   true,
+		})
+	}
+	afPfdTr.DeleteAllExtAppIDs()
+	for appID, pfdData := range pfdMng.PfdDatas {
+		afPfdTr.AddExtAppID(appID)
+		afPfdTr.SetAllowedDelay(appID, pfdData.AllowedDelay)
+		afPfdTr.SetCachingTime(appID, pfdData.CachingTime)
+		pfdData.Self = p.genPfdDataURI(scsAsID, afPfdTr.TransID, appID)
+		pfdMng.PfdDatas[appID] = pfdData
+		pfdNotifyContext.AddNotification(appID, &models.PfdChangeNotification{
+			ApplicationId: appID,
+			Pfds:          pfdDataForApps[appID].Pfds,
+		})
+	}
+
 	pfdMng.Self = p.genPfdManagementURI(scsAsID, afPfdTr.TransID)
+	nefCtx.SaveAf(af)
 
-	c.JSON(http.StatusOK, pfdMng)
+	c.JSON(http.StatusOK, newPfdManagementWithExpiry(pfdMng, afPfdTr))
 }
 
 func (p *Processor) DeleteIndividualPFDManagementTransaction(
@@ -299,11 +360,11 @@ func (p *Processor) DeleteIndividualPFDManagementTransaction(
 	pfdNotifyContext := p.Notifier().PfdChangeNotifier.NewPfdNotifyContext()
 	defer pfdNotifyContext.FlushNotifications()
 
+	if rsp := p.deletePfdTransFromUDR(afPfdTr); rsp != nil {
+		c.JSON(rsp.Status, rsp.Body)
+		return
+	}
 	for extAppID := range afPfdTr.ExtAppIDs {
-		if rsp := p.deletePfdDataFromUDR(extAppID); rsp != nil {
-			c.JSON(rsp.Status, rsp.Body)
-			return
-		}
 		pfdNotifyContext.AddNotification(extAppID, &models.PfdChangeNotification{
 			ApplicationId: extAppID,
 			RemovalFlag:   true,
@@ -311,6 +372,7 @@ func (p *Processor) DeleteIndividualPFDManagementTransaction(
 	}
 	delete(af.PfdTrans, afPfdTr.TransID)
 	afPfdTr.Log.Infoln("PFD Management Transaction is deleted")
+	nefCtx.SaveAf(af)
 
 	// TODO: Remove AfCtx if its subscriptions and transactions are both empty
 
@@ -353,6 +415,8 @@ func (p *Processor) GetIndividualApplicationPFDManagement(
 		return
 	}
 	pfdData := convertPfdDataForAppToPfdData(rspBody.(*models.PfdDataForAppExt))
+	pfdData.AllowedDelay = afPfdTr.AllowedDelays[appID]
+	pfdData.CachingTime = afPfdTr.CachingTimes[appID]
 	pfdData.Self = p.genPfdDataURI(scsAsID, transID, appID)
 
 	c.JSON(http.StatusOK, pfdData)
@@ -396,6 +460,7 @@ func (p *Processor) DeleteIndividualApplicationPFDManagement(
 		return
 	}
 	afPfdTr.DeleteExtAppID(appID)
+	p.Context().SaveAf(af)
 	pfdNotifyContext.AddNotification(appID, &models.PfdChangeNotification{
 		ApplicationId: appID,
 		RemovalFlag:   true,
@@ -416,8 +481,6 @@ func (p *Processor) PutIndividualApplicationPFDManagement(
 	logger.PFDManageLog.Infof("PutIndividualApplicationPFDManagement - scsAsID[%s], transID[%s], appID[%s]",
 		scsAsID, transID, appID)
 
-	// TODO: Authorize the AF
-
 	nefCtx := p.Context()
 	af := nefCtx.GetAf(scsAsID)
 	if af == nil {
@@ -448,6 +511,11 @@ func (p *Processor) PutIndividualApplicationPFDManagement(
 		return
 	}
 
+	if pfdReport := checkPfdDelay(appID, pfdData, nefCtx); pfdReport != nil {
+		c.JSON(http.StatusInternalServerError, pfdReport)
+		return
+	}
+
 	pfdNotifyContext := p.Notifier().PfdChangeNotifier.NewPfdNotifyContext()
 	defer pfdNotifyContext.FlushNotifications()
 
@@ -456,6 +524,9 @@ func (p *Processor) PutIndividualApplicationPFDManagement(
 		c.JSON(http.StatusInternalServerError, pfdReport)
 		return
 	}
+	afPfdTr.SetAllowedDelay(appID, pfdData.AllowedDelay)
+	afPfdTr.SetCachingTime(appID, pfdData.CachingTime)
+	nefCtx.SaveAf(af)
 	pfdData.Self = p.genPfdDataURI(scsAsID, transID, appID)
 	pfdNotifyContext.AddNotification(appID, &models.PfdChangeNotification{
 		ApplicationId: appID,
@@ -473,8 +544,6 @@ func (p *Processor) PatchIndividualApplicationPFDManagement(
 	logger.PFDManageLog.Infof("PatchIndividualApplicationPFDManagement - scsAsID[%s], transID[%s], appID[%s]",
 		scsAsID, transID, appID)
 
-	// TODO: Authorize the AF
-
 	nefCtx := p.Context()
 	af := nefCtx.GetAf(scsAsID)
 	if af == nil {
@@ -515,16 +584,26 @@ func (p *Processor) PatchIndividualApplicationPFDManagement(
 	}
 
 	oldPfdData := convertPfdDataForAppToPfdData(rspBody.(*models.PfdDataForAppExt))
+	oldPfdData.AllowedDelay = afPfdTr.AllowedDelays[appID]
+	oldPfdData.CachingTime = afPfdTr.CachingTimes[appID]
 	if pd := patchModifyPfdData(oldPfdData, pfdData); pd != nil {
 		c.JSON(int(pd.Status), pd)
 		return
 	}
 
+	if pfdReport := checkPfdDelay(appID, oldPfdData, nefCtx); pfdReport != nil {
+		c.JSON(http.StatusInternalServerError, pfdReport)
+		return
+	}
+
 	pfdDataForApp := convertPfdDataToPfdDataForApp(oldPfdData)
 	if pfdReport := p.storePfdDataToUDR(appID, pfdDataForApp); pfdReport != nil {
 		c.JSON(http.StatusInternalServerError, pfdReport)
 		return
 	}
+	afPfdTr.SetAllowedDelay(appID, oldPfdData.AllowedDelay)
+	afPfdTr.SetCachingTime(appID, oldPfdData.CachingTime)
+	nefCtx.SaveAf(af)
 	oldPfdData.Self = p.genPfdDataURI(scsAsID, transID, appID)
 	pfdNotifyContext.AddNotification(appID, &models.PfdChangeNotification{
 		ApplicationId: appID,
@@ -551,6 +630,8 @@ func (p *Processor) buildPfdManagement(
 	}
 	for _, pfdDataForApp := range *(rspBody.(*[]models.PfdDataForAppExt)) {
 		pfdData := convertPfdDataForAppToPfdData(&pfdDataForApp)
+		pfdData.AllowedDelay = afPfdTr.AllowedDelays[pfdData.ExternalAppId]
+		pfdData.CachingTime = afPfdTr.CachingTimes[pfdData.ExternalAppId]
 		pfdData.Self = p.genPfdDataURI(afID, transID, pfdData.ExternalAppId)
 		pfdMng.PfdDatas[pfdData.ExternalAppId] = *pfdData
 	}
@@ -560,9 +641,22 @@ func (p *Processor) buildPfdManagement(
 func (p *Processor) storePfdDataToUDR(appID string, pfdDataForApp *models.PfdDataForAppExt) *models.PfdReport {
 	rspCode, _ := p.Consumer().AppDataPfdsAppIdPut(appID, pfdDataForApp)
 	if rspCode != http.StatusCreated && rspCode != http.StatusOK {
-		return &models.PfdReport{
-			ExternalAppIds: []string{appID},
-			FailureCode:    models.FailureCode_MALFUNCTION,
+		return newMalfunctionPfdReport(appID)
+	}
+	return nil
+}
+
+// deletePfdTransFromUDR deletes the PFD data of all applications of the transaction,
+// UDR is restored if any of them fails.
+func (p *Processor) deletePfdTransFromUDR(afPfdTr *nef_context.AfPfdTransaction) *HandlerResponse {
+	txn := p.newPfdUdrTxn(afPfdTr.Log)
+	if rsp := txn.begin(afPfdTr.GetExtAppIDs()); rsp != nil {
+		return rsp
+	}
+	for extAppID := range afPfdTr.ExtAppIDs {
+		if rsp := txn.delete(extAppID); rsp != nil {
+			txn.rollback()
+			return rsp
 		}
 	}
 	return nil
@@ -576,6 +670,21 @@ func (p *Processor) deletePfdDataFromUDR(appID string) *HandlerResponse {
 	return nil
 }
 
+func getPfdDataAppIDs(pfdDatas map[string]models.PfdData) []string {
+	appIDs := make([]string, 0, len(pfdDatas))
+	for appID := range pfdDatas {
+		appIDs = append(appIDs, appID)
+	}
+	return appIDs
+}
+
+func newMalfunctionPfdReport(appID string) *models.PfdReport {
+	return &models.PfdReport{
+		ExternalAppIds: []string{appID},
+		FailureCode:    models.FailureCode_MALFUNCTION,
+	}
+}
+
 // The behavior of PATCH update is based on TS 29.250 v1.15.1 clause 4.4.1
 func patchModifyPfdData(oldPfdData, newPfdData *models.PfdData) *models.ProblemDetails {
 	for pfdID, newPfd := range newPfdData.Pfds {
@@ -593,6 +702,29 @@ func patchModifyPfdData(oldPfdData, newPfdData *models.PfdData) *models.ProblemD
 			oldPfdData.Pfds[pfdID] = newPfd
 		}
 	}
+	if newPfdData.AllowedDelay != 0 {
+		oldPfdData.AllowedDelay = newPfdData.AllowedDelay
+	}
+	if newPfdData.CachingTime != 0 {
+		oldPfdData.CachingTime = newPfdData.CachingTime
+	}
+	return nil
+}
+
+// checkPfdDelay applies the configured caching time if AF doesn't provide one.
+// The PFDs are cached in SMF up to the caching time, so an allowed delay shorter than it
+// can't be met and is reported with SHORT_DELAY (TS 29.122 clause 4.4.10).
+func checkPfdDelay(appID string, pfdData *models.PfdData, nefCtx *nef_context.NefContext) *models.PfdReport {
+	if pfdData.CachingTime == 0 {
+		pfdData.CachingTime = nefCtx.Config().PfdCachingTime()
+	}
+	if pfdData.AllowedDelay > 0 && pfdData.AllowedDelay < pfdData.CachingTime {
+		return &models.PfdReport{
+			ExternalAppIds: []string{appID},
+			FailureCode:    models.FailureCode_SHORT_DELAY,
+			CachingTime:    pfdData.CachingTime,
+		}
+	}
 	return nil
 }
 
@@ -616,6 +748,11 @@ func convertPfdDataToPfdDataForApp(pfdData *models.PfdData) *models.PfdDataForAp
 	pfdDataForApp := &models.PfdDataForAppExt{
 		ApplicationId: pfdData.ExternalAppId,
 	}
+	if pfdData.CachingTime > 0 {
+		// UDR holds the time when the caching expires, the duration is kept in AfPfdTransaction
+		cachingTime := time.Now().Add(time.Duration(pfdData.CachingTime) * time.Second)
+		pfdDataForApp.CachingTime = &cachingTime
+	}
 	for _, pfd := range pfdData.Pfds {
 		var pfdContent models.PfdContent
 		pfdContent.PfdId = pfd.PfdId
@@ -627,6 +764,12 @@ func convertPfdDataToPfdDataForApp(pfdData *models.PfdData) *models.PfdDataForAp
 	return pfdDataForApp
 }
 
+func (p *Processor) genPfdManagementsURI(afID string) string {
+	// E.g. https://localhost:29505/3gpp-pfd-management/v1/{afID}/transactions
+	return fmt.Sprintf("%s/%s/transactions",
+		p.Config().ServiceUri(factory.ServicePfdMng), afID)
+}
+
 func (p *Processor) genPfdManagementURI(afID, transID string) string {
 	// E.g. https://localhost:29505/3gpp-pfd-management/v1/{afID}/transactions/{transID}
 	return fmt.Sprintf("%s/%s/transactions/%s",
@@ -659,10 +802,21 @@ func validatePfdManagement(
 				ExternalAppIds: []string{appID},
 				FailureCode:    models.FailureCode_APP_ID_DUPLICATED,
 			})
+			continue
 		}
 		if pd := validatePfdData(&pfdData, nefCtx, false); pd != nil {
+			// Point at the PfdData in PfdManagement
+			for i := range pd.InvalidParams {
+				pd.InvalidParams[i].Param = "/pfdDatas/" + appID + pd.InvalidParams[i].Param
+			}
 			return pd
 		}
+		if pfdReport := checkPfdDelay(appID, &pfdData, nefCtx); pfdReport != nil {
+			delete(pfdMng.PfdDatas, appID)
+			addPfdReport(pfdMng, pfdReport)
+			continue
+		}
+		pfdMng.PfdDatas[appID] = pfdData
 	}
 
 	if len(pfdMng.PfdDatas) == 0 {
@@ -682,7 +836,8 @@ func validatePfdData(pfdData *models.PfdData, nefCtx *nef_context.NefContext, is
 		return openapi.ProblemDetailsDataNotFound(DetailNoPfd)
 	}
 
-	for _, pfd := range pfdData.Pfds {
+	pfdIDs := make([]string, 0, len(pfdData.Pfds))
+	for pfdID, pfd := range pfdData.Pfds {
 		if pfd.PfdId == "" {
 			return openapi.ProblemDetailsDataNotFound(DetailNoPfdID)
 		}
@@ -690,6 +845,19 @@ func validatePfdData(pfdData *models.PfdData, nefCtx *nef_context.NefContext, is
 		if !isPatch && len(pfd.FlowDescriptions) == 0 && len(pfd.Urls) == 0 && len(pfd.DomainNames) == 0 {
 			return openapi.ProblemDetailsDataNotFound(DetailNoPfdInfo)
 		}
+		pfdIDs = append(pfdIDs, pfdID)
+	}
+
+	sort.Strings(pfdIDs)
+	var invalidParams []models.InvalidParam
+	for _, pfdID := range pfdIDs {
+		pfd := pfdData.Pfds[pfdID]
+		invalidParams = append(invalidParams, validator.Pfd("/pfds/"+pfdID, &pfd)...)
+	}
+	if len(invalidParams) > 0 {
+		pd := openapi.ProblemDetailsMalformedReqSyntax(DetailInvalidPfdInfo)
+		pd.InvalidParams = invalidParams
+		return pd
 	}
 
 	return nil
@@ -698,6 +866,7 @@ func validatePfdData(pfdData *models.PfdData, nefCtx *nef_context.NefContext, is
 func addPfdReport(pfdMng *models.PfdManagement, newReport *models.PfdReport) {
 	if oldReport, ok := pfdMng.PfdReports[string(newReport.FailureCode)]; ok {
 		oldReport.ExternalAppIds = append(oldReport.ExternalAppIds, newReport.ExternalAppIds...)
+		pfdMng.PfdReports[string(newReport.FailureCode)] = oldReport
 	} else {
 		pfdMng.PfdReports[string(newReport.FailureCode)] = *newReport
 	}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	nef_context "github.com/free5gc/nef/internal/context"
@@ -38,7 +39,7 @@ func newTestApp(cfg *factory.Config, tlsKeyLogPath string) (*nefTestApp, error)
 	if nef.consumer, err = consumer.NewConsumer(nef); err != nil {
 		return nil, err
 	}
-	if nef.notifier, err = notifier.NewNotifier(); err != nil {
+	if nef.notifier, err = notifier.NewNotifier(nef.consumer.DirectHTTPClient()); err != nil {
 		return nil, err
 	}
 	if nef.proc, err = NewProcessor(nef); err != nil {
@@ -157,6 +158,7 @@ func TestMain(m *testing.M) {
 }
 
 func TestGetPFDManagementTransactions(t *testing.T) {
+	initNRFDiscUDRStub()
 	initUDRDrGetPfdDatasStub()
 	defer gock.Off()
 
@@ -220,7 +222,7 @@ func TestGetPFDManagementTransactions(t *testing.T) {
 			httpRecorder := httptest.NewRecorder()
 			c, _ := gin.CreateTestContext(httpRecorder)
 
-			nefApp.Processor().GetPFDManagementTransactions(c, tc.afID)
+			nefApp.Processor().GetPFDManagementTransactions(c, tc.afID, &Page{})
 			require.Equal(t, tc.expectedResponse.Status, httpRecorder.Code)
 
 			assertJSONBodyEqual(t, tc.expectedResponse.Body, httpRecorder.Body.Bytes())
@@ -229,6 +231,7 @@ func TestGetPFDManagementTransactions(t *testing.T) {
 }
 
 func TestDeletePFDManagementTransactions(t *testing.T) {
+	initNRFDiscUDRStub()
 	initUDRDrDeletePfdDataStub()
 	defer gock.Off()
 
@@ -277,6 +280,8 @@ func TestDeletePFDManagementTransactions(t *testing.T) {
 }
 
 func TestPostPFDManagementTransactions(t *testing.T) {
+	initNRFDiscUDRStub()
+	initUDRDrGetAbsentPfdDataStub()
 	initUDRDrPutPfdDataStub(http.StatusCreated)
 	defer gock.Off()
 
@@ -378,7 +383,7 @@ func TestPostPFDManagementTransactions(t *testing.T) {
 			httpRecorder := httptest.NewRecorder()
 			c, _ := gin.CreateTestContext(httpRecorder)
 
-			nefApp.Processor().PostPFDManagementTransactions(c, tc.afID, tc.pfdManagement)
+			nefApp.Processor().PostPFDManagementTransactions(c, tc.afID, tc.pfdManagement, nil)
 			require.Equal(t, tc.expectedResponse.Status, httpRecorder.Code)
 
 			assertJSONBodyEqual(t, tc.expectedResponse.Body, httpRecorder.Body.Bytes())
@@ -387,6 +392,7 @@ func TestPostPFDManagementTransactions(t *testing.T) {
 }
 
 func TestGetIndividualPFDManagementTransaction(t *testing.T) {
+	initNRFDiscUDRStub()
 	initUDRDrGetPfdDatasStub()
 	defer gock.Off()
 
@@ -460,6 +466,7 @@ func TestGetIndividualPFDManagementTransaction(t *testing.T) {
 }
 
 func TestDeleteIndividualPFDManagementTransaction(t *testing.T) {
+	initNRFDiscUDRStub()
 	initUDRDrDeletePfdDataStub()
 	defer gock.Off()
 
@@ -511,6 +518,8 @@ func TestDeleteIndividualPFDManagementTransaction(t *testing.T) {
 }
 
 func TestPutIndividualPFDManagementTransaction(t *testing.T) {
+	initNRFDiscUDRStub()
+	initUDRDrGetAbsentPfdDataStub()
 	initUDRDrPutPfdDataStub(http.StatusOK)
 	defer gock.Off()
 
@@ -629,7 +638,214 @@ func TestPutIndividualPFDManagementTransaction(t *testing.T) {
 	}
 }
 
+// PUT is all-or-nothing, a failed application fails the whole transaction with 500,
+// instead of the partial success of POST
+func TestPutIndividualPFDManagementTransactionRollback(t *testing.T) {
+	initNRFDiscUDRStub()
+	initUDRDrDeletePfdDataStub()
+	// The snapshot of app1 and app2 is taken once, only app1 is in UDR
+	snapshotMock := gock.New("http://127.0.0.4:8000/nudr-dr/v1").
+		Get("/application-data/pfds$").
+		Reply(http.StatusOK).
+		JSON([]models.PfdDataForApp{pfdDataForApp1}).Mock
+	gock.New("http://127.0.0.4:8000/nudr-dr/v1").
+		Put("/application-data/pfds/app2").
+		Persist().
+		Reply(http.StatusInternalServerError).
+		JSON(models.ProblemDetails{Status: http.StatusInternalServerError})
+	gock.New("http://127.0.0.4:8000/nudr-dr/v1").
+		Put("/application-data/pfds/app1").
+		Persist().
+		Reply(http.StatusCreated).
+		SetHeader("Location", "http://127.0.0.4:8000/nudr-dr/v1/application-data/pfds/app1").
+		JSON(pfdDataForApp1)
+	defer gock.Off()
+
+	// `restoreChan` is used to pass the requests restoring app1 in UDR intercepted by gock.
+	restoreChan := make(chan *http.Request, 1)
+	gock.Observe(func(request *http.Request, mock gock.Mock) {
+		if request.Method == http.MethodPut && strings.HasSuffix(request.URL.Path, "/pfds/app1") {
+			restoreChan <- request
+		}
+	})
+	defer gock.Observe(nil)
+
+	af := nefApp.Context().NewAf("af1")
+	af.Mu.Lock()
+	afPfdTr := af.NewPfdTrans()
+	afPfdTr.AddExtAppID("app1")
+	af.PfdTrans[afPfdTr.TransID] = afPfdTr
+	af.Mu.Unlock()
+	nefApp.Context().AddAf(af)
+	defer nefApp.Context().DeleteAf("af1")
+
+	httpRecorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(httpRecorder)
+
+	// app1 is deleted before app2 fails, so it should be restored and kept in the transaction
+	nefApp.Processor().PutIndividualPFDManagementTransaction(c, "af1", afPfdTr.TransID, &models.PfdManagement{
+		PfdDatas: map[string]models.PfdData{
+			"app2": {
+				ExternalAppId: "app2",
+				Pfds: map[string]models.Pfd{
+					"pfd3": pfd3,
+				},
+			},
+		},
+	})
+	require.Equal(t, http.StatusInternalServerError, httpRecorder.Code)
+	assertJSONBodyEqual(t, &map[string]models.PfdReport{
+		string(models.FailureCode_MALFUNCTION): {
+			ExternalAppIds: []string{"app2"},
+			FailureCode:    models.FailureCode_MALFUNCTION,
+		},
+	}, httpRecorder.Body.Bytes())
+
+	select {
+	case <-restoreChan:
+	default:
+		t.Fatal("app1 is not restored in UDR")
+	}
+	require.True(t, snapshotMock.Done())
+
+	af.Mu.RLock()
+	require.Equal(t, []string{"app1"}, afPfdTr.GetExtAppIDs())
+	af.Mu.RUnlock()
+}
+
+// An application rejected by the validation fails the whole PUT, the current PFDs in UDR are untouched
+func TestPutIndividualPFDManagementTransactionShortDelay(t *testing.T) {
+	initNRFDiscUDRStub()
+	initUDRDrGetAbsentPfdDataStub()
+	deleteMock := gock.New("http://127.0.0.4:8000/nudr-dr/v1").
+		Delete("/application-data/pfds/.*").
+		Persist().
+		Reply(http.StatusNoContent).Mock
+	putMock := gock.New("http://127.0.0.4:8000/nudr-dr/v1").
+		Put("/application-data/pfds/.*").
+		Persist().
+		Reply(http.StatusOK).
+		JSON(pfdDataForApp1).Mock
+	defer gock.Off()
+
+	af := nefApp.Context().NewAf("af1")
+	af.Mu.Lock()
+	afPfdTr := af.NewPfdTrans()
+	afPfdTr.AddExtAppID("app1")
+	af.PfdTrans[afPfdTr.TransID] = afPfdTr
+	af.Mu.Unlock()
+	nefApp.Context().AddAf(af)
+	defer nefApp.Context().DeleteAf("af1")
+
+	httpRecorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(httpRecorder)
+
+	nefApp.Processor().PutIndividualPFDManagementTransaction(c, "af1", afPfdTr.TransID, &models.PfdManagement{
+		PfdDatas: map[string]models.PfdData{
+			"app1": {
+				ExternalAppId: "app1",
+				Pfds: map[string]models.Pfd{
+					"pfd1": pfd1,
+				},
+				AllowedDelay: 5,
+				CachingTime:  10,
+			},
+			"app2": {
+				ExternalAppId: "app2",
+				Pfds: map[string]models.Pfd{
+					"pfd3": pfd3,
+				},
+			},
+		},
+	})
+	require.Equal(t, http.StatusInternalServerError, httpRecorder.Code)
+	assertJSONBodyEqual(t, &map[string]models.PfdReport{
+		string(models.FailureCode_SHORT_DELAY): {
+			ExternalAppIds: []string{"app1"},
+			FailureCode:    models.FailureCode_SHORT_DELAY,
+			CachingTime:    10,
+		},
+	}, httpRecorder.Body.Bytes())
+
+	// The PFDs of app1 are neither deleted nor replaced in UDR, and app2 isn't created
+	require.False(t, deleteMock.Done())
+	require.False(t, putMock.Done())
+
+	af.Mu.RLock()
+	require.Equal(t, []string{"app1"}, afPfdTr.GetExtAppIDs())
+	af.Mu.RUnlock()
+}
+
+// An application provisioned by another transaction fails the whole PUT, instead of being left out of it
+func TestPutIndividualPFDManagementTransactionDuplicated(t *testing.T) {
+	initNRFDiscUDRStub()
+	initUDRDrGetAbsentPfdDataStub()
+	deleteMock := gock.New("http://127.0.0.4:8000/nudr-dr/v1").
+		Delete("/application-data/pfds/.*").
+		Persist().
+		Reply(http.StatusNoContent).Mock
+	putMock := gock.New("http://127.0.0.4:8000/nudr-dr/v1").
+		Put("/application-data/pfds/.*").
+		Persist().
+		Reply(http.StatusOK).
+		JSON(pfdDataForApp1).Mock
+	defer gock.Off()
+
+	af := nefApp.Context().NewAf("af1")
+	af.Mu.Lock()
+	afPfdTr := af.NewPfdTrans()
+	afPfdTr.AddExtAppID("app1")
+	af.PfdTrans[afPfdTr.TransID] = afPfdTr
+	af.Mu.Unlock()
+	nefApp.Context().AddAf(af)
+	defer nefApp.Context().DeleteAf("af1")
+
+	otherAf := nefApp.Context().NewAf("af2")
+	otherAf.Mu.Lock()
+	otherAfPfdTr := otherAf.NewPfdTrans()
+	otherAfPfdTr.AddExtAppID("app3")
+	otherAf.PfdTrans[otherAfPfdTr.TransID] = otherAfPfdTr
+	otherAf.Mu.Unlock()
+	nefApp.Context().AddAf(otherAf)
+	defer nefApp.Context().DeleteAf("af2")
+
+	httpRecorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(httpRecorder)
+
+	nefApp.Processor().PutIndividualPFDManagementTransaction(c, "af1", afPfdTr.TransID, &models.PfdManagement{
+		PfdDatas: map[string]models.PfdData{
+			"app2": {
+				ExternalAppId: "app2",
+				Pfds: map[string]models.Pfd{
+					"pfd3": pfd3,
+				},
+			},
+			"app3": {
+				ExternalAppId: "app3",
+				Pfds: map[string]models.Pfd{
+					"pfd1": pfd1,
+				},
+			},
+		},
+	})
+	require.Equal(t, http.StatusInternalServerError, httpRecorder.Code)
+	assertJSONBodyEqual(t, &map[string]models.PfdReport{
+		string(models.FailureCode_APP_ID_DUPLICATED): {
+			ExternalAppIds: []string{"app3"},
+			FailureCode:    models.FailureCode_APP_ID_DUPLICATED,
+		},
+	}, httpRecorder.Body.Bytes())
+
+	require.False(t, deleteMock.Done())
+	require.False(t, putMock.Done())
+
+	af.Mu.RLock()
+	require.Equal(t, []string{"app1"}, afPfdTr.GetExtAppIDs())
+	af.Mu.RUnlock()
+}
+
 func TestGetIndividualApplicationPFDManagement(t *testing.T) {
+	initNRFDiscUDRStub()
 	initUDRDrGetPfdDataStub()
 	defer gock.Off()
 
@@ -641,7 +857,7 @@ func TestGetIndividualApplicationPFDManagement(t *testing.T) {
 		expectedResponse *HandlerResponse
 	}{
 		{
-			description: "TC1: Valid input",
+			description: "TC1: Valid input, should return the caching time requested by AF",
 			afID:        "af1",
 			transID:     "1",
 			appID:       "app1",
@@ -654,6 +870,7 @@ func TestGetIndividualApplicationPFDManagement(t *testing.T) {
 						"pfd1": pfd1,
 						"pfd2": pfd2,
 					},
+					CachingTime: 3600,
 				},
 			},
 		},
@@ -679,6 +896,7 @@ func TestGetIndividualApplicationPFDManagement(t *testing.T) {
 			afPfdTr := af.NewPfdTrans()
 			af.PfdTrans[afPfdTr.TransID] = afPfdTr
 			afPfdTr.AddExtAppID("app1")
+			afPfdTr.SetCachingTime("app1", 3600)
 			af.Mu.Unlock()
 
 			httpRecorder := httptest.NewRecorder()
@@ -693,6 +911,7 @@ func TestGetIndividualApplicationPFDManagement(t *testing.T) {
 }
 
 func TestDeleteIndividualApplicationPFDManagement(t *testing.T) {
+	initNRFDiscUDRStub()
 	initUDRDrDeletePfdDataStub()
 	defer gock.Off()
 
@@ -748,6 +967,7 @@ func TestDeleteIndividualApplicationPFDManagement(t *testing.T) {
 }
 
 func TestPutIndividualApplicationPFDManagement(t *testing.T) {
+	initNRFDiscUDRStub()
 	initUDRDrPutPfdDataStub(http.StatusOK)
 	defer gock.Off()
 
@@ -844,6 +1064,7 @@ func TestPutIndividualApplicationPFDManagement(t *testing.T) {
 }
 
 func TestPatchIndividualApplicationPFDManagement(t *testing.T) {
+	initNRFDiscUDRStub()
 	initUDRDrGetPfdDataStub()
 	initUDRDrPutPfdDataStub(http.StatusOK)
 	defer gock.Off()
@@ -1025,6 +1246,65 @@ func TestValidatePfdManagement(t *testing.T) {
 				},
 			},
 		},
+		{
+			description: "TC5: Malformed FlowDescription, should return InvalidParams pointing at the PfdData",
+			pfdManagement: &models.PfdManagement{
+				PfdDatas: map[string]models.PfdData{
+					"app1": {
+						ExternalAppId: "app1",
+						Pfds: map[string]models.Pfd{
+							"pfd1": {
+								PfdId:            "pfd1",
+								FlowDescriptions: []string{"permit inout ip from any to any"},
+							},
+						},
+					},
+				},
+			},
+			expectedProblem: &models.ProblemDetails{
+				Title:  "Malformed request syntax",
+				Status: http.StatusBadRequest,
+				Detail: DetailInvalidPfdInfo,
+				InvalidParams: []models.InvalidParam{
+					{
+						Param:  "/pfdDatas/app1/pfds/pfd1/flowDescriptions/0",
+						Reason: "direction should be 'in' or 'out'",
+					},
+				},
+			},
+			expectedReports: map[string]models.PfdReport{},
+		},
+		{
+			description: "TC6: Allowed delay shorter than caching time, should mark SHORT_DELAY in PfdReports",
+			pfdManagement: &models.PfdManagement{
+				PfdDatas: map[string]models.PfdData{
+					"app1": {
+						ExternalAppId: "app1",
+						Pfds: map[string]models.Pfd{
+							"pfd1": pfd1,
+						},
+						AllowedDelay: 60,
+						CachingTime:  120,
+					},
+					"app2": {
+						ExternalAppId: "app2",
+						Pfds: map[string]models.Pfd{
+							"pfd3": pfd3,
+						},
+						AllowedDelay: 120,
+						CachingTime:  60,
+					},
+				},
+			},
+			expectedProblem: nil,
+			expectedReports: map[string]models.PfdReport{
+				string(models.FailureCode_SHORT_DELAY): {
+					ExternalAppIds: []string{"app1"},
+					FailureCode:    models.FailureCode_SHORT_DELAY,
+					CachingTime:    120,
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -1105,6 +1385,63 @@ func TestValidatePfdData(t *testing.T) {
 			},
 			expectedResult: openapi.ProblemDetailsDataNotFound(DetailNoPfdInfo),
 		},
+		{
+			description: "TC6: Valid CIDR, port ranges, URL and wildcard domain name",
+			pfdData: &models.PfdData{
+				ExternalAppId: "app1",
+				Pfds: map[string]models.Pfd{
+					"pfd1": {
+						PfdId: "pfd1",
+						FlowDescriptions: []string{
+							"permit out 17 from 10.0.0.0/8 1000-2000,3000 to assigned",
+						},
+						Urls:        []string{"https://example.com/video"},
+						DomainNames: []string{"*.example.com"},
+					},
+				},
+			},
+			expectedResult: nil,
+		},
+		{
+			description: "TC7: Malformed FlowDescriptions, Urls and DomainNames, should return InvalidParams",
+			pfdData: &models.PfdData{
+				ExternalAppId: "app1",
+				Pfds: map[string]models.Pfd{
+					"pfd1": {
+						PfdId: "pfd1",
+						FlowDescriptions: []string{
+							"permit out 256 from any to any",
+							"permit out ip from 10.0.0.0/8 8080-80 to any",
+						},
+						Urls:        []string{"ftp://example.com"},
+						DomainNames: []string{"*.example.com", "-bad.example.com"},
+					},
+				},
+			},
+			expectedResult: &models.ProblemDetails{
+				Title:  "Malformed request syntax",
+				Status: http.StatusBadRequest,
+				Detail: DetailInvalidPfdInfo,
+				InvalidParams: []models.InvalidParam{
+					{
+						Param:  "/pfds/pfd1/flowDescriptions/0",
+						Reason: "invalid protocol[256], should be 'ip' or 0-255",
+					},
+					{
+						Param:  "/pfds/pfd1/flowDescriptions/1",
+						Reason: "invalid port range[8080-80], start is larger than end",
+					},
+					{
+						Param:  "/pfds/pfd1/urls/0",
+						Reason: "invalid URL scheme[ftp], should be http or https",
+					},
+					{
+						Param:  "/pfds/pfd1/domainNames/1",
+						Reason: "invalid label[-bad] of domain name[-bad.example.com]",
+					},
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -1227,6 +1564,30 @@ func TestPatchModifyPfdData(t *testing.T) {
 				},
 			},
 		},
+		{
+			description: "TC5: Given a PfdData with allowedDelay and cachingTime, should update them",
+			old: &models.PfdData{
+				ExternalAppId: "app1",
+				Pfds: map[string]models.Pfd{
+					"pfd1": pfd1,
+				},
+				AllowedDelay: 300,
+				CachingTime:  60,
+			},
+			new: &models.PfdData{
+				ExternalAppId: "app1",
+				CachingTime:   120,
+			},
+			expectedProblem: nil,
+			expectedResult: &models.PfdData{
+				ExternalAppId: "app1",
+				Pfds: map[string]models.Pfd{
+					"pfd1": pfd1,
+				},
+				AllowedDelay: 300,
+				CachingTime:  120,
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -1295,6 +1656,7 @@ func initNRFDiscUDRStub() {
 		MatchParam("target-nf-type", "UDR").
 		MatchParam("requester-nf-type", "NEF").
 		MatchParam("service-names", "nudr-dr").
+		Persist().
 		Reply(http.StatusOK).
 		JSON(searchResult)
 }
@@ -1383,6 +1745,15 @@ func initUDRDrGetPfdDataStub() {
 		JSON(models.ProblemDetails{Status: http.StatusNotFound})
 }
 
+func initUDRDrGetAbsentPfdDataStub() {
+	// Matches both the PFD data of all applications and the one of an application
+	gock.New("http://127.0.0.4:8000/nudr-dr/v1").
+		Get("/application-data/pfds").
+		Persist().
+		Reply(http.StatusNotFound).
+		JSON(models.ProblemDetails{Status: http.StatusNotFound})
+}
+
 func initUDRDrDeletePfdDataStub() {
 	gock.New("http://127.0.0.4:8000/nudr-dr/v1").
 		Delete("/application-data/pfds/.*").
@@ -0,0 +1,117 @@
+package processor
+
+import (
+	"net/http"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/sirupsen/logrus"
+)
+
+// pfdUdrTxn applies the PFD data changes of a PFD management transaction to UDR.
+// The previous PfdDataForAppExt of the applications are snapshotted by begin() with a single request,
+// so that rollback() can restore the UDR content when the transaction fails half-way.
+type pfdUdrTxn struct {
+	p         *Processor
+	log       *logrus.Entry
+	olds      map[string]*models.PfdDataForAppExt // appID -> UDR content before the transaction, nil if absent
+	snapshots []pfdSnapshot                       // in the order of changes
+}
+
+type pfdSnapshot struct {
+	appID string
+	old   *models.PfdDataForAppExt // nil if the application was absent in UDR
+}
+
+func (p *Processor) newPfdUdrTxn(log *logrus.Entry) *pfdUdrTxn {
+	return &pfdUdrTxn{
+		p:   p,
+		log: log,
+	}
+}
+
+// begin snapshots the PFD data of all applications which the transaction may change
+func (t *pfdUdrTxn) begin(appIDs []string) *HandlerResponse {
+	t.olds = make(map[string]*models.PfdDataForAppExt, len(appIDs))
+	if len(appIDs) == 0 {
+		return nil
+	}
+
+	// The generated UDR client can't encode multiple appId query parameters,
+	// so the PFD data of all applications is read for more than one application.
+	var queryAppIDs []string
+	if len(appIDs) == 1 {
+		queryAppIDs = appIDs
+	}
+	rspCode, rspBody := t.p.Consumer().AppDataPfdsGet(queryAppIDs)
+	switch rspCode {
+	case http.StatusOK:
+		pfdDataForApps := *rspBody.(*[]models.PfdDataForAppExt)
+		for i := range pfdDataForApps {
+			t.olds[pfdDataForApps[i].ApplicationId] = &pfdDataForApps[i]
+		}
+	case http.StatusNotFound:
+		// None of the applications is in UDR
+	default:
+		return &HandlerResponse{rspCode, nil, rspBody}
+	}
+	return nil
+}
+
+func (t *pfdUdrTxn) snapshot(appID string) *pfdSnapshot {
+	return &pfdSnapshot{appID: appID, old: t.olds[appID]}
+}
+
+// store creates or replaces the PFD data of the application in UDR.
+// On failure, the application is restored at once and not kept in the transaction.
+func (t *pfdUdrTxn) store(appID string, pfdDataForApp *models.PfdDataForAppExt) *HandlerResponse {
+	snapshot := t.snapshot(appID)
+
+	rspCode, rspBody := t.p.Consumer().AppDataPfdsAppIdPut(appID, pfdDataForApp)
+	if rspCode != http.StatusCreated && rspCode != http.StatusOK && rspCode != http.StatusNoContent {
+		// UDR may have applied the request even if the response is lost
+		t.restore(snapshot)
+		return &HandlerResponse{rspCode, nil, rspBody}
+	}
+	t.snapshots = append(t.snapshots, *snapshot)
+	return nil
+}
+
+// delete removes the PFD data of the application from UDR, an absent application is ignored
+func (t *pfdUdrTxn) delete(appID string) *HandlerResponse {
+	snapshot := t.snapshot(appID)
+	if snapshot.old == nil {
+		return nil
+	}
+
+	rspCode, rspBody := t.p.Consumer().AppDataPfdsAppIdDelete(appID)
+	if rspCode != http.StatusNoContent {
+		t.restore(snapshot)
+		return &HandlerResponse{rspCode, nil, rspBody}
+	}
+	t.snapshots = append(t.snapshots, *snapshot)
+	return nil
+}
+
+// rollback restores the UDR content changed by the transaction in reverse order
+func (t *pfdUdrTxn) rollback() {
+	for i := len(t.snapshots) - 1; i >= 0; i-- {
+		t.restore(&t.snapshots[i])
+	}
+	t.snapshots = nil
+}
+
+func (t *pfdUdrTxn) restore(snapshot *pfdSnapshot) {
+	if snapshot.old == nil {
+		rspCode, rspBody := t.p.Consumer().AppDataPfdsAppIdDelete(snapshot.appID)
+		if rspCode != http.StatusNoContent && rspCode != http.StatusNotFound {
+			t.log.Errorf("Restore appID[%s] by deletion failed: status[%d], body[%+v]",
+				snapshot.appID, rspCode, rspBody)
+		}
+		return
+	}
+
+	rspCode, rspBody := t.p.Consumer().AppDataPfdsAppIdPut(snapshot.appID, snapshot.old)
+	if rspCode != http.StatusCreated && rspCode != http.StatusOK && rspCode != http.StatusNoContent {
+		t.log.Errorf("Restore appID[%s] failed: status[%d], body[%+v]", snapshot.appID, rspCode, rspBody)
+	}
+}
@@ -14,6 +14,7 @@ import (
 )
 
 func TestGetApplicationsPFD(t *testing.T) {
+	initNRFDiscUDRStub()
 	initUDRDrGetPfdDatasStub()
 	defer gock.Off()
 
@@ -54,6 +55,7 @@ func TestGetApplicationsPFD(t *testing.T) {
 }
 
 func TestGetIndividualApplicationPFD(t *testing.T) {
+	initNRFDiscUDRStub()
 	initUDRDrGetPfdDataStub()
 	defer gock.Off()
 
@@ -172,6 +174,7 @@ var (
 
 func TestPostPfdChangeReports(t *testing.T) {
 	// Note: Because TestPostPFDSubscriptions() already used subscription ID 1, the ID will start from 2 here.
+	initNRFDiscUDRStub()
 	initUDRDrPutPfdDataStub(http.StatusOK)
 	initUDRDrDeletePfdDataStub()
 	initNEFNotificationStub("http://pfdSub2URI")
@@ -19,6 +19,11 @@ type nef interface {
 
 type Processor struct {
 	nef
+
+	capif        *capifAef
+	capifRoutes  map[string][]CapifRoute // serviceName -> route table of the API published to CAPIF
+	reconcile    *reconcileState
+	nfStatusSubs *nfStatusSubscriptions
 }
 
 type HandlerResponse struct {
@@ -29,7 +34,11 @@ type HandlerResponse struct {
 
 func NewProcessor(nef nef) (*Processor, error) {
 	handler := &Processor{
-		nef: nef,
+		nef:          nef,
+		capif:        newCapifAef(),
+		capifRoutes:  make(map[string][]CapifRoute),
+		reconcile:    &reconcileState{},
+		nfStatusSubs: newNfStatusSubscriptions(),
 	}
 
 	return handler, nil
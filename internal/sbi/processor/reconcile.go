@@ -0,0 +1,298 @@
+package processor
+
+import (
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	nef_context "github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/openapi/models"
+	"github.com/sirupsen/logrus"
+)
+
+var _ nef_context.Reconciler = &Processor{}
+
+type ReconcileMismatchType string
+
+const (
+	// The appID of the PFD management transaction is absent in UDR
+	ReconcilePfdMissingInUdr ReconcileMismatchType = "PFD_MISSING_IN_UDR"
+	// The app session of the active single UE subscription is absent in PCF
+	ReconcileAppSessionMissingInPcf ReconcileMismatchType = "APP_SESSION_MISSING_IN_PCF"
+	// The influence data of the active group or any UE subscription is absent in UDR
+	ReconcileInfluDataMissingInUdr ReconcileMismatchType = "INFLUENCE_DATA_MISSING_IN_UDR"
+)
+
+type ReconcileAction string
+
+const (
+	ReconcileActionFlagged      ReconcileAction = "FLAGGED"
+	ReconcileActionRepaired     ReconcileAction = "REPAIRED"
+	ReconcileActionRepairFailed ReconcileAction = "REPAIR_FAILED"
+)
+
+type ReconcileMismatch struct {
+	Type    ReconcileMismatchType `json:"type"`
+	Action  ReconcileAction       `json:"action"`
+	AfID    string                `json:"afId"`
+	SubID   string                `json:"subscriptionId,omitempty"`
+	TransID string                `json:"transactionId,omitempty"`
+	AppID   string                `json:"externalAppId,omitempty"`
+}
+
+// ReconcileReport is the result of a reconciliation exposed by OAM
+type ReconcileReport struct {
+	StartTime            time.Time `json:"startTime"`
+	EndTime              time.Time `json:"endTime"`
+	CheckedSubscriptions int       `json:"checkedSubscriptions"`
+	CheckedApplications  int       `json:"checkedApplications"`
+	// Number of checks not completed due to UDR/PCF failures, they're retried in next reconciliation
+	Failures   int                 `json:"failures"`
+	Mismatches []ReconcileMismatch `json:"mismatches"`
+}
+
+// reconcileState keeps the report of the last reconciliation
+type reconcileState struct {
+	mu         sync.Mutex
+	lastReport *ReconcileReport
+}
+
+func (p *Processor) LastReconcileReport() *ReconcileReport {
+	p.reconcile.mu.Lock()
+	defer p.reconcile.mu.Unlock()
+	return p.reconcile.lastReport
+}
+
+// reconcilePfdTrans is a copy of a PFD management transaction taken under the lock of AF
+type reconcilePfdTrans struct {
+	transID string
+	appIDs  []string
+	log     *logrus.Entry
+}
+
+// reconcileSub is a copy of an active subscription taken under the lock of AF, the traffic influence
+// is repaired on the copy and the result is applied to the subscription afterwards.
+type reconcileSub struct {
+	sub      *nef_context.AfSubscription
+	work     *nef_context.AfSubscription
+	repaired bool
+}
+
+// Reconcile compares the PFD management transactions and the active traffic influence subscriptions
+// with UDR/PCF. The mismatches are repaired only if it's configured, since UDR/PCF may be changed by OAM on purpose.
+// The AF is copied under its lock, the requests to UDR/PCF are sent without it and the repairs are applied
+// under it again.
+func (p *Processor) Reconcile(now time.Time) {
+	repair := false
+	if cfg := p.Config().Reconciliation(); cfg != nil {
+		repair = cfg.Repair
+	}
+
+	report := &ReconcileReport{
+		StartTime:  now,
+		Mismatches: []ReconcileMismatch{},
+	}
+	for _, af := range p.Context().Afs() {
+		pfdTrs, subs := snapshotReconcileAf(af)
+		for _, pfdTr := range pfdTrs {
+			p.reconcilePfdTrans(af, pfdTr, report)
+		}
+		for _, rs := range subs {
+			rs.repaired = p.reconcileTrafficInflu(af, rs.work, repair, report)
+		}
+
+		for _, orphan := range p.applyReconcileRepairs(af, subs) {
+			// The traffic influence is installed again while the subscription is removed or changed
+			orphan.Log.Infoln("Subscription is changed during reconciliation, uninstall the stale traffic influence")
+			if rsp := p.uninstallTrafficInflu(orphan); rsp != nil {
+				orphan.Log.Errorf("Uninstall stale traffic influence failed: status[%d], body[%+v]",
+					rsp.Status, rsp.Body)
+			}
+		}
+	}
+	report.EndTime = time.Now()
+
+	logger.ProcessorLog.Infof("Reconciliation is done: %d subscriptions, %d applications, %d mismatches, %d failures",
+		report.CheckedSubscriptions, report.CheckedApplications, len(report.Mismatches), report.Failures)
+
+	p.reconcile.mu.Lock()
+	p.reconcile.lastReport = report
+	p.reconcile.mu.Unlock()
+}
+
+func snapshotReconcileAf(af *nef_context.AfData) ([]*reconcilePfdTrans, []*reconcileSub) {
+	af.Mu.RLock()
+	defer af.Mu.RUnlock()
+
+	var pfdTrs []*reconcilePfdTrans
+	for _, pfdTr := range af.PfdTrans {
+		pfdTrs = append(pfdTrs, &reconcilePfdTrans{
+			transID: pfdTr.TransID,
+			appIDs:  pfdTr.GetExtAppIDs(),
+			log:     pfdTr.Log,
+		})
+	}
+
+	var subs []*reconcileSub
+	for _, sub := range af.Subs {
+		if sub.TiSub == nil || sub.TempValidityState != nef_context.TempValidityActive {
+			// Not installed in PCF/UDR
+			continue
+		}
+		work := *sub
+		tiSub := *sub.TiSub
+		work.TiSub = &tiSub
+		subs = append(subs, &reconcileSub{
+			sub:  sub,
+			work: &work,
+		})
+	}
+	return pfdTrs, subs
+}
+
+// applyReconcileRepairs updates the subscriptions by the repaired copies, and returns the copies of
+// the subscriptions which are removed or changed in the meantime
+func (p *Processor) applyReconcileRepairs(af *nef_context.AfData, subs []*reconcileSub) []*nef_context.AfSubscription {
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	var orphans []*nef_context.AfSubscription
+	changed := false
+	for _, rs := range subs {
+		if !rs.repaired {
+			continue
+		}
+		sub, ok := af.Subs[rs.work.SubID]
+		if !ok || sub != rs.sub || sub.TempValidityState != nef_context.TempValidityActive ||
+			!reflect.DeepEqual(sub.TiSub, rs.work.TiSub) {
+			orphans = append(orphans, rs.work)
+			continue
+		}
+		sub.AppSessID = rs.work.AppSessID
+		sub.PcfUri = rs.work.PcfUri
+		sub.Supi = rs.work.Supi
+		sub.InfluID = rs.work.InfluID
+		changed = true
+	}
+	if changed {
+		p.Context().SaveAf(af)
+	}
+	return orphans
+}
+
+// reconcilePfdTrans checks whether the appIDs of the transaction exist in UDR. NEF doesn't keep the PFDs
+// to store them again, so the appID absent in UDR is only flagged for OAM even if repair is configured.
+func (p *Processor) reconcilePfdTrans(
+	af *nef_context.AfData,
+	pfdTr *reconcilePfdTrans,
+	report *ReconcileReport,
+) {
+	if len(pfdTr.appIDs) == 0 {
+		return
+	}
+
+	// The generated UDR client can't encode multiple appId query parameters,
+	// so the PFD data of all applications is read for more than one application.
+	var queryAppIDs []string
+	if len(pfdTr.appIDs) == 1 {
+		queryAppIDs = pfdTr.appIDs
+	}
+	existed := make(map[string]struct{}, len(pfdTr.appIDs))
+	rspCode, rspBody := p.Consumer().AppDataPfdsGet(queryAppIDs)
+	switch rspCode {
+	case http.StatusOK:
+		for _, pfdDataForApp := range *rspBody.(*[]models.PfdDataForAppExt) {
+			existed[pfdDataForApp.ApplicationId] = struct{}{}
+		}
+	case http.StatusNotFound:
+		// None of the appIDs exists
+	default:
+		pfdTr.log.Errorf("Reconcile PFDs failed: status[%d], body[%+v]", rspCode, rspBody)
+		report.Failures++
+		return
+	}
+	report.CheckedApplications += len(pfdTr.appIDs)
+
+	for _, appID := range pfdTr.appIDs {
+		if _, ok := existed[appID]; ok {
+			continue
+		}
+		pfdTr.log.Warnf("appID[%s] is absent in UDR", appID)
+		report.Mismatches = append(report.Mismatches, ReconcileMismatch{
+			Type:    ReconcilePfdMissingInUdr,
+			Action:  ReconcileActionFlagged,
+			AfID:    af.AfID,
+			TransID: pfdTr.transID,
+			AppID:   appID,
+		})
+	}
+}
+
+// reconcileTrafficInflu returns true if the traffic influence absent in PCF/UDR is repaired by installing
+// it again, sub is a copy of the subscription and af.Mu is not locked.
+func (p *Processor) reconcileTrafficInflu(
+	af *nef_context.AfData,
+	sub *nef_context.AfSubscription,
+	repair bool,
+	report *ReconcileReport,
+) bool {
+	var mismatchType ReconcileMismatchType
+	if sub.AppSessID != "" {
+		rspCode, rspBody := p.Consumer().GetAppSession(sub.PcfUri, sub.AppSessID)
+		switch rspCode {
+		case http.StatusOK:
+		case http.StatusNotFound:
+			mismatchType = ReconcileAppSessionMissingInPcf
+		default:
+			sub.Log.Errorf("Reconcile app session failed: status[%d], body[%+v]", rspCode, rspBody)
+			report.Failures++
+			return false
+		}
+	} else if sub.InfluID != "" {
+		rspCode, rspBody := p.Consumer().AppDataInfluenceDataIdGet(sub.InfluID,
+			newNfDiscoveryQuery(sub.TiSub, ""))
+		switch rspCode {
+		case http.StatusOK:
+			if tiDatas, ok := rspBody.([]models.TrafficInfluData); ok && len(tiDatas) == 0 {
+				mismatchType = ReconcileInfluDataMissingInUdr
+			}
+		case http.StatusNoContent, http.StatusNotFound:
+			mismatchType = ReconcileInfluDataMissingInUdr
+		default:
+			sub.Log.Errorf("Reconcile influence data failed: status[%d], body[%+v]", rspCode, rspBody)
+			report.Failures++
+			return false
+		}
+	} else {
+		return false
+	}
+	report.CheckedSubscriptions++
+	if mismatchType == "" {
+		return false
+	}
+
+	mismatch := ReconcileMismatch{
+		Type:   mismatchType,
+		Action: ReconcileActionFlagged,
+		AfID:   af.AfID,
+		SubID:  sub.SubID,
+	}
+	sub.Log.Warnf("Traffic influence mismatch: %s", mismatchType)
+	changed := false
+	if repair {
+		// A new app session replaces the absent one in single UE case, and it's retried in next
+		// reconciliation on failure.
+		if rsp := p.installTrafficInflu(sub); rsp != nil {
+			sub.Log.Errorf("Repair traffic influence failed: status[%d], body[%+v]", rsp.Status, rsp.Body)
+			mismatch.Action = ReconcileActionRepairFailed
+		} else {
+			sub.Log.Infoln("Traffic influence is repaired")
+			mismatch.Action = ReconcileActionRepaired
+			changed = true
+		}
+	}
+	report.Mismatches = append(report.Mismatches, mismatch)
+	return changed
+}
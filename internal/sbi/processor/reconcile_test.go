@@ -0,0 +1,110 @@
+package processor
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	nef_context "github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi/models"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestReconcile(t *testing.T) {
+	cfg := *nefApp.Config()
+	configuration := *cfg.Configuration
+	configuration.Reconciliation = &factory.Reconciliation{
+		Repair: true,
+	}
+	cfg.Configuration = &configuration
+
+	// A new NEF is used, so that only the AF below is reconciled
+	app, err := newTestApp(&cfg, "")
+	require.NoError(t, err)
+	nefCtx := app.Context()
+
+	af := nefCtx.NewAf("af1")
+	af.Mu.Lock()
+	afPfdTr := af.NewPfdTrans()
+	afPfdTr.AddExtAppID("app1")
+	afPfdTr.AddExtAppID("app3")
+	af.PfdTrans[afPfdTr.TransID] = afPfdTr
+	afSub := af.NewSub(nefCtx.NewCorreID(), &models.NefTrafficInfluSub{
+		AfAppId:                 "app1",
+		AnyUeInd:                true,
+		NotificationDestination: "http://af1/notify",
+	})
+	afSub.InfluID = "influ1"
+	af.Subs[afSub.SubID] = afSub
+	inactiveSub := af.NewSub(nefCtx.NewCorreID(), &models.NefTrafficInfluSub{
+		AfAppId:  "app1",
+		AnyUeInd: true,
+	})
+	inactiveSub.TempValidityState = nef_context.TempValidityInactive
+	af.Subs[inactiveSub.SubID] = inactiveSub
+	af.Mu.Unlock()
+	nefCtx.AddAf(af)
+
+	initNRFDiscUDRStub()
+	initUDRDrGetPfdDatasOfApp1Stub()
+	initUDRDrGetAbsentTiDataStub()
+	initUDRDrPutTiDataStub(http.StatusNoContent)
+	defer gock.Off()
+
+	// The requests to UDR are sent without the lock of AF
+	lockedDuringRequest := false
+	gock.Observe(func(request *http.Request, mock gock.Mock) {
+		if !af.Mu.TryLock() {
+			lockedDuringRequest = true
+			return
+		}
+		af.Mu.Unlock()
+	})
+	defer gock.Observe(nil)
+
+	require.Nil(t, app.Processor().LastReconcileReport())
+	now := time.Now()
+	app.Processor().Reconcile(now)
+	require.False(t, lockedDuringRequest)
+
+	report := app.Processor().LastReconcileReport()
+	require.NotNil(t, report)
+	require.Equal(t, now, report.StartTime)
+	require.Equal(t, 2, report.CheckedApplications)
+	require.Equal(t, 1, report.CheckedSubscriptions)
+	require.Equal(t, 0, report.Failures)
+	require.ElementsMatch(t, []ReconcileMismatch{
+		{
+			Type:    ReconcilePfdMissingInUdr,
+			Action:  ReconcileActionFlagged,
+			AfID:    "af1",
+			TransID: afPfdTr.TransID,
+			AppID:   "app3",
+		},
+		{
+			Type:   ReconcileInfluDataMissingInUdr,
+			Action: ReconcileActionRepaired,
+			AfID:   "af1",
+			SubID:  afSub.SubID,
+		},
+	}, report.Mismatches)
+	// NEF doesn't keep the PFDs to store them again, so the absent appID is kept for OAM
+	require.Equal(t, map[string]struct{}{"app1": {}, "app3": {}}, afPfdTr.ExtAppIDs)
+	require.Equal(t, "influ1", afSub.InfluID)
+}
+
+func initUDRDrGetPfdDatasOfApp1Stub() {
+	gock.New("http://127.0.0.4:8000/nudr-dr/v1").
+		Get("/application-data/pfds").
+		Reply(http.StatusOK).
+		JSON([]models.PfdDataForApp{pfdDataForApp1})
+}
+
+func initUDRDrGetAbsentTiDataStub() {
+	gock.New("http://127.0.0.4:8000/nudr-dr/v1").
+		Get("/application-data/influenceData").
+		Reply(http.StatusOK).
+		JSON([]models.TrafficInfluData{})
+}
@@ -0,0 +1,118 @@
+package processor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	nef_context "github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func newSbiClientTestApp(
+	t *testing.T, sbiClient *factory.SbiClient, udrs []*nef_context.NfInstance,
+) *nefTestApp {
+	cfg := *nefApp.Config()
+	configuration := *cfg.Configuration
+	configuration.SbiClient = sbiClient
+	cfg.Configuration = &configuration
+
+	app, err := newTestApp(&cfg, "")
+	require.NoError(t, err)
+	// The UDRs serving the DNN and S-NSSAI of tiSub1ForAf1
+	app.Context().SetNfInstances(nef_context.NfDiscoveryKey{
+		SrvName: models.ServiceName_NUDR_DR,
+		Sst:     1,
+		Sd:      "010203",
+		Dnn:     "internet",
+	}, udrs, time.Hour)
+	return app
+}
+
+func postTiSub1ForAf1(app *nefTestApp) int {
+	httpRecorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(httpRecorder)
+	tiSub := tiSub1ForAf1
+	app.Processor().PostTrafficInfluenceSubscription(c, "af1", &tiSub, nil)
+	return httpRecorder.Code
+}
+
+func TestTrafficInfluenceUdrTimeout(t *testing.T) {
+	app := newSbiClientTestApp(t, &factory.SbiClient{
+		ServiceTimeouts: []factory.ServiceTimeout{
+			{ServiceName: "nudr-dr", Timeout: 100},
+		},
+	}, []*nef_context.NfInstance{
+		{NfInstID: "udr1", Uri: "http://127.0.0.4:8000", Priority: 1},
+		{NfInstID: "udr2", Uri: "http://127.0.0.14:8000", Priority: 2},
+	})
+
+	// The UDR not responding times out, and the request fails over to the other one
+	udr1Mock := gock.New("http://127.0.0.4:8000/nudr-dr/v1").
+		Put("/application-data/influenceData/.*").
+		Reply(http.StatusNoContent).
+		Delay(10 * time.Second).Mock
+	udr2Mock := gock.New("http://127.0.0.14:8000/nudr-dr/v1").
+		Put("/application-data/influenceData/.*").
+		Reply(http.StatusNoContent).Mock
+	defer gock.Remove(udr1Mock)
+	defer gock.Remove(udr2Mock)
+
+	start := time.Now()
+	require.Equal(t, http.StatusCreated, postTiSub1ForAf1(app))
+	require.Less(t, time.Since(start), 5*time.Second)
+	require.True(t, udr1Mock.Done())
+	require.True(t, udr2Mock.Done())
+}
+
+func TestTrafficInfluenceUdrRetry(t *testing.T) {
+	app := newSbiClientTestApp(t, &factory.SbiClient{
+		MaxRetries:   1,
+		RetryBackoff: 1,
+	}, []*nef_context.NfInstance{
+		{NfInstID: "udr1", Uri: "http://127.0.0.4:8000"},
+	})
+
+	// PUT is idempotent, so it's retried on 503
+	failedMock := gock.New("http://127.0.0.4:8000/nudr-dr/v1").
+		Put("/application-data/influenceData/.*").
+		Reply(http.StatusServiceUnavailable).Mock
+	retriedMock := gock.New("http://127.0.0.4:8000/nudr-dr/v1").
+		Put("/application-data/influenceData/.*").
+		Reply(http.StatusNoContent).Mock
+	defer gock.Remove(failedMock)
+	defer gock.Remove(retriedMock)
+
+	require.Equal(t, http.StatusCreated, postTiSub1ForAf1(app))
+	require.True(t, failedMock.Done())
+	require.True(t, retriedMock.Done())
+}
+
+func TestTrafficInfluenceUdrCircuitBreaker(t *testing.T) {
+	app := newSbiClientTestApp(t, &factory.SbiClient{
+		CircuitBreakerThreshold: 1,
+		CircuitBreakerOpenTime:  60,
+	}, []*nef_context.NfInstance{
+		{NfInstID: "udr1", Uri: "http://127.0.0.4:8000"},
+	})
+
+	failedMock := gock.New("http://127.0.0.4:8000/nudr-dr/v1").
+		Put("/application-data/influenceData/.*").
+		Reply(http.StatusServiceUnavailable).Mock
+	pendingMock := gock.New("http://127.0.0.4:8000/nudr-dr/v1").
+		Put("/application-data/influenceData/.*").
+		Reply(http.StatusNoContent).Mock
+	defer gock.Remove(failedMock)
+	defer gock.Remove(pendingMock)
+
+	require.NotEqual(t, http.StatusCreated, postTiSub1ForAf1(app))
+	// The UDR isn't requested while the circuit breaker is open
+	require.NotEqual(t, http.StatusCreated, postTiSub1ForAf1(app))
+	require.True(t, failedMock.Done())
+	require.False(t, pendingMock.Done())
+}
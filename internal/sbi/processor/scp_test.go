@@ -0,0 +1,143 @@
+package processor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestTrafficInfluenceThroughScpModelD(t *testing.T) {
+	cfg := *nefApp.Config()
+	configuration := *cfg.Configuration
+	configuration.Scp = &factory.Scp{
+		Uri:   "http://127.0.0.50:8000",
+		Model: factory.ScpModelD,
+	}
+	cfg.Configuration = &configuration
+
+	app, err := newTestApp(&cfg, "")
+	require.NoError(t, err)
+
+	// UDR is discovered by SCP with the DNN and S-NSSAI of tiSub1ForAf1, rather than NRF
+	udrMock := gock.New("http://127.0.0.50:8000/nudr-dr/v1").
+		Put("/application-data/influenceData/.*").
+		MatchHeader("3gpp-Sbi-Discovery-target-nf-type", "UDR").
+		MatchHeader("3gpp-Sbi-Discovery-requester-nf-type", "NEF").
+		MatchHeader("3gpp-Sbi-Discovery-service-names", "nudr-dr").
+		MatchHeader("3gpp-Sbi-Discovery-dnn", "internet").
+		MatchHeader("3gpp-Sbi-Discovery-snssais", `\[{"sst":1,"sd":"010203"}\]`).
+		Reply(http.StatusNoContent).Mock
+	defer gock.Remove(udrMock)
+
+	httpRecorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(httpRecorder)
+	tiSub := tiSub1ForAf1
+	app.Processor().PostTrafficInfluenceSubscription(c, "af1", &tiSub, nil)
+	require.Equal(t, http.StatusCreated, httpRecorder.Code)
+	require.True(t, udrMock.Done())
+}
+
+func TestTrafficInfluenceThroughScpModelC(t *testing.T) {
+	cfg := *nefApp.Config()
+	configuration := *cfg.Configuration
+	configuration.Scp = &factory.Scp{
+		Uri:   "http://127.0.0.50:8000/scp",
+		Model: factory.ScpModelC,
+	}
+	cfg.Configuration = &configuration
+
+	app, err := newTestApp(&cfg, "")
+	require.NoError(t, err)
+
+	// Both NRF and the UDR discovered by NEF are reached through SCP
+	nrfMock := gock.New("http://127.0.0.50:8000/scp/nnrf-disc/v1").
+		Get("/nf-instances").
+		MatchHeader("3gpp-Sbi-Target-apiRoot", "http://127.0.0.10:8000").
+		MatchParam("target-nf-type", "UDR").
+		Reply(http.StatusOK).
+		JSON(&models.SearchResult{
+			NfInstances: []models.NrfNfDiscoveryNfProfile{
+				{
+					NfInstanceId: "udr1",
+					NfType:       "UDR",
+					NfStatus:     "REGISTERED",
+					NfServices: []models.NrfNfDiscoveryNfService{
+						{
+							ServiceInstanceId: "datarepository",
+							ServiceName:       "nudr-dr",
+							Scheme:            "http",
+							NfServiceStatus:   "REGISTERED",
+							IpEndPoints: []models.IpEndPoint{
+								{
+									Ipv4Address: "127.0.0.4",
+									Port:        8000,
+								},
+							},
+						},
+					},
+				},
+			},
+		}).Mock
+	udrMock := gock.New("http://127.0.0.50:8000/scp/nudr-dr/v1").
+		Put("/application-data/influenceData/.*").
+		MatchHeader("3gpp-Sbi-Target-apiRoot", "http://127.0.0.4:8000").
+		Reply(http.StatusNoContent).Mock
+	defer gock.Remove(nrfMock)
+	defer gock.Remove(udrMock)
+
+	httpRecorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(httpRecorder)
+	tiSub := tiSub1ForAf1
+	app.Processor().PostTrafficInfluenceSubscription(c, "af1", &tiSub, nil)
+	require.Equal(t, http.StatusCreated, httpRecorder.Code)
+	require.True(t, nrfMock.Done())
+	require.True(t, udrMock.Done())
+}
+
+func TestCircuitBreakerThroughScpModelD(t *testing.T) {
+	cfg := *nefApp.Config()
+	configuration := *cfg.Configuration
+	configuration.Scp = &factory.Scp{
+		Uri:   "http://127.0.0.50:8000",
+		Model: factory.ScpModelD,
+	}
+	configuration.SbiClient = &factory.SbiClient{
+		CircuitBreakerThreshold: 1,
+		CircuitBreakerOpenTime:  60,
+	}
+	cfg.Configuration = &configuration
+
+	app, err := newTestApp(&cfg, "")
+	require.NoError(t, err)
+
+	failedMock := gock.New("http://127.0.0.50:8000/nudr-dr/v1").
+		Put("/application-data/influenceData/.*").
+		Reply(http.StatusServiceUnavailable).Mock
+	pendingMock := gock.New("http://127.0.0.50:8000/nudr-dr/v1").
+		Put("/application-data/influenceData/.*").
+		Reply(http.StatusNoContent).Mock
+	pfdMock := gock.New("http://127.0.0.50:8000/nudr-dr/v1").
+		Get("/application-data/pfds").
+		Reply(http.StatusOK).
+		JSON([]models.PfdDataForApp{}).Mock
+	defer gock.Remove(failedMock)
+	defer gock.Remove(pendingMock)
+	defer gock.Remove(pfdMock)
+
+	require.NotEqual(t, http.StatusCreated, postTiSub1ForAf1(app))
+	// The UDRs discovered by SCP for the DNN and S-NSSAI aren't requested while the circuit breaker is open
+	require.NotEqual(t, http.StatusCreated, postTiSub1ForAf1(app))
+	require.True(t, failedMock.Done())
+	require.False(t, pendingMock.Done())
+
+	// The circuit to SCP is still closed for the other target NFs
+	rspCode, _ := app.Consumer().AppDataPfdsGet(nil)
+	require.Equal(t, http.StatusOK, rspCode)
+	require.True(t, pfdMock.Done())
+}
@@ -0,0 +1,81 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	nef_context "github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestoreNefContext(t *testing.T) {
+	cfg := *nefApp.Config()
+	configuration := *cfg.Configuration
+	configuration.Store = &factory.Store{
+		Type: factory.StoreTypeFile,
+		Path: t.TempDir(),
+	}
+	cfg.Configuration = &configuration
+
+	app1, err := newTestApp(&cfg, "")
+	require.NoError(t, err)
+	nefCtx := app1.Context()
+
+	expiry := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	af := nefCtx.NewAf("af1")
+	af.Mu.Lock()
+	afSub := af.NewSub(nefCtx.NewCorreID(), &models.NefTrafficInfluSub{
+		AfAppId:                 "app1",
+		NotificationDestination: "http://af1/notify",
+		Gpsi:                    "msisdn-0900000000",
+	})
+	afSub.AppSessID = "appSess1"
+	afSub.PcfUri = "http://pcf1"
+	afSub.Expiry = &expiry
+	af.Subs[afSub.SubID] = afSub
+	afPfdTr := af.NewPfdTrans()
+	afPfdTr.AddExtAppID("app1")
+	afPfdTr.SetAllowedDelay("app1", 60)
+	afPfdTr.SetCachingTime("app1", 120)
+	af.PfdTrans[afPfdTr.TransID] = afPfdTr
+	af.Mu.Unlock()
+	nefCtx.AddAf(af)
+
+	af2 := nefCtx.NewAf("af2")
+	nefCtx.AddAf(af2)
+	nefCtx.DeleteAf("af2")
+
+	// Restart NEF with the same store
+	app2, err := newTestApp(&cfg, "")
+	require.NoError(t, err)
+	restoredCtx := app2.Context()
+
+	require.Nil(t, restoredCtx.GetAf("af2"))
+	restoredAf := restoredCtx.GetAf("af1")
+	require.NotNil(t, restoredAf)
+	require.Equal(t, uint64(1), restoredAf.NumSubscID)
+	require.Equal(t, uint64(1), restoredAf.NumTransID)
+
+	restoredSub := restoredAf.Subs[afSub.SubID]
+	require.NotNil(t, restoredSub)
+	require.NotNil(t, restoredSub.Log)
+	require.Equal(t, afSub.TiSub, restoredSub.TiSub)
+	require.Equal(t, "appSess1", restoredSub.AppSessID)
+	require.Equal(t, "http://pcf1", restoredSub.PcfUri)
+	require.True(t, expiry.Equal(*restoredSub.Expiry))
+
+	restoredPfdTr := restoredAf.PfdTrans[afPfdTr.TransID]
+	require.NotNil(t, restoredPfdTr)
+	require.NotNil(t, restoredPfdTr.Log)
+	require.Equal(t, map[string]struct{}{"app1": {}}, restoredPfdTr.ExtAppIDs)
+	require.Equal(t, map[string]int32{"app1": 60}, restoredPfdTr.AllowedDelays)
+	require.Equal(t, map[string]int32{"app1": 120}, restoredPfdTr.CachingTimes)
+
+	// The correlation IDs continue after the batch reserved before restart
+	require.Equal(t, uint64(nef_context.CorreIDBatchSize+1), restoredCtx.NewCorreID())
+	foundAf, foundSub := restoredCtx.FindAfSub(afSub.NotifCorreID)
+	require.Equal(t, restoredAf, foundAf)
+	require.Equal(t, restoredSub, foundSub)
+}
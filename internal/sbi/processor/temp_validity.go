@@ -0,0 +1,51 @@
+package processor
+
+import (
+	"fmt"
+
+	nef_context "github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/nef/internal/sbi/notifier"
+	"github.com/free5gc/openapi/models"
+)
+
+var _ nef_context.TempValidityHandler = &Processor{}
+
+func (p *Processor) ActivateTrafficInflu(af *nef_context.AfData, sub *nef_context.AfSubscription) error {
+	if rsp := p.installTrafficInflu(sub); rsp != nil {
+		return fmt.Errorf("install traffic influence failed: status[%d], body[%+v]", rsp.Status, rsp.Body)
+	}
+	sub.Log.Infoln("Traffic influence is activated")
+	return nil
+}
+
+func (p *Processor) DeactivateTrafficInflu(af *nef_context.AfData, sub *nef_context.AfSubscription) error {
+	if rsp := p.uninstallTrafficInflu(sub); rsp != nil {
+		return fmt.Errorf("uninstall traffic influence failed: status[%d], body[%+v]", rsp.Status, rsp.Body)
+	}
+	sub.Log.Infoln("Traffic influence is deactivated")
+	return nil
+}
+
+func (p *Processor) ExpireTrafficInflu(af *nef_context.AfData, sub *nef_context.AfSubscription) {
+	if sub.TempValidityState == nef_context.TempValidityActive {
+		// The subscription is removed anyway, PCF/UDR also stops the influence by tempValidities
+		if rsp := p.uninstallTrafficInflu(sub); rsp != nil {
+			sub.Log.Errorf("Uninstall expired traffic influence failed: status[%d], body[%+v]",
+				rsp.Status, rsp.Body)
+		}
+	}
+
+	if sub.TiSub.NotificationDestination == "" || !p.Config().SubscriptionExpiredNotif(af.AfID) {
+		return
+	}
+	p.Notifier().TrafficInfluNotifier.Notify(sub.Log, sub.TiSub.NotificationDestination,
+		[]models.EventNotification{
+			{
+				AfTransId:       sub.TiSub.AfTransId,
+				DnaiChgType:     sub.TiSub.DnaiChgType,
+				SubscribedEvent: notifier.SubscribedEventSubscriptionExpired,
+				Gpsi:            sub.TiSub.Gpsi,
+				UeMac:           sub.TiSub.MacAddr,
+			},
+		})
+}
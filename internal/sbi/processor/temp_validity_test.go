@@ -0,0 +1,281 @@
+package processor
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	nef_context "github.com/free5gc/nef/internal/context"
+	"github.com/free5gc/nef/internal/sbi/notifier"
+	"github.com/free5gc/openapi/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/h2non/gock.v1"
+)
+
+func TestCheckTempValidities(t *testing.T) {
+	initUDRDrPutTiDataStub(http.StatusNoContent)
+	initUDRDrDeleteTiDataStub(http.StatusNoContent)
+	initAFNotificationStub("http://af1NotifURI")
+	defer gock.Off()
+
+	// `afNotifChan` is used to pass the notification requests to AF intercepted by gock.
+	afNotifChan := make(chan *http.Request, 1)
+	gock.Observe(func(request *http.Request, mock gock.Mock) {
+		if strings.Contains(request.URL.String(), "af1NotifURI") {
+			afNotifChan <- request
+		}
+	})
+	defer gock.Observe(nil)
+
+	cfg := nefApp.Config()
+	cfg.Configuration.SubscriptionExpiredNotifAfs = []string{"af1"}
+	defer func() {
+		cfg.Configuration.SubscriptionExpiredNotifAfs = nil
+	}()
+
+	now := time.Now()
+	past := now.Add(-2 * time.Hour)
+	recent := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	farFuture := now.Add(2 * time.Hour)
+
+	expiredTiSub := tiSub1ForAf1
+	expiredTiSub.AfTransId = "afTrans1"
+	expiredTiSub.NotificationDestination = "http://af1NotifURI/notify"
+	expiredTiSub.TempValidities = []models.TemporalValidity{
+		{StartTime: &past, StopTime: &recent},
+	}
+
+	notStartedTiSub := tiSub1ForAf1
+	notStartedTiSub.TempValidities = []models.TemporalValidity{
+		{StartTime: &future, StopTime: &farFuture},
+	}
+
+	startedTiSub := tiSub2ForAf1
+	startedTiSub.TempValidities = []models.TemporalValidity{
+		{StartTime: &recent, StopTime: &future},
+	}
+
+	nefCtx := nefApp.Context()
+	af1 := nefCtx.NewAf("af1")
+	af1.Mu.Lock()
+	afSub1 := af1.NewSub(nefCtx.NewCorreID(), &expiredTiSub)
+	afSub1.InfluID = uuid.New().String()
+	af1.Subs[afSub1.SubID] = afSub1
+
+	afSub2 := af1.NewSub(nefCtx.NewCorreID(), &notStartedTiSub)
+	afSub2.InfluID = uuid.New().String()
+	af1.Subs[afSub2.SubID] = afSub2
+
+	afSub3 := af1.NewSub(nefCtx.NewCorreID(), &startedTiSub)
+	afSub3.TempValidityState = nef_context.TempValidityInactive
+	af1.Subs[afSub3.SubID] = afSub3
+	af1.Mu.Unlock()
+	nefCtx.AddAf(af1)
+	defer func() {
+		nefCtx.DeleteAf(af1.AfID)
+		nefCtx.ResetCorreID()
+	}()
+
+	nefCtx.CheckTempValidities(now, nefApp.Processor())
+
+	af1.Mu.RLock()
+	_, ok := af1.Subs[afSub1.SubID]
+	require.False(t, ok, "expired subscription should be removed")
+	require.Equal(t, nef_context.TempValidityInactive, afSub2.TempValidityState)
+	require.Equal(t, nef_context.TempValidityActive, afSub3.TempValidityState)
+	require.NotEmpty(t, afSub3.InfluID)
+	af1.Mu.RUnlock()
+
+	select {
+	case r := <-afNotifChan:
+		var notif models.EventNotification
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&notif))
+		require.Equal(t, models.EventNotification{
+			AfTransId:       "afTrans1",
+			SubscribedEvent: notifier.SubscribedEventSubscriptionExpired,
+		}, notif)
+	case <-time.After(3 * time.Second):
+		t.Fatal("AF notification is not received")
+	}
+}
+
+func TestExpireTrafficInfluNotification(t *testing.T) {
+	initAFNotificationStub("http://af1NotifURI")
+	defer gock.Off()
+
+	// `afNotifChan` is used to pass the notification requests to AF intercepted by gock.
+	afNotifChan := make(chan *http.Request, 1)
+	gock.Observe(func(request *http.Request, mock gock.Mock) {
+		if strings.Contains(request.URL.String(), "af1NotifURI") {
+			afNotifChan <- request
+		}
+	})
+	defer gock.Observe(nil)
+
+	testCases := []struct {
+		description      string
+		notifAfs         []string
+		expectedNotified bool
+	}{
+		{
+			description:      "TC1: AF configured for SUBSCRIPTION_EXPIRED, should be notified",
+			notifAfs:         []string{"af1"},
+			expectedNotified: true,
+		},
+		{
+			description:      "TC2: AF not configured for SUBSCRIPTION_EXPIRED, should not be notified",
+			notifAfs:         []string{"af2"},
+			expectedNotified: false,
+		},
+		{
+			description:      "TC3: No AF configured for SUBSCRIPTION_EXPIRED, should not be notified",
+			expectedNotified: false,
+		},
+	}
+
+	cfg := nefApp.Config()
+	defer func() {
+		cfg.Configuration.SubscriptionExpiredNotifAfs = nil
+	}()
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			cfg.Configuration.SubscriptionExpiredNotifAfs = tc.notifAfs
+
+			tiSub := tiSub1ForAf1
+			tiSub.NotificationDestination = "http://af1NotifURI/notify"
+			af1 := nefApp.Context().NewAf("af1")
+			afSub1 := af1.NewSub(1, &tiSub)
+			// Not installed in PCF/UDR, only the notification is sent
+			afSub1.TempValidityState = nef_context.TempValidityInactive
+
+			nefApp.Processor().ExpireTrafficInflu(af1, afSub1)
+
+			select {
+			case <-afNotifChan:
+				require.True(t, tc.expectedNotified, "AF notification should not be sent")
+			case <-time.After(500 * time.Millisecond):
+				require.False(t, tc.expectedNotified, "AF notification is not received")
+			}
+		})
+	}
+}
+
+// lockCheckingHandler records whether the lock of AF is held when the traffic influence is (un)installed
+type lockCheckingHandler struct {
+	lockHeld bool
+}
+
+func (h *lockCheckingHandler) check(af *nef_context.AfData) {
+	if af.Mu.TryLock() {
+		af.Mu.Unlock()
+	} else {
+		h.lockHeld = true
+	}
+}
+
+func (h *lockCheckingHandler) ActivateTrafficInflu(af *nef_context.AfData, sub *nef_context.AfSubscription) error {
+	h.check(af)
+	sub.InfluID = "influ1"
+	return nil
+}
+
+func (h *lockCheckingHandler) DeactivateTrafficInflu(af *nef_context.AfData, sub *nef_context.AfSubscription) error {
+	h.check(af)
+	return nil
+}
+
+func (h *lockCheckingHandler) ExpireTrafficInflu(af *nef_context.AfData, sub *nef_context.AfSubscription) {
+	h.check(af)
+}
+
+func TestCheckTempValiditiesWithoutAfLock(t *testing.T) {
+	now := time.Now()
+	recent := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	startedTiSub := tiSub2ForAf1
+	startedTiSub.TempValidities = []models.TemporalValidity{
+		{StartTime: &recent, StopTime: &future},
+	}
+
+	nefCtx := nefApp.Context()
+	af1 := nefCtx.NewAf("af1")
+	af1.Mu.Lock()
+	afSub1 := af1.NewSub(nefCtx.NewCorreID(), &startedTiSub)
+	afSub1.TempValidityState = nef_context.TempValidityInactive
+	af1.Subs[afSub1.SubID] = afSub1
+	af1.Mu.Unlock()
+	nefCtx.AddAf(af1)
+	defer func() {
+		nefCtx.DeleteAf(af1.AfID)
+		nefCtx.ResetCorreID()
+	}()
+
+	handler := &lockCheckingHandler{}
+	nefCtx.CheckTempValidities(now, handler)
+
+	require.False(t, handler.lockHeld, "lock of AF should not be held during activation")
+	af1.Mu.RLock()
+	require.Equal(t, nef_context.TempValidityActive, afSub1.TempValidityState)
+	require.Equal(t, "influ1", afSub1.InfluID)
+	af1.Mu.RUnlock()
+}
+
+func TestTempValidityStateAt(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-2 * time.Hour)
+	recent := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	testCases := []struct {
+		description   string
+		tempVals      []models.TemporalValidity
+		expectedState nef_context.TempValidityState
+	}{
+		{
+			description:   "TC1: No tempValidities, should be active",
+			expectedState: nef_context.TempValidityActive,
+		},
+		{
+			description: "TC2: Within a window, should be active",
+			tempVals: []models.TemporalValidity{
+				{StartTime: &recent, StopTime: &future},
+			},
+			expectedState: nef_context.TempValidityActive,
+		},
+		{
+			description: "TC3: Window without stopTime, should be active",
+			tempVals: []models.TemporalValidity{
+				{StartTime: &recent},
+			},
+			expectedState: nef_context.TempValidityActive,
+		},
+		{
+			description: "TC4: Window not started, should be inactive",
+			tempVals: []models.TemporalValidity{
+				{StartTime: &past, StopTime: &recent},
+				{StartTime: &future},
+			},
+			expectedState: nef_context.TempValidityInactive,
+		},
+		{
+			description: "TC5: All windows ended, should be expired",
+			tempVals: []models.TemporalValidity{
+				{StartTime: &past, StopTime: &recent},
+				{StopTime: &past},
+			},
+			expectedState: nef_context.TempValidityExpired,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.Equal(t, tc.expectedState, nef_context.TempValidityStateAt(tc.tempVals, now))
+		})
+	}
+}
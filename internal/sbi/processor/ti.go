@@ -1,9 +1,15 @@
 package processor
 
 import (
+	"fmt"
 	"net/http"
+	"reflect"
+	"regexp"
+	"time"
 
+	nef_context "github.com/free5gc/nef/internal/context"
 	"github.com/free5gc/nef/internal/logger"
+	"github.com/free5gc/nef/internal/sbi/consumer"
 	"github.com/free5gc/nef/pkg/factory"
 	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
@@ -11,9 +17,13 @@ import (
 	"github.com/google/uuid"
 )
 
+var macAddr48Regexp = regexp.MustCompile(`^([0-9a-fA-F]{2})((-[0-9a-fA-F]{2}){5})$`)
+
 func (p *Processor) GetTrafficInfluenceSubscription(
 	c *gin.Context,
 	afID string,
+	filter *TrafficInfluSubFilter,
+	pg *Page,
 ) {
 	logger.TrafInfluLog.Infof("GetTrafficInfluenceSubscription - afID[%s]", afID)
 
@@ -27,12 +37,25 @@ func (p *Processor) GetTrafficInfluenceSubscription(
 	af.Mu.RLock()
 	defer af.Mu.RUnlock()
 
-	var tiSubs []models.NefTrafficInfluSub
-	for _, sub := range af.Subs {
-		if sub.TiSub == nil {
+	subIDs := make([]string, 0, len(af.Subs))
+	for subID, sub := range af.Subs {
+		if sub.TiSub == nil || !filter.match(sub.TiSub) {
 			continue
 		}
-		tiSubs = append(tiSubs, *sub.TiSub)
+		subIDs = append(subIDs, subID)
+	}
+	subIDs, hasNext := paginate(subIDs, pg)
+
+	var tiSubs []trafficInfluSubWithExpiry
+	for _, subID := range subIDs {
+		tiSubs = append(tiSubs, *newTrafficInfluSubWithExpiry(af.Subs[subID]))
+	}
+	if hasNext {
+		values := filter.values()
+		for k, v := range pg.values() {
+			values[k] = v
+		}
+		setNextLink(c, p.genTrafficInfluSubsURI(afID), values, subIDs[len(subIDs)-1])
 	}
 	c.JSON(http.StatusOK, &tiSubs)
 }
@@ -41,6 +64,7 @@ func (p *Processor) PostTrafficInfluenceSubscription(
 	c *gin.Context,
 	afID string,
 	tiSub *models.NefTrafficInfluSub,
+	reqExpiry *time.Time,
 ) {
 	logger.TrafInfluLog.Infof("PostTrafficInfluenceSubscription - afID[%s]", afID)
 
@@ -50,6 +74,18 @@ func (p *Processor) PostTrafficInfluenceSubscription(
 		return
 	}
 
+	if rsp = p.validateGeoZoneIds(tiSub.ValidGeoZoneIds); rsp != nil {
+		c.JSON(rsp.Status, rsp.Body)
+		return
+	}
+
+	now := time.Now()
+	expiry, rsp := p.grantExpiry(factory.ServiceTraffInflu, reqExpiry, now)
+	if rsp != nil {
+		c.JSON(rsp.Status, rsp.Body)
+		return
+	}
+
 	nefCtx := p.Context()
 	af := nefCtx.GetAf(afID)
 	if af == nil {
@@ -61,62 +97,62 @@ func (p *Processor) PostTrafficInfluenceSubscription(
 		}
 	}
 
+	rsp = p.addTrafficInfluSub(af, tiSub, expiry, now)
+	if rsp.Status == http.StatusCreated {
+		// A new AF is added after its first subscription, af.Mu is locked by AddAf
+		nefCtx.AddAf(af)
+	}
+
+	for hdrName, hdrValues := range rsp.Headers {
+		for _, hdrValue := range hdrValues {
+			c.Header(hdrName, hdrValue)
+		}
+	}
+	c.JSON(rsp.Status, rsp.Body)
+}
+
+// addTrafficInfluSub installs the traffic influence and adds the subscription to the AF
+func (p *Processor) addTrafficInfluSub(
+	af *nef_context.AfData,
+	tiSub *models.NefTrafficInfluSub,
+	expiry *time.Time,
+	now time.Time,
+) *HandlerResponse {
 	af.Mu.Lock()
 	defer af.Mu.Unlock()
 
-	correID := nefCtx.NewCorreID()
+	correID := p.Context().NewCorreID()
 	afSub := af.NewSub(correID, tiSub)
 	if afSub == nil {
 		pd := openapi.ProblemDetailsSystemFailure("No resource can be allocated")
-		c.JSON(int(pd.Status), pd)
-		return
+		return &HandlerResponse{int(pd.Status), nil, pd}
 	}
+	afSub.Expiry = expiry
 
-	if len(tiSub.Gpsi) > 0 || len(tiSub.Ipv4Addr) > 0 || len(tiSub.Ipv6Addr) > 0 {
-		// Single UE, sent to PCF
-		asc := p.convertTrafficInfluSubToAppSessionContext(tiSub, afSub.NotifCorreID)
-		rspStatus, rspBody, appSessID := p.Consumer().PostAppSessions(asc)
-		if rspStatus != http.StatusCreated {
-			c.JSON(rspStatus, rspBody)
-			return
-		}
-		afSub.AppSessID = appSessID
-	} else if len(tiSub.ExternalGroupId) > 0 || tiSub.AnyUeInd {
-		// Group or any UE, sent to UDR
-		afSub.InfluID = uuid.New().String()
-		tiData := p.convertTrafficInfluSubToTrafficInfluData(tiSub, afSub.NotifCorreID)
-		rspStatus, rspBody := p.Consumer().AppDataInfluenceDataPut(afSub.InfluID, tiData)
-		if rspStatus != http.StatusOK &&
-			rspStatus != http.StatusCreated &&
-			rspStatus != http.StatusNoContent {
-			c.JSON(rspStatus, rspBody)
-			return
+	switch nef_context.TempValidityStateAt(tiSub.TempValidities, now) {
+	case nef_context.TempValidityExpired:
+		pd := openapi.ProblemDetailsMalformedReqSyntax("All tempValidities have expired")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	case nef_context.TempValidityInactive:
+		// Installed by the TempValidity scheduler when one of the windows starts
+		afSub.TempValidityState = nef_context.TempValidityInactive
+		afSub.Log.Infoln("Traffic influence is not activated until tempValidities start")
+	default:
+		if rsp := p.installTrafficInflu(afSub); rsp != nil {
+			return rsp
 		}
-	} else {
-		// Invalid case. Return Error
-		pd := openapi.ProblemDetailsMalformedReqSyntax("Not individual UE case, nor group case")
-		c.JSON(int(pd.Status), pd)
-		return
 	}
 
 	af.Subs[afSub.SubID] = afSub
 	af.Log.Infoln("Subscription is added")
 
-	nefCtx.AddAf(af)
-
 	// Create Location URI
-	tiSub.Self = p.genTrafficInfluSubURI(afID, afSub.SubID)
+	tiSub.Self = p.genTrafficInfluSubURI(af.AfID, afSub.SubID)
 	headers := map[string][]string{
 		"Location": {tiSub.Self},
 	}
-
-	for hdrName, hdrValues := range headers {
-		for _, hdrValue := range hdrValues {
-			c.Header(hdrName, hdrValue)
-		}
-	}
 	af.Log.Infoln("Convert TI 3")
-	c.JSON(http.StatusCreated, tiSub)
+	return &HandlerResponse{http.StatusCreated, headers, newTrafficInfluSubWithExpiry(afSub)}
 }
 
 func (p *Processor) GetIndividualTrafficInfluenceSubscription(
@@ -142,7 +178,7 @@ func (p *Processor) GetIndividualTrafficInfluenceSubscription(
 		return
 	}
 
-	c.JSON(http.StatusOK, afSub.TiSub)
+	c.JSON(http.StatusOK, newTrafficInfluSubWithExpiry(afSub))
 }
 
 func (p *Processor) PutIndividualTrafficInfluenceSubscription(
@@ -158,6 +194,11 @@ func (p *Processor) PutIndividualTrafficInfluenceSubscription(
 		return
 	}
 
+	if rsp = p.validateGeoZoneIds(tiSub.ValidGeoZoneIds); rsp != nil {
+		c.JSON(rsp.Status, rsp.Body)
+		return
+	}
+
 	af := p.Context().GetAf(afID)
 	if af == nil {
 		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
@@ -175,18 +216,50 @@ func (p *Processor) PutIndividualTrafficInfluenceSubscription(
 		return
 	}
 
-	afSub.TiSub = tiSub
-	if afSub.AppSessID != "" {
-		asc := p.convertTrafficInfluSubToAppSessionContext(tiSub, afSub.NotifCorreID)
-		rspStatus, rspBody, appSessID := p.Consumer().PostAppSessions(asc)
-		if rspStatus != http.StatusCreated {
-			c.JSON(rspStatus, rspBody)
+	// The stored TiSub is only replaced after PCF/UDR accepts the update
+	if afSub.TempValidityState != nef_context.TempValidityActive {
+		// Not installed in PCF/UDR, the new TiSub takes effect when the TempValidity scheduler activates it
+		afSub.Log.Infoln("Traffic influence is not active, only the stored subscription is updated")
+	} else if afSub.AppSessID != "" {
+		if !isSameUeSession(afSub.TiSub, tiSub) {
+			pd := openapi.ProblemDetailsMalformedReqSyntax(
+				"UE identifier, dnn and snssai of an individual UE subscription cannot be changed")
+			c.JSON(int(pd.Status), pd)
 			return
 		}
-		afSub.AppSessID = appSessID
+		oldAscUpdateData := p.convertTrafficInfluSubToAppSessionContextUpdateData(afSub.TiSub, afSub.NotifCorreID)
+		ascUpdateData := p.convertTrafficInfluSubToAppSessionContextUpdateData(tiSub, afSub.NotifCorreID)
+		if appSessionAttrsRemoved(oldAscUpdateData, ascUpdateData) {
+			// PATCH of PCF keeps the attributes absent from the update, the AppSession is recreated instead
+			if rsp := p.recreateAppSession(afSub, tiSub); rsp != nil {
+				// The old AppSession may be restored with another ID
+				p.Context().SaveAf(af)
+				c.JSON(rsp.Status, rsp.Body)
+				return
+			}
+		} else {
+			rspStatus, rspBody, _ := p.Consumer().PutAppSession(afSub.PcfUri, afSub.AppSessID, ascUpdateData)
+			if rspStatus != http.StatusOK &&
+				rspStatus != http.StatusNoContent {
+				c.JSON(rspStatus, rspBody)
+				return
+			}
+		}
 	} else if afSub.InfluID != "" {
-		tiData := p.convertTrafficInfluSubToTrafficInfluData(tiSub, afSub.NotifCorreID)
-		rspStatus, rspBody := p.Consumer().AppDataInfluenceDataPut(afSub.InfluID, tiData)
+		if isIndividualUe(tiSub) {
+			pd := openapi.ProblemDetailsMalformedReqSyntax(
+				"Group or any UE subscription cannot be changed to individual UE")
+			c.JSON(int(pd.Status), pd)
+			return
+		}
+		interGroupID, rsp := p.resolveInterGroupID(tiSub)
+		if rsp != nil {
+			c.JSON(rsp.Status, rsp.Body)
+			return
+		}
+		tiData := p.convertTrafficInfluSubToTrafficInfluData(tiSub, afSub.NotifCorreID, interGroupID)
+		rspStatus, rspBody := p.Consumer().AppDataInfluenceDataPut(afSub.InfluID, tiData,
+			newNfDiscoveryQuery(afSub.TiSub, ""))
 		if rspStatus != http.StatusOK &&
 			rspStatus != http.StatusCreated &&
 			rspStatus != http.StatusNoContent {
@@ -199,7 +272,9 @@ func (p *Processor) PutIndividualTrafficInfluenceSubscription(
 		return
 	}
 
-	c.JSON(http.StatusOK, afSub.TiSub)
+	afSub.TiSub = tiSub
+	p.Context().SaveAf(af)
+	c.JSON(http.StatusOK, newTrafficInfluSubWithExpiry(afSub))
 }
 
 func (p *Processor) PatchIndividualTrafficInfluenceSubscription(
@@ -209,6 +284,11 @@ func (p *Processor) PatchIndividualTrafficInfluenceSubscription(
 ) {
 	logger.TrafInfluLog.Infof("PatchIndividualTrafficInfluenceSubscription - afID[%s], subID[%s]", afID, subID)
 
+	if rsp := p.validateGeoZoneIds(tiSubPatch.ValidGeoZoneIds); rsp != nil {
+		c.JSON(rsp.Status, rsp.Body)
+		return
+	}
+
 	af := p.Context().GetAf(afID)
 	if af == nil {
 		pd := openapi.ProblemDetailsDataNotFound("AF is not found")
@@ -226,9 +306,11 @@ func (p *Processor) PatchIndividualTrafficInfluenceSubscription(
 		return
 	}
 
-	if afSub.AppSessID != "" {
+	if afSub.TempValidityState != nef_context.TempValidityActive {
+		afSub.Log.Infoln("Traffic influence is not active, only the stored subscription is updated")
+	} else if afSub.AppSessID != "" {
 		ascUpdateData := p.convertTrafficInfluSubPatchToAppSessionContextUpdateData(tiSubPatch)
-		rspStatus, rspBody := p.Consumer().PatchAppSession(afSub.AppSessID, ascUpdateData)
+		rspStatus, rspBody := p.Consumer().PatchAppSession(afSub.PcfUri, afSub.AppSessID, ascUpdateData)
 		if rspStatus != http.StatusOK &&
 			rspStatus != http.StatusNoContent {
 			c.JSON(rspStatus, rspBody)
@@ -236,7 +318,8 @@ func (p *Processor) PatchIndividualTrafficInfluenceSubscription(
 		}
 	} else if afSub.InfluID != "" {
 		tiDataPatch := p.convertTrafficInfluSubPatchToTrafficInfluDataPatch(tiSubPatch)
-		rspStatus, rspBody := p.Consumer().AppDataInfluenceDataPatch(afSub.InfluID, tiDataPatch)
+		rspStatus, rspBody := p.Consumer().AppDataInfluenceDataPatch(afSub.InfluID, tiDataPatch,
+			newNfDiscoveryQuery(afSub.TiSub, ""))
 		if rspStatus != http.StatusOK &&
 			rspStatus != http.StatusNoContent {
 			c.JSON(rspStatus, rspBody)
@@ -249,7 +332,8 @@ func (p *Processor) PatchIndividualTrafficInfluenceSubscription(
 	}
 
 	afSub.PatchTiSubData(tiSubPatch)
-	c.JSON(http.StatusOK, afSub.TiSub)
+	p.Context().SaveAf(af)
+	c.JSON(http.StatusOK, newTrafficInfluSubWithExpiry(afSub))
 }
 
 func (p *Processor) DeleteIndividualTrafficInfluenceSubscription(
@@ -275,23 +359,139 @@ func (p *Processor) DeleteIndividualTrafficInfluenceSubscription(
 		return
 	}
 
-	if sub.AppSessID != "" {
-		rspStatus, rspBody := p.Consumer().DeleteAppSession(sub.AppSessID)
+	if sub.TempValidityState == nef_context.TempValidityActive {
+		if rsp := p.uninstallTrafficInflu(sub); rsp != nil {
+			c.JSON(rsp.Status, rsp.Body)
+			return
+		}
+	}
+	delete(af.Subs, subID)
+	p.Context().SaveAf(af)
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// installTrafficInflu sends the traffic influence of the subscription to PCF (single UE)
+// or UDR (group or any UE)
+func (p *Processor) installTrafficInflu(afSub *nef_context.AfSubscription) *HandlerResponse {
+	tiSub := afSub.TiSub
+	if isIndividualUe(tiSub) {
+		// Single UE, sent to PCF
+		supi, pcfUri, rsp := p.selectPcfForUe(tiSub)
+		if rsp != nil {
+			return rsp
+		}
+		asc := p.convertTrafficInfluSubToAppSessionContext(tiSub, afSub.NotifCorreID, supi)
+		// The PCF is selected by consumer if BSF has no binding of the UE, and the app session
+		// is kept in the selected one
+		rspStatus, rspBody, appSessID, pcfUri := p.Consumer().PostAppSessions(pcfUri, asc,
+			newNfDiscoveryQuery(tiSub, supi))
+		if rspStatus != http.StatusCreated {
+			return &HandlerResponse{rspStatus, nil, rspBody}
+		}
+		afSub.AppSessID = appSessID
+		afSub.PcfUri = pcfUri
+		afSub.Supi = supi
+	} else if len(tiSub.ExternalGroupId) > 0 || tiSub.AnyUeInd {
+		// Group or any UE, sent to UDR
+		interGroupID, rsp := p.resolveInterGroupID(tiSub)
+		if rsp != nil {
+			return rsp
+		}
+		if afSub.InfluID == "" {
+			afSub.InfluID = uuid.New().String()
+		}
+		tiData := p.convertTrafficInfluSubToTrafficInfluData(tiSub, afSub.NotifCorreID, interGroupID)
+		rspStatus, rspBody := p.Consumer().AppDataInfluenceDataPut(afSub.InfluID, tiData,
+			newNfDiscoveryQuery(tiSub, ""))
+		if rspStatus != http.StatusOK &&
+			rspStatus != http.StatusCreated &&
+			rspStatus != http.StatusNoContent {
+			return &HandlerResponse{rspStatus, nil, rspBody}
+		}
+	} else {
+		// Invalid case. Return Error
+		pd := openapi.ProblemDetailsMalformedReqSyntax("Not individual UE case, nor group case")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+	return nil
+}
+
+// uninstallTrafficInflu removes the traffic influence of the subscription from PCF or UDR
+func (p *Processor) uninstallTrafficInflu(afSub *nef_context.AfSubscription) *HandlerResponse {
+	if afSub.AppSessID != "" {
+		rspStatus, rspBody := p.Consumer().DeleteAppSession(afSub.PcfUri, afSub.AppSessID)
 		if rspStatus != http.StatusOK &&
 			rspStatus != http.StatusNoContent {
-			c.JSON(rspStatus, rspBody)
-			return
+			return &HandlerResponse{rspStatus, nil, rspBody}
 		}
+		afSub.AppSessID = ""
 	} else {
-		rspStatus, rspBody := p.Consumer().AppDataInfluenceDataDelete(sub.InfluID)
+		rspStatus, rspBody := p.Consumer().AppDataInfluenceDataDelete(afSub.InfluID,
+			newNfDiscoveryQuery(afSub.TiSub, ""))
 		if rspStatus != http.StatusOK &&
 			rspStatus != http.StatusNoContent {
-			c.JSON(rspStatus, rspBody)
-			return
+			return &HandlerResponse{rspStatus, nil, rspBody}
 		}
 	}
-	delete(af.Subs, subID)
-	c.JSON(http.StatusNoContent, nil)
+	return nil
+}
+
+// recreateAppSession replaces the AppSession of the subscription by a new one of tiSub in the same PCF,
+// the old AppSession is restored if the new one is rejected
+func (p *Processor) recreateAppSession(
+	afSub *nef_context.AfSubscription,
+	tiSub *models.NefTrafficInfluSub,
+) *HandlerResponse {
+	rspStatus, rspBody := p.Consumer().DeleteAppSession(afSub.PcfUri, afSub.AppSessID)
+	if rspStatus != http.StatusOK &&
+		rspStatus != http.StatusNoContent {
+		return &HandlerResponse{rspStatus, nil, rspBody}
+	}
+
+	asc := p.convertTrafficInfluSubToAppSessionContext(tiSub, afSub.NotifCorreID, afSub.Supi)
+	rspStatus, rspBody, appSessID, pcfUri := p.Consumer().PostAppSessions(afSub.PcfUri, asc,
+		newNfDiscoveryQuery(tiSub, afSub.Supi))
+	if rspStatus == http.StatusCreated {
+		afSub.AppSessID = appSessID
+		afSub.PcfUri = pcfUri
+		return nil
+	}
+
+	afSub.Log.Warnf("New AppSession is rejected (status: %d), restore the old one", rspStatus)
+	oldAsc := p.convertTrafficInfluSubToAppSessionContext(afSub.TiSub, afSub.NotifCorreID, afSub.Supi)
+	restoreStatus, _, appSessID, pcfUri := p.Consumer().PostAppSessions(afSub.PcfUri, oldAsc,
+		newNfDiscoveryQuery(afSub.TiSub, afSub.Supi))
+	if restoreStatus == http.StatusCreated {
+		afSub.AppSessID = appSessID
+		afSub.PcfUri = pcfUri
+	} else {
+		// Installed again by the TempValidity scheduler
+		afSub.Log.Errorf("Failed to restore the old AppSession (status: %d)", restoreStatus)
+		afSub.AppSessID = ""
+		afSub.TempValidityState = nef_context.TempValidityInactive
+	}
+	return &HandlerResponse{rspStatus, nil, rspBody}
+}
+
+// appSessionAttrsRemoved reports whether any attribute of the old AppSession update is absent from the new one
+func appSessionAttrsRemoved(oldData, newData *models.AppSessionContextUpdateData) bool {
+	if oldData.AfAppId != "" && newData.AfAppId == "" {
+		return true
+	}
+	if oldData.AfRoutReq == nil {
+		return false
+	}
+	if newData.AfRoutReq == nil {
+		return true
+	}
+	oldReq := reflect.ValueOf(*oldData.AfRoutReq)
+	newReq := reflect.ValueOf(*newData.AfRoutReq)
+	for i := 0; i < oldReq.NumField(); i++ {
+		if !oldReq.Field(i).IsZero() && newReq.Field(i).IsZero() {
+			return true
+		}
+	}
+	return false
 }
 
 func validateTrafficInfluenceData(
@@ -311,24 +511,65 @@ func validateTrafficInfluenceData(
 	// (i.e. "gpsi", “macAddr”, "ipv4Addr" or "ipv6Addr"),
 	// External Group Identifier (i.e. "externalGroupId") or
 	// any UE indication "anyUeInd" shall be included.
-	if tiSub.Gpsi == "" &&
-		tiSub.Ipv4Addr == "" &&
-		tiSub.Ipv6Addr == "" &&
+	if !isIndividualUe(tiSub) &&
 		tiSub.ExternalGroupId == "" &&
 		!tiSub.AnyUeInd {
 		pd := openapi.
 			ProblemDetailsMalformedReqSyntax(
-				"Missing one of Gpsi, Ipv4Addr, Ipv6Addr, ExternalGroupId, AnyUeInd")
+				"Missing one of Gpsi, MacAddr, Ipv4Addr, Ipv6Addr, ExternalGroupId, AnyUeInd")
+		return &HandlerResponse{int(pd.Status), nil, pd}
+	}
+
+	// TS29.571: MacAddr48 is formatted as six groups of two hexadecimal digits separated by "-"
+	if tiSub.MacAddr != "" && !macAddr48Regexp.MatchString(tiSub.MacAddr) {
+		pd := openapi.
+			ProblemDetailsMalformedReqSyntax(
+				fmt.Sprintf("Invalid macAddr[%s]", tiSub.MacAddr))
 		return &HandlerResponse{int(pd.Status), nil, pd}
 	}
 	return nil
 }
 
+// isSameUeSession reports whether both subscriptions target the same PDU session,
+// which is the one the PCF app session is bound to
+func isSameUeSession(a, b *models.NefTrafficInfluSub) bool {
+	return a.Gpsi == b.Gpsi &&
+		a.MacAddr == b.MacAddr &&
+		a.Ipv4Addr == b.Ipv4Addr &&
+		a.Ipv6Addr == b.Ipv6Addr &&
+		a.Dnn == b.Dnn &&
+		reflect.DeepEqual(a.Snssai, b.Snssai)
+}
+
+// isIndividualUe reports whether the subscription targets a single UE,
+// i.e. an IP PDU session (gpsi, ipv4Addr, ipv6Addr) or an Ethernet PDU session (macAddr)
+func isIndividualUe(tiSub *models.NefTrafficInfluSub) bool {
+	return tiSub.Gpsi != "" ||
+		tiSub.MacAddr != "" ||
+		tiSub.Ipv4Addr != "" ||
+		tiSub.Ipv6Addr != ""
+}
+
+// newNfDiscoveryQuery lets the PCF or UDR serving the DNN and S-NSSAI of the subscription be selected,
+// supi is empty if the UE is not identified by GPSI
+func newNfDiscoveryQuery(tiSub *models.NefTrafficInfluSub, supi string) *consumer.NfDiscoveryQuery {
+	return &consumer.NfDiscoveryQuery{
+		Snssai: tiSub.Snssai,
+		Dnn:    tiSub.Dnn,
+		Supi:   supi,
+	}
+}
+
+func (p *Processor) genTrafficInfluSubsURI(afID string) string {
+	// E.g. https://localhost:29505/3gpp-traffic-Influence/v1/{afId}/subscriptions
+	return p.Config().ServiceUri(factory.ServiceTraffInflu) + "/" + afID + "/subscriptions"
+}
+
 func (p *Processor) genTrafficInfluSubURI(
 	afID, subscriptionId string,
 ) string {
 	// E.g. https://localhost:29505/3gpp-traffic-Influence/v1/{afId}/subscriptions/{subscriptionId}
-	return p.Config().ServiceUri(factory.ServiceTraffInflu) + "/" + afID + "/subscriptions/" + subscriptionId
+	return p.genTrafficInfluSubsURI(afID) + "/" + subscriptionId
 }
 
 func (p *Processor) genNotificationUri() string {
@@ -338,6 +579,7 @@ func (p *Processor) genNotificationUri() string {
 func (p *Processor) convertTrafficInfluSubToAppSessionContext(
 	tiSub *models.NefTrafficInfluSub,
 	notifCorreID string,
+	supi string,
 ) *models.AppSessionContext {
 	asc := &models.AppSessionContext{
 		AscReqData: &models.AppSessionContextReqData{
@@ -354,20 +596,104 @@ func (p *Processor) convertTrafficInfluSubToAppSessionContext(
 			SuppFeat:  tiSub.SuppFeat,
 			Dnn:       tiSub.Dnn,
 			SliceInfo: tiSub.Snssai,
-			// Supi: ,
+			Supi:      supi,
+			Gpsi:      tiSub.Gpsi,
 		},
 	}
 
+	if presenceInfoList := p.convertGeoZonesToPresenceInfoList(tiSub.ValidGeoZoneIds); presenceInfoList != nil {
+		asc.AscReqData.AfRoutReq.SpVal = &models.SpatialValidity{
+			PresenceInfoList: presenceInfoList,
+		}
+	}
+
 	if tiSub.DnaiChgType != "" {
 		asc.AscReqData.AfRoutReq.UpPathChgSub = &models.UpPathChgEvent{
 			DnaiChgType:     tiSub.DnaiChgType,
 			NotificationUri: p.genNotificationUri(),
 			NotifCorreId:    notifCorreID,
+			AfAckInd:        tiSub.AfAckInd,
 		}
 	}
 	return asc
 }
 
+// selectPcfForUe translates the GPSI to SUPI by UDM and retrieves the PCF holding
+// the PDU session binding of the UE from BSF. If no binding is found, the request is rejected,
+// or an empty pcfUri is returned to use the PCF discovered from NRF if pcfBindingFallback is configured.
+func (p *Processor) selectPcfForUe(
+	tiSub *models.NefTrafficInfluSub,
+) (string, string, *HandlerResponse) {
+	var supi string
+	if tiSub.Gpsi != "" {
+		var rspStatus int
+		var rspBody interface{}
+		rspStatus, rspBody, supi = p.Consumer().GetSupiByGpsi(tiSub.Gpsi)
+		switch rspStatus {
+		case http.StatusOK:
+		case http.StatusNotFound:
+			pd := openapi.ProblemDetailsDataNotFound(fmt.Sprintf("gpsi[%s] is not found", tiSub.Gpsi))
+			return "", "", &HandlerResponse{int(pd.Status), nil, pd}
+		default:
+			return "", "", &HandlerResponse{rspStatus, nil, rspBody}
+		}
+	}
+
+	rspStatus, rspBody, pcfUri := p.Consumer().GetPcfPaUriByBinding(&consumer.PcfBindingQuery{
+		Ipv4Addr:   tiSub.Ipv4Addr,
+		Ipv6Prefix: tiSub.Ipv6Addr,
+		MacAddr48:  tiSub.MacAddr,
+		Supi:       supi,
+		Gpsi:       tiSub.Gpsi,
+		Dnn:        tiSub.Dnn,
+		Snssai:     tiSub.Snssai,
+	})
+	switch {
+	case rspStatus == http.StatusOK:
+		return supi, pcfUri, nil
+	case p.Config().PcfBindingFallback():
+		logger.TrafInfluLog.Warnf("No PCF binding is found from BSF (status: %d), use the PCF discovered from NRF",
+			rspStatus)
+		return supi, "", nil
+	case rspStatus == http.StatusNoContent:
+		pd := openapi.ProblemDetailsDataNotFound("PCF binding of the UE is not found")
+		return "", "", &HandlerResponse{int(pd.Status), nil, pd}
+	default:
+		return "", "", &HandlerResponse{rspStatus, nil, rspBody}
+	}
+}
+
+func (p *Processor) convertTrafficInfluSubToAppSessionContextUpdateData(
+	tiSub *models.NefTrafficInfluSub,
+	notifCorreID string,
+) *models.AppSessionContextUpdateData {
+	ascUpdate := &models.AppSessionContextUpdateData{
+		AfAppId: tiSub.AfAppId,
+		AfRoutReq: &models.AfRoutingRequirementRm{
+			AppReloc:      tiSub.AppReloInd,
+			RouteToLocs:   tiSub.TrafficRoutes,
+			TempVals:      tiSub.TempValidities,
+			AddrPreserInd: tiSub.AddrPreserInd,
+		},
+	}
+
+	if presenceInfoList := p.convertGeoZonesToPresenceInfoList(tiSub.ValidGeoZoneIds); presenceInfoList != nil {
+		ascUpdate.AfRoutReq.SpVal = &models.SpatialValidityRm{
+			PresenceInfoList: presenceInfoList,
+		}
+	}
+
+	if tiSub.DnaiChgType != "" {
+		ascUpdate.AfRoutReq.UpPathChgSub = &models.UpPathChgEvent{
+			DnaiChgType:     tiSub.DnaiChgType,
+			NotificationUri: p.genNotificationUri(),
+			NotifCorreId:    notifCorreID,
+			AfAckInd:        tiSub.AfAckInd,
+		}
+	}
+	return ascUpdate
+}
+
 func (p *Processor) convertTrafficInfluSubPatchToAppSessionContextUpdateData(
 	tiSubPatch *models.NefTrafficInfluSubPatch,
 ) *models.AppSessionContextUpdateData {
@@ -378,12 +704,19 @@ func (p *Processor) convertTrafficInfluSubPatchToAppSessionContextUpdateData(
 			TempVals:    tiSubPatch.TempValidities,
 		},
 	}
+
+	if presenceInfoList := p.convertGeoZonesToPresenceInfoList(tiSubPatch.ValidGeoZoneIds); presenceInfoList != nil {
+		ascUpdate.AfRoutReq.SpVal = &models.SpatialValidityRm{
+			PresenceInfoList: presenceInfoList,
+		}
+	}
 	return ascUpdate
 }
 
 func (p *Processor) convertTrafficInfluSubToTrafficInfluData(
 	tiSub *models.NefTrafficInfluSub,
 	notifCorreID string,
+	interGroupID string,
 ) *models.TrafficInfluData {
 	tiData := &models.TrafficInfluData{
 		AfAppId:    tiSub.AfAppId,
@@ -404,14 +737,35 @@ func (p *Processor) convertTrafficInfluSubToTrafficInfluData(
 		AfAckInd:          tiSub.AfAckInd,
 		AddrPreserInd:     tiSub.AddrPreserInd,
 		SupportedFeatures: tiSub.SuppFeat,
+		InterGroupId:      interGroupID,
+		NwAreaInfo:        p.convertGeoZonesToNetworkAreaInfo(tiSub.ValidGeoZoneIds),
 	}
+	return tiData
+}
 
-	// TODO: handle ExternalGroupId
-	if tiSub.AnyUeInd {
-		tiData.InterGroupId = "AnyUE"
+// resolveInterGroupID returns the InterGroupId of the TrafficInfluData for the group or any UE case,
+// the externalGroupId is translated to the internalGroupId by UDM.
+func (p *Processor) resolveInterGroupID(
+	tiSub *models.NefTrafficInfluSub,
+) (string, *HandlerResponse) {
+	if tiSub.ExternalGroupId == "" {
+		if tiSub.AnyUeInd {
+			return "AnyUE", nil
+		}
+		return "", nil
 	}
 
-	return tiData
+	rspStatus, rspBody, intGroupID := p.Consumer().GetIntGroupID(tiSub.ExternalGroupId)
+	switch rspStatus {
+	case http.StatusOK:
+		return intGroupID, nil
+	case http.StatusNotFound:
+		pd := openapi.ProblemDetailsDataNotFound(
+			fmt.Sprintf("externalGroupId[%s] is not found", tiSub.ExternalGroupId))
+		return "", &HandlerResponse{int(pd.Status), nil, pd}
+	default:
+		return "", &HandlerResponse{rspStatus, nil, rspBody}
+	}
 }
 
 func (p *Processor) convertTrafficInfluSubPatchToTrafficInfluDataPatch(
@@ -422,6 +776,7 @@ func (p *Processor) convertTrafficInfluSubPatchToTrafficInfluDataPatch(
 		EthTrafficFilters: tiSubPatch.EthTrafficFilters,
 		TrafficFilters:    tiSubPatch.TrafficFilters,
 		TrafficRoutes:     tiSubPatch.TrafficRoutes,
+		NwAreaInfo:        p.convertGeoZonesToNetworkAreaInfo(tiSubPatch.ValidGeoZoneIds),
 	}
 	return tiDataPatch
 }
@@ -5,7 +5,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/free5gc/nef/pkg/factory"
+	"github.com/free5gc/openapi"
 	"github.com/free5gc/openapi/models"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -145,17 +148,91 @@ var (
 			},
 		},
 	}
+
+	tiSub6ForAf1 = models.NefTrafficInfluSub{
+		AfServiceId: "Service6",
+		AfAppId:     "App6",
+		Dnn:         "internet",
+		Snssai: &models.Snssai{
+			Sst: 1,
+			Sd:  "010203",
+		},
+		ExternalGroupId: "extgroupid-group1@free5gc.org",
+		TrafficFilters: []models.FlowInfo{
+			{
+				FlowId: 1,
+				FlowDescriptions: []string{
+					"permit out ip from 192.168.0.26 to 10.60.0.0/16",
+				},
+			},
+		},
+		TrafficRoutes: []*models.RouteToLocation{
+			{
+				Dnai: "mec",
+			},
+		},
+	}
+
+	tiSub7ForAf1 = models.NefTrafficInfluSub{
+		AfServiceId: "Service7",
+		AfAppId:     "App7",
+		Dnn:         "internet",
+		Snssai: &models.Snssai{
+			Sst: 1,
+			Sd:  "010203",
+		},
+		Gpsi: "msisdn-0900000000",
+		TrafficFilters: []models.FlowInfo{
+			{
+				FlowId: 1,
+				FlowDescriptions: []string{
+					"permit out ip from 192.168.0.27 to 10.60.0.0/16",
+				},
+			},
+		},
+		TrafficRoutes: []*models.RouteToLocation{
+			{
+				Dnai: "mec",
+			},
+		},
+	}
+
+	tiSub8ForAf1 = models.NefTrafficInfluSub{
+		AfServiceId: "Service8",
+		Dnn:         "lan",
+		Snssai: &models.Snssai{
+			Sst: 1,
+			Sd:  "010203",
+		},
+		MacAddr: "02-00-00-00-00-01",
+		EthTrafficFilters: []models.EthFlowDescription{
+			{
+				DestMacAddr: "02-00-00-00-00-02",
+				EthType:     "0800",
+			},
+		},
+		TrafficRoutes: []*models.RouteToLocation{
+			{
+				Dnai: "mec",
+			},
+		},
+	}
 )
 
 func TestGetTrafficInfluenceSubscription(t *testing.T) {
 	testCases := []struct {
 		description      string
 		afID             string
+		filter           *TrafficInfluSubFilter
+		page             *Page
 		expectedResponse *HandlerResponse
+		expectedLink     string
 	}{
 		{
 			description: "TC1: AfID found, should return all TrafficInfluSub",
 			afID:        "af1",
+			filter:      &TrafficInfluSubFilter{},
+			page:        &Page{},
 			expectedResponse: &HandlerResponse{
 				Status: http.StatusOK,
 				Body:   &[]models.NefTrafficInfluSub{tiSub1ForAf1, tiSub2ForAf1},
@@ -164,6 +241,8 @@ func TestGetTrafficInfluenceSubscription(t *testing.T) {
 		{
 			description: "TC2: AfID not found, should return ProblemDetails",
 			afID:        "af3",
+			filter:      &TrafficInfluSubFilter{},
+			page:        &Page{},
 			expectedResponse: &HandlerResponse{
 				Status: http.StatusNotFound,
 				Body: &models.ProblemDetails{
@@ -173,6 +252,41 @@ func TestGetTrafficInfluenceSubscription(t *testing.T) {
 				},
 			},
 		},
+		{
+			description: "TC3: Filtered by afAppId, should return matched TrafficInfluSub",
+			afID:        "af1",
+			filter: &TrafficInfluSubFilter{
+				AfAppId: tiSub2ForAf1.AfAppId,
+				Snssai:  &models.Snssai{Sst: 1, Sd: "010203"},
+			},
+			page: &Page{},
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusOK,
+				Body:   &[]models.NefTrafficInfluSub{tiSub2ForAf1},
+			},
+		},
+		{
+			description: "TC4: First page, should return the first TrafficInfluSub with link to next page",
+			afID:        "af1",
+			filter:      &TrafficInfluSubFilter{Dnn: "internet"},
+			page:        &Page{Limit: 1},
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusOK,
+				Body:   &[]models.NefTrafficInfluSub{tiSub1ForAf1},
+			},
+			expectedLink: "<" + nefApp.Processor().genTrafficInfluSubsURI("af1") +
+				"?cursor=1&dnn=internet&limit=1>; rel=\"next\"",
+		},
+		{
+			description: "TC5: Last page, should return the rest TrafficInfluSub without link",
+			afID:        "af1",
+			filter:      &TrafficInfluSubFilter{Dnn: "internet"},
+			page:        &Page{Cursor: "1", Limit: 1},
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusOK,
+				Body:   &[]models.NefTrafficInfluSub{tiSub2ForAf1},
+			},
+		},
 	}
 
 	nefCtx := nefApp.Context()
@@ -185,16 +299,17 @@ func TestGetTrafficInfluenceSubscription(t *testing.T) {
 	correID2 := nefCtx.NewCorreID()
 	afSub2 := af1.NewSub(correID2, &tiSub2ForAf1)
 	af1.Subs[afSub2.SubID] = afSub2
-	nefCtx.AddAf(af1)
 	af1.Mu.Unlock()
+	nefCtx.AddAf(af1)
 
 	for _, tc := range testCases {
 		t.Run(tc.description, func(t *testing.T) {
 			httpRecorder := httptest.NewRecorder()
 			c, _ := gin.CreateTestContext(httpRecorder)
 
-			nefApp.Processor().GetTrafficInfluenceSubscription(c, tc.afID)
+			nefApp.Processor().GetTrafficInfluenceSubscription(c, tc.afID, tc.filter, tc.page)
 			require.Equal(t, tc.expectedResponse.Status, httpRecorder.Code)
+			require.Equal(t, tc.expectedLink, httpRecorder.Header().Get("Link"))
 
 			if trafficInfluSub, ok := tc.expectedResponse.Body.(*[]models.NefTrafficInfluSub); ok {
 				var rspSubs []models.NefTrafficInfluSub
@@ -260,8 +375,8 @@ func TestGetGetIndividualTrafficInfluenceSubscription(t *testing.T) {
 	correID1 := nefCtx.NewCorreID()
 	afSub1 := af1.NewSub(correID1, &tiSub1ForAf1)
 	af1.Subs[afSub1.SubID] = afSub1
-	nefCtx.AddAf(af1)
 	af1.Mu.Unlock()
+	nefCtx.AddAf(af1)
 
 	for _, tc := range testCases {
 		t.Run(tc.description, func(t *testing.T) {
@@ -281,8 +396,16 @@ func TestGetGetIndividualTrafficInfluenceSubscription(t *testing.T) {
 
 func TestPostTrafficInfluenceSubscription(t *testing.T) {
 	initNRFDiscPCFStub()
+	initNRFDiscUDMStub()
 	initUDRDrPutTiDataStub(http.StatusNoContent)
 	initPCFPaPostAppSessionsStub(http.StatusCreated)
+	initUDMSdmGetGroupIdentifiersStub()
+	initNRFDiscBSFStub()
+	initUDMSdmGetSupiStub()
+	initBSFMgmtGetPcfBindingStub()
+	initBSFMgmtGetPcfBindingByMacStub()
+	initPCFPaPostAppSessionsStubForBinding()
+	initBSFMgmtGetNoPcfBindingStub()
 	defer gock.Off()
 
 	rspTiSub1 := tiSub1ForAf1
@@ -291,11 +414,33 @@ func TestPostTrafficInfluenceSubscription(t *testing.T) {
 	rspTiSub2 := tiSub3ForAf1
 	rspTiSub2.Self = nefApp.Processor().genTrafficInfluSubURI("af1", "2")
 
+	rspTiSub3 := tiSub6ForAf1
+	rspTiSub3.Self = nefApp.Processor().genTrafficInfluSubURI("af1", "3")
+
+	rspTiSub4 := tiSub7ForAf1
+	rspTiSub4.Self = nefApp.Processor().genTrafficInfluSubURI("af1", "5")
+
+	rspTiSub5 := tiSub8ForAf1
+	rspTiSub5.Self = nefApp.Processor().genTrafficInfluSubURI("af1", "6")
+
+	tiSubInvalidMac := tiSub8ForAf1
+	tiSubInvalidMac.MacAddr = "02:00:00:00:00:01"
+
+	expiredTime := time.Now().Add(-time.Hour)
+	tiSubExpired := tiSub1ForAf1
+	tiSubExpired.TempValidities = []models.TemporalValidity{
+		{StopTime: &expiredTime},
+	}
+
+	tiSubUnknownGroup := tiSub6ForAf1
+	tiSubUnknownGroup.ExternalGroupId = "extgroupid-unknown@free5gc.org"
+
 	testCases := []struct {
-		description      string
-		afID             string
-		tiSub            *models.NefTrafficInfluSub
-		expectedResponse *HandlerResponse
+		description        string
+		afID               string
+		tiSub              *models.NefTrafficInfluSub
+		pcfBindingFallback bool
+		expectedResponse   *HandlerResponse
 	}{
 		{
 			description: "TC1: Successful AnyUE subscription, should put tiData to UDR",
@@ -310,9 +455,10 @@ func TestPostTrafficInfluenceSubscription(t *testing.T) {
 			},
 		},
 		{
-			description: "TC2: Successful UEIPv4 subscription, should post AppSession to PCF",
-			afID:        "af1",
-			tiSub:       &tiSub3ForAf1,
+			description:        "TC2: Successful UEIPv4 subscription without PCF binding, should post AppSession to PCF",
+			afID:               "af1",
+			tiSub:              &tiSub3ForAf1,
+			pcfBindingFallback: true,
 			expectedResponse: &HandlerResponse{
 				Status: http.StatusCreated,
 				Headers: map[string][]string{
@@ -335,7 +481,7 @@ func TestPostTrafficInfluenceSubscription(t *testing.T) {
 			},
 		},
 		{
-			description: "TC4: Missing one of Gpsi, Ipv4Addr, Ipv6Addr, ExternalGroupId, AnyUeInd",
+			description: "TC4: Missing one of Gpsi, MacAddr, Ipv4Addr, Ipv6Addr, ExternalGroupId, AnyUeInd",
 			afID:        "af1",
 			tiSub:       &tiSub5ForAf1,
 			expectedResponse: &HandlerResponse{
@@ -343,19 +489,113 @@ func TestPostTrafficInfluenceSubscription(t *testing.T) {
 				Body: &models.ProblemDetails{
 					Status: http.StatusBadRequest,
 					Title:  "Malformed request syntax",
-					Detail: "Missing one of Gpsi, Ipv4Addr, Ipv6Addr, ExternalGroupId, AnyUeInd",
+					Detail: "Missing one of Gpsi, MacAddr, Ipv4Addr, Ipv6Addr, ExternalGroupId, AnyUeInd",
+				},
+			},
+		},
+		{
+			description: "TC5: Successful group subscription, should put tiData with internalGroupId to UDR",
+			afID:        "af1",
+			tiSub:       &tiSub6ForAf1,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusCreated,
+				Headers: map[string][]string{
+					"Location": {rspTiSub3.Self},
+				},
+				Body: &rspTiSub3,
+			},
+		},
+		{
+			description: "TC6: Unknown externalGroupId, should return ProblemDetails",
+			afID:        "af1",
+			tiSub:       &tiSubUnknownGroup,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusNotFound,
+				Body: &models.ProblemDetails{
+					Status: http.StatusNotFound,
+					Title:  "Data not found",
+					Detail: "externalGroupId[extgroupid-unknown@free5gc.org] is not found",
+				},
+			},
+		},
+		{
+			description: "TC7: Successful GPSI subscription, should post AppSession to the PCF bound by BSF",
+			afID:        "af1",
+			tiSub:       &tiSub7ForAf1,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusCreated,
+				Headers: map[string][]string{
+					"Location": {rspTiSub4.Self},
+				},
+				Body: &rspTiSub4,
+			},
+		},
+		{
+			description: "TC8: Successful MacAddr subscription, should post AppSession to PCF",
+			afID:        "af1",
+			tiSub:       &tiSub8ForAf1,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusCreated,
+				Headers: map[string][]string{
+					"Location": {rspTiSub5.Self},
+				},
+				Body: &rspTiSub5,
+			},
+		},
+		{
+			description: "TC9: Invalid MacAddr, should return ProblemDetails",
+			afID:        "af1",
+			tiSub:       &tiSubInvalidMac,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusBadRequest,
+				Body: &models.ProblemDetails{
+					Status: http.StatusBadRequest,
+					Title:  "Malformed request syntax",
+					Detail: "Invalid macAddr[02:00:00:00:00:01]",
+				},
+			},
+		},
+		{
+			description: "TC10: All tempValidities have expired, should return ProblemDetails",
+			afID:        "af1",
+			tiSub:       &tiSubExpired,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusBadRequest,
+				Body: &models.ProblemDetails{
+					Status: http.StatusBadRequest,
+					Title:  "Malformed request syntax",
+					Detail: "All tempValidities have expired",
+				},
+			},
+		},
+		{
+			description: "TC11: UEIPv4 subscription without PCF binding, should return ProblemDetails",
+			afID:        "af1",
+			tiSub:       &tiSub3ForAf1,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusNotFound,
+				Body: &models.ProblemDetails{
+					Status: http.StatusNotFound,
+					Title:  "Data not found",
+					Detail: "PCF binding of the UE is not found",
 				},
 			},
 		},
 	}
 
+	cfg := nefApp.Config()
+	defer func() {
+		cfg.Configuration.PcfBindingFallback = false
+	}()
+
 	nefCtx := nefApp.Context()
 	for _, tc := range testCases {
 		t.Run(tc.description, func(t *testing.T) {
 			httpRecorder := httptest.NewRecorder()
 			c, _ := gin.CreateTestContext(httpRecorder)
 
-			nefApp.Processor().PostTrafficInfluenceSubscription(c, tc.afID, tc.tiSub)
+			cfg.Configuration.PcfBindingFallback = tc.pcfBindingFallback
+			nefApp.Processor().PostTrafficInfluenceSubscription(c, tc.afID, tc.tiSub, nil)
 			require.Equal(t, tc.expectedResponse.Status, httpRecorder.Code)
 
 			if tc.expectedResponse.Headers != nil {
@@ -374,6 +614,24 @@ func TestPostTrafficInfluenceSubscription(t *testing.T) {
 			assertJSONBodyEqual(t, tc.expectedResponse.Body, httpRecorder.Body.Bytes())
 		})
 	}
+
+	intGroupID, ok := nefCtx.GetIntGroupID(tiSub6ForAf1.ExternalGroupId)
+	require.True(t, ok)
+	require.Equal(t, "group1", intGroupID)
+
+	af1 := nefCtx.GetAf("af1")
+	require.NotNil(t, af1)
+	afSub := af1.Subs["5"]
+	require.NotNil(t, afSub)
+	require.Equal(t, "imsi-208930000000001", afSub.Supi)
+	require.Equal(t, "http://127.0.0.17:8000", afSub.PcfUri)
+	require.Equal(t, "67890", afSub.AppSessID)
+
+	afSub = af1.Subs["6"]
+	require.NotNil(t, afSub)
+	require.Equal(t, "http://127.0.0.7:8000", afSub.PcfUri)
+	require.Equal(t, "12345", afSub.AppSessID)
+
 	nefCtx.DeleteAf("af1")
 	nefCtx.ResetCorreID()
 }
@@ -433,8 +691,8 @@ func TestDeleteIndividualTrafficInfluenceSubscription(t *testing.T) {
 	afSub2 := af1.NewSub(correID2, &tiSub3ForAf1)
 	af1.Subs[afSub2.SubID] = afSub2
 	afSub2.AppSessID = "12345"
-	nefCtx.AddAf(af1)
 	af1.Mu.Unlock()
+	nefCtx.AddAf(af1)
 
 	for _, tc := range testCases {
 		t.Run(tc.description, func(t *testing.T) {
@@ -520,8 +778,8 @@ func TestPatchIndividualTrafficInfluenceSubscription(t *testing.T) {
 	afSub2 := af1.NewSub(correID2, &tiSub3ForAf1)
 	af1.Subs[afSub2.SubID] = afSub2
 	afSub2.AppSessID = "12345"
-	nefCtx.AddAf(af1)
 	af1.Mu.Unlock()
+	nefCtx.AddAf(af1)
 
 	for _, tc := range testCases {
 		t.Run(tc.description, func(t *testing.T) {
@@ -542,9 +800,29 @@ func TestPatchIndividualTrafficInfluenceSubscription(t *testing.T) {
 func TestPutIndividualTrafficInfluenceSubscription(t *testing.T) {
 	initNRFDiscPCFStub()
 	initUDRDrPutTiDataStub(http.StatusNoContent)
-	initPCFPaPostAppSessionsStub(http.StatusCreated)
+	initPCFPaGetAppSessionStub()
+	initPCFPaPatchAppSessionsStub(http.StatusNoContent)
 	defer gock.Off()
 
+	tiSub3Put := tiSub3ForAf1
+	tiSub3Put.TrafficRoutes = []*models.RouteToLocation{
+		{
+			Dnai: "mec5",
+		},
+	}
+
+	tiSub3PutOtherUe := tiSub3ForAf1
+	tiSub3PutOtherUe.Ipv4Addr = "10.60.0.20"
+
+	tiSub3PutNoRoutes := tiSub3ForAf1
+	tiSub3PutNoRoutes.TrafficRoutes = nil
+
+	// Registered before the POST of AppSession which also matches the path
+	deleteMock := gock.New("http://127.0.0.7:8000/npcf-policyauthorization/v1").
+		Post("/app-sessions/24680/delete").
+		Reply(http.StatusNoContent).Mock
+	initPCFPaPostAppSessionsStub(http.StatusCreated)
+
 	testCases := []struct {
 		description      string
 		afID             string
@@ -563,19 +841,19 @@ func TestPutIndividualTrafficInfluenceSubscription(t *testing.T) {
 			},
 		},
 		{
-			description: "TC2: Successful put TI subscription to PCF",
+			description: "TC2: Successful put TI subscription to PCF, should update the existing AppSession",
 			afID:        "af1",
 			subID:       "2",
-			tiSub:       &tiSub2ForAf1,
+			tiSub:       &tiSub3Put,
 			expectedResponse: &HandlerResponse{
 				Status: http.StatusOK,
-				Body:   &tiSub2ForAf1,
+				Body:   &tiSub3Put,
 			},
 		},
 		{
 			description: "TC3: Put non-existed TI subscription",
 			afID:        "af1",
-			subID:       "3",
+			subID:       "9",
 			tiSub:       &tiSub2ForAf1,
 			expectedResponse: &HandlerResponse{
 				Status: http.StatusNotFound,
@@ -601,7 +879,7 @@ func TestPutIndividualTrafficInfluenceSubscription(t *testing.T) {
 			},
 		},
 		{
-			description: "TC5: Missing one of Gpsi, Ipv4Addr, Ipv6Addr, ExternalGroupId, AnyUeInd",
+			description: "TC5: Missing one of Gpsi, MacAddr, Ipv4Addr, Ipv6Addr, ExternalGroupId, AnyUeInd",
 			afID:        "af1",
 			subID:       "5",
 			tiSub:       &tiSub5ForAf1,
@@ -610,10 +888,47 @@ func TestPutIndividualTrafficInfluenceSubscription(t *testing.T) {
 				Body: &models.ProblemDetails{
 					Status: http.StatusBadRequest,
 					Title:  "Malformed request syntax",
-					Detail: "Missing one of Gpsi, Ipv4Addr, Ipv6Addr, ExternalGroupId, AnyUeInd",
+					Detail: "Missing one of Gpsi, MacAddr, Ipv4Addr, Ipv6Addr, ExternalGroupId, AnyUeInd",
+				},
+			},
+		},
+		{
+			description: "TC6: Change UE of individual UE subscription, should return ProblemDetails",
+			afID:        "af1",
+			subID:       "2",
+			tiSub:       &tiSub3PutOtherUe,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusBadRequest,
+				Body: &models.ProblemDetails{
+					Status: http.StatusBadRequest,
+					Title:  "Malformed request syntax",
+					Detail: "UE identifier, dnn and snssai of an individual UE subscription cannot be changed",
+				},
+			},
+		},
+		{
+			description: "TC7: PCF rejects the update, should keep the stored TI subscription",
+			afID:        "af1",
+			subID:       "3",
+			tiSub:       &tiSub3Put,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusNotFound,
+				Body: &models.ProblemDetails{
+					Status: http.StatusNotFound,
+					Cause:  "APPLICATION_SESSION_CONTEXT_NOT_FOUND",
 				},
 			},
 		},
+		{
+			description: "TC8: Put TI subscription without trafficRoutes to PCF, should recreate the AppSession",
+			afID:        "af1",
+			subID:       "4",
+			tiSub:       &tiSub3PutNoRoutes,
+			expectedResponse: &HandlerResponse{
+				Status: http.StatusOK,
+				Body:   &tiSub3PutNoRoutes,
+			},
+		},
 	}
 
 	nefCtx := nefApp.Context()
@@ -628,8 +943,18 @@ func TestPutIndividualTrafficInfluenceSubscription(t *testing.T) {
 	afSub2 := af1.NewSub(correID2, &tiSub3ForAf1)
 	af1.Subs[afSub2.SubID] = afSub2
 	afSub2.AppSessID = "12345"
-	nefCtx.AddAf(af1)
+
+	correID3 := nefCtx.NewCorreID()
+	afSub3 := af1.NewSub(correID3, &tiSub3ForAf1)
+	af1.Subs[afSub3.SubID] = afSub3
+	afSub3.AppSessID = "67890"
+
+	correID4 := nefCtx.NewCorreID()
+	afSub4 := af1.NewSub(correID4, &tiSub3ForAf1)
+	af1.Subs[afSub4.SubID] = afSub4
+	afSub4.AppSessID = "24680"
 	af1.Mu.Unlock()
+	nefCtx.AddAf(af1)
 
 	for _, tc := range testCases {
 		t.Run(tc.description, func(t *testing.T) {
@@ -643,10 +968,73 @@ func TestPutIndividualTrafficInfluenceSubscription(t *testing.T) {
 			assertJSONBodyEqual(t, tc.expectedResponse.Body, httpRecorder.Body.Bytes())
 		})
 	}
+
+	require.Equal(t, "12345", afSub2.AppSessID)
+	require.Equal(t, &tiSub3Put, afSub2.TiSub)
+	require.Equal(t, &tiSub3ForAf1, afSub3.TiSub)
+	require.True(t, deleteMock.Done())
+	require.Equal(t, "12345", afSub4.AppSessID)
+	require.Equal(t, &tiSub3PutNoRoutes, afSub4.TiSub)
 	nefCtx.DeleteAf(af1.AfID)
 	nefCtx.ResetCorreID()
 }
 
+func TestConvertTrafficInfluSubWithGeoZones(t *testing.T) {
+	stadiumTai := models.Tai{
+		PlmnId: &models.PlmnId{
+			Mcc: "208",
+			Mnc: "93",
+		},
+		Tac: "000001",
+	}
+	stadiumNcgi := models.Ncgi{
+		PlmnId: &models.PlmnId{
+			Mcc: "208",
+			Mnc: "93",
+		},
+		NrCellId: "000000010",
+	}
+
+	cfg := nefApp.Config()
+	cfg.Configuration.GeoZones = []factory.GeoZone{
+		{
+			ZoneId: "stadium",
+			Tais:   []models.Tai{stadiumTai},
+			Ncgis:  []models.Ncgi{stadiumNcgi},
+		},
+	}
+	defer func() {
+		cfg.Configuration.GeoZones = nil
+	}()
+
+	tiSub := tiSub3ForAf1
+	tiSub.ValidGeoZoneIds = []string{"stadium"}
+
+	require.Nil(t, nefApp.Processor().validateGeoZoneIds(tiSub.ValidGeoZoneIds))
+
+	rsp := nefApp.Processor().validateGeoZoneIds([]string{"campus"})
+	require.NotNil(t, rsp)
+	require.Equal(t, http.StatusBadRequest, rsp.Status)
+	require.Equal(t, openapi.ProblemDetailsMalformedReqSyntax("Unknown validGeoZoneId[campus]"), rsp.Body)
+
+	asc := nefApp.Processor().convertTrafficInfluSubToAppSessionContext(&tiSub, "1", "")
+	require.Equal(t, &models.SpatialValidity{
+		PresenceInfoList: map[string]models.PresenceInfo{
+			"stadium": {
+				PresenceState:    models.PresenceState_IN_AREA,
+				TrackingAreaList: []models.Tai{stadiumTai},
+				NcgiList:         []models.Ncgi{stadiumNcgi},
+			},
+		},
+	}, asc.AscReqData.AfRoutReq.SpVal)
+
+	tiData := nefApp.Processor().convertTrafficInfluSubToTrafficInfluData(&tiSub, "1", "")
+	require.Equal(t, &models.NetworkAreaInfo{
+		Tais:  []models.Tai{stadiumTai},
+		Ncgis: []models.Ncgi{stadiumNcgi},
+	}, tiData.NwAreaInfo)
+}
+
 func initUDRDrPutTiDataStub(statusCode int) {
 	gock.New("http://127.0.0.4:8000/nudr-dr/v1").
 		Put("/application-data/influenceData/.*").
@@ -696,6 +1084,28 @@ func initPCFPaPostAppSessionsStub(statusCode int) {
 		JSON(asc3ForAf1)
 }
 
+func initPCFPaGetAppSessionStub() {
+	gock.New("http://127.0.0.7:8000/npcf-policyauthorization/v1").
+		Get("/app-sessions/12345").
+		Persist().
+		Reply(http.StatusOK).
+		JSON(&models.AppSessionContext{
+			AscReqData: &models.AppSessionContextReqData{
+				AfAppId: tiSub3ForAf1.AfAppId,
+				UeIpv4:  tiSub3ForAf1.Ipv4Addr,
+			},
+		})
+
+	gock.New("http://127.0.0.7:8000/npcf-policyauthorization/v1").
+		Get("/app-sessions/67890").
+		Persist().
+		Reply(http.StatusNotFound).
+		JSON(&models.ProblemDetails{
+			Status: http.StatusNotFound,
+			Cause:  "APPLICATION_SESSION_CONTEXT_NOT_FOUND",
+		})
+}
+
 func initPCFPaPatchAppSessionsStub(statusCode int) {
 	gock.New("http://127.0.0.7:8000/npcf-policyauthorization/v1").
 		Patch("/app-sessions/12345").
@@ -727,3 +1137,193 @@ func assertJSONBodyEqual(t *testing.T, expectedBody interface{}, actualBody []by
 
 	require.Equal(t, expectedData, actualData)
 }
+
+func initNRFDiscUDMStub() {
+	searchResult := &models.SearchResult{
+		ValidityPeriod: 100,
+		NfInstances: []models.NrfNfDiscoveryNfProfile{
+			{
+				NfInstanceId: "nef-unit-testing",
+				NfType:       "UDM",
+				NfStatus:     "REGISTERED",
+				Ipv4Addresses: []string{
+					"127.0.0.3",
+				},
+				NfServices: []models.NrfNfDiscoveryNfService{
+					{
+						ServiceInstanceId: "1",
+						ServiceName:       "nudm-sdm",
+						Versions: []models.NfServiceVersion{
+							{
+								ApiVersionInUri: "v2",
+								ApiFullVersion:  "2.0.0",
+							},
+						},
+						Scheme:          "http",
+						NfServiceStatus: "REGISTERED",
+						IpEndPoints: []models.IpEndPoint{
+							{
+								Ipv4Address: "127.0.0.3",
+								Transport:   "TCP",
+								Port:        8000,
+							},
+						},
+						ApiPrefix: "http://127.0.0.3:8000",
+					},
+				},
+			},
+		},
+	}
+
+	gock.New("http://127.0.0.10:8000/nnrf-disc/v1").
+		Get("/nf-instances").
+		MatchParam("target-nf-type", "UDM").
+		MatchParam("requester-nf-type", "NEF").
+		MatchParam("service-names", "nudm-sdm").
+		Reply(http.StatusOK).
+		JSON(searchResult)
+}
+
+func initUDMSdmGetGroupIdentifiersStub() {
+	gock.New("http://127.0.0.3:8000/nudm-sdm/v2").
+		Get("/group-data/group-identifiers").
+		MatchParam("ext-group-id", "extgroupid-group1@free5gc.org").
+		Reply(http.StatusOK).
+		JSON(&models.GroupIdentifiers{
+			ExtGroupId: "extgroupid-group1@free5gc.org",
+			IntGroupId: "group1",
+		})
+
+	gock.New("http://127.0.0.3:8000/nudm-sdm/v2").
+		Get("/group-data/group-identifiers").
+		MatchParam("ext-group-id", "extgroupid-unknown@free5gc.org").
+		Reply(http.StatusNotFound).
+		JSON(&models.ProblemDetails{
+			Status: http.StatusNotFound,
+			Cause:  "DATA_NOT_FOUND",
+		})
+}
+
+func initNRFDiscBSFStub() {
+	searchResult := &models.SearchResult{
+		ValidityPeriod: 100,
+		NfInstances: []models.NrfNfDiscoveryNfProfile{
+			{
+				NfInstanceId: "nef-unit-testing",
+				NfType:       "BSF",
+				NfStatus:     "REGISTERED",
+				Ipv4Addresses: []string{
+					"127.0.0.13",
+				},
+				NfServices: []models.NrfNfDiscoveryNfService{
+					{
+						ServiceInstanceId: "1",
+						ServiceName:       "nbsf-management",
+						Versions: []models.NfServiceVersion{
+							{
+								ApiVersionInUri: "v1",
+								ApiFullVersion:  "1.0.0",
+							},
+						},
+						Scheme:          "http",
+						NfServiceStatus: "REGISTERED",
+						IpEndPoints: []models.IpEndPoint{
+							{
+								Ipv4Address: "127.0.0.13",
+								Transport:   "TCP",
+								Port:        8000,
+							},
+						},
+						ApiPrefix: "http://127.0.0.13:8000",
+					},
+				},
+			},
+		},
+	}
+
+	gock.New("http://127.0.0.10:8000/nnrf-disc/v1").
+		Get("/nf-instances").
+		MatchParam("target-nf-type", "BSF").
+		MatchParam("requester-nf-type", "NEF").
+		MatchParam("service-names", "nbsf-management").
+		Reply(http.StatusOK).
+		JSON(searchResult)
+}
+
+func initUDMSdmGetSupiStub() {
+	gock.New("http://127.0.0.3:8000/nudm-sdm/v2").
+		Get("/msisdn-0900000000/id-translation-result").
+		Reply(http.StatusOK).
+		JSON(&models.IdTranslationResult{
+			Supi: "imsi-208930000000001",
+		})
+}
+
+func initBSFMgmtGetPcfBindingStub() {
+	gock.New("http://127.0.0.13:8000/nbsf-management/v1").
+		Get("/pcfBindings").
+		MatchParam("supi", "imsi-208930000000001").
+		MatchParam("dnn", "internet").
+		Reply(http.StatusOK).
+		JSON(&models.PcfBinding{
+			Supi: "imsi-208930000000001",
+			Dnn:  "internet",
+			Snssai: &models.Snssai{
+				Sst: 1,
+				Sd:  "010203",
+			},
+			PcfIpEndPoints: []models.IpEndPoint{
+				{
+					Ipv4Address: "127.0.0.17",
+					Transport:   "TCP",
+					Port:        8000,
+				},
+			},
+		})
+}
+
+func initBSFMgmtGetPcfBindingByMacStub() {
+	gock.New("http://127.0.0.13:8000/nbsf-management/v1").
+		Get("/pcfBindings").
+		MatchParam("macAddr48", "02-00-00-00-00-01").
+		MatchParam("dnn", "lan").
+		Reply(http.StatusOK).
+		JSON(&models.PcfBinding{
+			MacAddr48: "02-00-00-00-00-01",
+			Dnn:       "lan",
+			Snssai: &models.Snssai{
+				Sst: 1,
+				Sd:  "010203",
+			},
+			PcfIpEndPoints: []models.IpEndPoint{
+				{
+					Ipv4Address: "127.0.0.7",
+					Transport:   "TCP",
+					Port:        8000,
+				},
+			},
+		})
+}
+
+func initBSFMgmtGetNoPcfBindingStub() {
+	gock.New("http://127.0.0.13:8000/nbsf-management/v1").
+		Get("/pcfBindings").
+		MatchParam("ipv4Addr", "10.60.0.10").
+		MatchParam("dnn", "internet").
+		Times(2).
+		Reply(http.StatusNoContent)
+}
+
+func initPCFPaPostAppSessionsStubForBinding() {
+	gock.New("http://127.0.0.17:8000/npcf-policyauthorization/v1").
+		Post("/app-sessions").
+		Reply(http.StatusCreated).
+		SetHeader("Location", "http://127.0.0.17:8000/npcf-policyauthorization/v1/app-sessions/67890").
+		JSON(&models.AppSessionContext{
+			AscReqData: &models.AppSessionContextReqData{
+				AfAppId: tiSub7ForAf1.AfAppId,
+				Supi:    "imsi-208930000000001",
+				Gpsi:    tiSub7ForAf1.Gpsi,
+			},
+		})
+}
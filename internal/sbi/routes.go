@@ -1,6 +1,10 @@
 package sbi
 
 import (
+	"fmt"
+	"strconv"
+
+	"github.com/free5gc/nef/internal/sbi/processor"
 	"github.com/gin-gonic/gin"
 )
 
@@ -8,6 +12,8 @@ type Route struct {
 	Method  string
 	Pattern string
 	APIFunc gin.HandlerFunc
+	// Name of the resource published to CAPIF, empty if the route is not published
+	ResourceName string
 }
 
 func applyRoutes(group *gin.RouterGroup, endpoints []Route) {
@@ -26,3 +32,34 @@ func applyRoutes(group *gin.RouterGroup, endpoints []Route) {
 		}
 	}
 }
+
+// capifRoutes gets the routes published to CAPIF from the route table
+func capifRoutes(endpoints []Route) []processor.CapifRoute {
+	var routes []processor.CapifRoute
+	for _, endpoint := range endpoints {
+		if endpoint.ResourceName == "" {
+			continue
+		}
+		routes = append(routes, processor.CapifRoute{
+			ResourceName: endpoint.ResourceName,
+			Method:       endpoint.Method,
+			Pattern:      endpoint.Pattern,
+		})
+	}
+	return routes
+}
+
+// parsePage gets the cursor/limit pagination from the query parameters of a collection GET
+func parsePage(gc *gin.Context) (*processor.Page, error) {
+	pg := &processor.Page{
+		Cursor: gc.Query("cursor"),
+	}
+	if limit := gc.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid limit[%s], should be a positive integer", limit)
+		}
+		pg.Limit = n
+	}
+	return pg, nil
+}
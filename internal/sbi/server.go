@@ -31,8 +31,10 @@ type nef interface {
 type Server struct {
 	nef
 
-	httpServer *http.Server
-	router     *gin.Engine
+	httpServer     *http.Server
+	router         *gin.Engine
+	tokenValidator *tokenValidator // nil if the access tokens of AFs are not validated
+	certIdentity   string          // field of the client certificate as AF identity, empty if not verified
 }
 
 func NewServer(nef nef, tlsKeyLogPath string) (*Server, error) {
@@ -40,15 +42,40 @@ func NewServer(nef nef, tlsKeyLogPath string) (*Server, error) {
 		nef: nef,
 	}
 
+	if oauth2 := s.Config().NorthboundOAuth2(); oauth2 != nil {
+		var err error
+		if s.tokenValidator, err = newTokenValidator(oauth2); err != nil {
+			logger.InitLog.Errorf("Initialize access token validator failed: %+v", err)
+			return nil, err
+		}
+	}
+
+	clientAuth, clientCA := s.Config().TlsClientAuth()
+	if s.Config().SbiScheme() == "https" && clientAuth != factory.TlsClientAuthNone {
+		s.certIdentity = s.Config().TlsClientIdentity()
+	}
+
 	s.router = logger_util.NewGinWithLogrus(logger.GinLog)
 
 	endpoints := s.getTrafficInfluenceRoutes()
 	group := s.router.Group(factory.TraffInfluResUriPrefix)
+	group.Use(s.capifInvocationLog(factory.ServiceTraffInflu, factory.TraffInfluResUriPrefix, "afID"))
+	group.Use(certAuthentication(s.certIdentity, "afID"))
+	group.Use(oauth2Authentication(s.tokenValidator, factory.ServiceTraffInflu, "afID"))
+	group.Use(s.capifAuthorization(factory.ServiceTraffInflu, "afID"))
+	group.Use(s.afAuthorization(factory.ServiceTraffInflu, "afID", trafficInfluScope))
 	applyRoutes(group, endpoints)
+	s.Processor().SetCapifRoutes(factory.ServiceTraffInflu, capifRoutes(endpoints))
 
 	endpoints = s.getPFDManagementRoutes()
 	group = s.router.Group(factory.PfdMngResUriPrefix)
+	group.Use(s.capifInvocationLog(factory.ServicePfdMng, factory.PfdMngResUriPrefix, "scsAsID"))
+	group.Use(certAuthentication(s.certIdentity, "scsAsID"))
+	group.Use(oauth2Authentication(s.tokenValidator, factory.ServicePfdMng, "scsAsID"))
+	group.Use(s.capifAuthorization(factory.ServicePfdMng, "scsAsID"))
+	group.Use(s.afAuthorization(factory.ServicePfdMng, "scsAsID", pfdMngScope))
 	applyRoutes(group, endpoints)
+	s.Processor().SetCapifRoutes(factory.ServicePfdMng, capifRoutes(endpoints))
 
 	endpoints = s.getPFDFRoutes()
 	group = s.router.Group(factory.NefPfdMngResUriPrefix)
@@ -56,6 +83,9 @@ func NewServer(nef nef, tlsKeyLogPath string) (*Server, error) {
 
 	endpoints = s.getOamRoutes()
 	group = s.router.Group(factory.NefOamResUriPrefix)
+	// OAM isn't invoked by AFs, so only the client certificate and the token scope are checked
+	group.Use(certAuthentication(s.certIdentity, ""))
+	group.Use(oauth2Authentication(s.tokenValidator, factory.ServiceNefOam, ""))
 	applyRoutes(group, endpoints)
 
 	endpoints = s.getCallbackRoutes()
@@ -81,6 +111,12 @@ func NewServer(nef nef, tlsKeyLogPath string) (*Server, error) {
 		logger.InitLog.Errorf("Initialize HTTP server failed: %+v", err)
 		return nil, err
 	}
+	if s.certIdentity != "" {
+		if s.httpServer.TLSConfig, err = newClientAuthTLSConfig(s.httpServer.TLSConfig, clientAuth, clientCA); err != nil {
+			logger.InitLog.Errorf("Initialize client certificate verification failed: %+v", err)
+			return nil, err
+		}
+	}
 
 	return s, nil
 }
@@ -0,0 +1,202 @@
+// Package validator checks the syntax of the PFD contents provisioned by AF before they are stored in UDR
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/free5gc/openapi/models"
+)
+
+const (
+	flowDescAny      = "any"
+	flowDescAssigned = "assigned"
+)
+
+var domainLabelRegexp = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?$`)
+
+// Pfd checks FlowDescriptions, Urls and DomainNames of the PFD.
+// The Param of the returned InvalidParams is a JSON pointer prefixed with the given pointer of the PFD,
+// e.g. "/pfds/pfd1/flowDescriptions/0".
+func Pfd(pointer string, pfd *models.Pfd) []models.InvalidParam {
+	var invalidParams []models.InvalidParam
+	for i, fd := range pfd.FlowDescriptions {
+		if err := FlowDescription(fd); err != nil {
+			invalidParams = append(invalidParams, models.InvalidParam{
+				Param:  pointer + "/flowDescriptions/" + strconv.Itoa(i),
+				Reason: err.Error(),
+			})
+		}
+	}
+	for i, u := range pfd.Urls {
+		if err := Url(u); err != nil {
+			invalidParams = append(invalidParams, models.InvalidParam{
+				Param:  pointer + "/urls/" + strconv.Itoa(i),
+				Reason: err.Error(),
+			})
+		}
+	}
+	for i, dn := range pfd.DomainNames {
+		if err := DomainName(dn); err != nil {
+			invalidParams = append(invalidParams, models.InvalidParam{
+				Param:  pointer + "/domainNames/" + strconv.Itoa(i),
+				Reason: err.Error(),
+			})
+		}
+	}
+	return invalidParams
+}
+
+// FlowDescription checks the IPFilterRule of TS 29.214 5.3.8, i.e.
+// "permit <in|out> <proto> from <src> [ports] to <dst> [ports]"
+func FlowDescription(fd string) error {
+	fields := strings.Fields(fd)
+	i := 0
+	next := func() (string, bool) {
+		if i >= len(fields) {
+			return "", false
+		}
+		i++
+		return fields[i-1], true
+	}
+
+	if action, ok := next(); !ok || action != "permit" {
+		return errors.New("action should be 'permit'")
+	}
+	if dir, ok := next(); !ok || (dir != "in" && dir != "out") {
+		return errors.New("direction should be 'in' or 'out'")
+	}
+	proto, ok := next()
+	if !ok {
+		return errors.New("absent of protocol")
+	}
+	if err := validateProto(proto); err != nil {
+		return err
+	}
+
+	for _, side := range []string{"from", "to"} {
+		if keyword, ok := next(); !ok || keyword != side {
+			return fmt.Errorf("'%s' is expected", side)
+		}
+		addr, ok := next()
+		if !ok {
+			return fmt.Errorf("absent of address after '%s'", side)
+		}
+		if err := validateFlowDescAddr(addr); err != nil {
+			return err
+		}
+		// Optional ports
+		if i < len(fields) && fields[i] != "to" {
+			if err := validatePorts(fields[i]); err != nil {
+				return err
+			}
+			i++
+		}
+	}
+
+	if i != len(fields) {
+		return fmt.Errorf("unexpected '%s' after destination", strings.Join(fields[i:], " "))
+	}
+	return nil
+}
+
+func validateProto(proto string) error {
+	if proto == "ip" {
+		return nil
+	}
+	n, err := strconv.Atoi(proto)
+	if err != nil || n < 0 || n > 255 {
+		return fmt.Errorf("invalid protocol[%s], should be 'ip' or 0-255", proto)
+	}
+	return nil
+}
+
+func validateFlowDescAddr(addr string) error {
+	if addr == flowDescAny || addr == flowDescAssigned {
+		return nil
+	}
+	if strings.HasPrefix(addr, "!") {
+		return fmt.Errorf("invalid address[%s], '!' shall not be used", addr)
+	}
+	if strings.Contains(addr, "/") {
+		if _, _, err := net.ParseCIDR(addr); err != nil {
+			return fmt.Errorf("invalid address[%s]", addr)
+		}
+		return nil
+	}
+	if net.ParseIP(addr) == nil {
+		return fmt.Errorf("invalid address[%s]", addr)
+	}
+	return nil
+}
+
+func validatePorts(ports string) error {
+	for _, r := range strings.Split(ports, ",") {
+		bounds := strings.Split(r, "-")
+		if len(bounds) > 2 {
+			return fmt.Errorf("invalid port range[%s]", r)
+		}
+		var nums []uint64
+		for _, b := range bounds {
+			n, err := strconv.ParseUint(b, 10, 16)
+			if err != nil {
+				return fmt.Errorf("invalid port[%s]", b)
+			}
+			nums = append(nums, n)
+		}
+		if len(nums) == 2 && nums[0] > nums[1] {
+			return fmt.Errorf("invalid port range[%s], start is larger than end", r)
+		}
+	}
+	return nil
+}
+
+// Url checks an absolute http(s) URL, or a regular expression (starting with '^')
+// matching the significant parts of the URL
+func Url(u string) error {
+	if strings.HasPrefix(u, "^") {
+		if _, err := regexp.Compile(u); err != nil {
+			return fmt.Errorf("invalid regular expression: %w", err)
+		}
+		return nil
+	}
+
+	parsed, err := url.ParseRequestURI(u)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("invalid URL scheme[%s], should be http or https", parsed.Scheme)
+	}
+	if parsed.Host == "" {
+		return errors.New("absent of host in URL")
+	}
+	return nil
+}
+
+// DomainName checks an FQDN, optionally with a leading wildcard label (e.g. "*.example.com"),
+// or a regular expression (starting with '^') matching the domain name
+func DomainName(dn string) error {
+	if strings.HasPrefix(dn, "^") {
+		if _, err := regexp.Compile(dn); err != nil {
+			return fmt.Errorf("invalid regular expression: %w", err)
+		}
+		return nil
+	}
+
+	name := strings.TrimSuffix(strings.TrimPrefix(dn, "*."), ".")
+	if name == "" || len(name) > 253 {
+		return fmt.Errorf("invalid domain name[%s]", dn)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if !domainLabelRegexp.MatchString(label) {
+			return fmt.Errorf("invalid label[%s] of domain name[%s]", label, dn)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/free5gc/openapi/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlowDescription(t *testing.T) {
+	testCases := []struct {
+		description string
+		fd          string
+		expectedErr string
+	}{
+		{
+			description: "TC1: IPv4 addresses with ports, should be valid",
+			fd:          "permit out 17 from 10.68.28.39 80 to 10.60.0.1 8000-8080,9000",
+		},
+		{
+			description: "TC2: IPv6 prefix and any, should be valid",
+			fd:          "permit in ip from 2001:db8::/32 to any",
+		},
+		{
+			description: "TC3: Assigned address, should be valid",
+			fd:          "permit out 6 from 192.168.0.0/24 443 to assigned",
+		},
+		{
+			description: "TC4: Action other than permit, should be invalid",
+			fd:          "deny out ip from any to assigned",
+			expectedErr: "action should be 'permit'",
+		},
+		{
+			description: "TC5: Empty flow description, should be invalid",
+			fd:          "",
+			expectedErr: "action should be 'permit'",
+		},
+		{
+			description: "TC6: Invalid direction, should be invalid",
+			fd:          "permit both ip from any to assigned",
+			expectedErr: "direction should be 'in' or 'out'",
+		},
+		{
+			description: "TC7: Absent protocol, should be invalid",
+			fd:          "permit out",
+			expectedErr: "absent of protocol",
+		},
+		{
+			description: "TC8: Protocol out of range, should be invalid",
+			fd:          "permit out 256 from any to assigned",
+			expectedErr: "invalid protocol[256], should be 'ip' or 0-255",
+		},
+		{
+			description: "TC9: Absent 'to', should be invalid",
+			fd:          "permit out ip from any",
+			expectedErr: "'to' is expected",
+		},
+		{
+			description: "TC10: Absent destination address, should be invalid",
+			fd:          "permit out ip from any to",
+			expectedErr: "absent of address after 'to'",
+		},
+		{
+			description: "TC11: Negated address, should be invalid",
+			fd:          "permit out ip from !10.0.0.1 to assigned",
+			expectedErr: "invalid address[!10.0.0.1], '!' shall not be used",
+		},
+		{
+			description: "TC12: Invalid prefix, should be invalid",
+			fd:          "permit out ip from 10.0.0.0/33 to assigned",
+			expectedErr: "invalid address[10.0.0.0/33]",
+		},
+		{
+			description: "TC13: Port out of range, should be invalid",
+			fd:          "permit out 17 from any 65536 to assigned",
+			expectedErr: "invalid port[65536]",
+		},
+		{
+			description: "TC14: Reversed port range, should be invalid",
+			fd:          "permit out 17 from any to assigned 9000-8000",
+			expectedErr: "invalid port range[9000-8000], start is larger than end",
+		},
+		{
+			description: "TC15: Port range with three bounds, should be invalid",
+			fd:          "permit out 17 from any to assigned 1-2-3",
+			expectedErr: "invalid port range[1-2-3]",
+		},
+		{
+			description: "TC16: Trailing option after destination, should be invalid",
+			fd:          "permit out 17 from any to assigned 80 frag",
+			expectedErr: "unexpected 'frag' after destination",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			err := FlowDescription(tc.fd)
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestUrl(t *testing.T) {
+	testCases := []struct {
+		description string
+		url         string
+		expectedErr string
+	}{
+		{
+			description: "TC1: HTTP URL, should be valid",
+			url:         "http://www.example.com/video",
+		},
+		{
+			description: "TC2: HTTPS URL with port and query, should be valid",
+			url:         "https://www.example.com:8443/path?id=1",
+		},
+		{
+			description: "TC3: Regular expression, should be valid",
+			url:         "^http://.*\\.example\\.com/.*$",
+		},
+		{
+			description: "TC4: Invalid regular expression, should be invalid",
+			url:         "^http://(example",
+			expectedErr: "invalid regular expression",
+		},
+		{
+			description: "TC5: Relative URL, should be invalid",
+			url:         "www.example.com/video",
+			expectedErr: "invalid URL",
+		},
+		{
+			description: "TC6: Scheme other than http(s), should be invalid",
+			url:         "ftp://www.example.com/file",
+			expectedErr: "invalid URL scheme[ftp], should be http or https",
+		},
+		{
+			description: "TC7: Absent host, should be invalid",
+			url:         "http:///video",
+			expectedErr: "absent of host in URL",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			err := Url(tc.url)
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestDomainName(t *testing.T) {
+	testCases := []struct {
+		description string
+		domainName  string
+		expectedErr string
+	}{
+		{
+			description: "TC1: FQDN, should be valid",
+			domainName:  "www.example.com",
+		},
+		{
+			description: "TC2: FQDN with trailing dot, should be valid",
+			domainName:  "www.example.com.",
+		},
+		{
+			description: "TC3: Leading wildcard label, should be valid",
+			domainName:  "*.example.com",
+		},
+		{
+			description: "TC4: Regular expression, should be valid",
+			domainName:  "^.*\\.example\\.com$",
+		},
+		{
+			description: "TC5: Label of 63 characters, should be valid",
+			domainName:  strings.Repeat("a", 63) + ".com",
+		},
+		{
+			description: "TC6: Invalid regular expression, should be invalid",
+			domainName:  "^[example",
+			expectedErr: "invalid regular expression",
+		},
+		{
+			description: "TC7: Only wildcard, should be invalid",
+			domainName:  "*.",
+			expectedErr: "invalid domain name[*.]",
+		},
+		{
+			description: "TC8: Longer than 253 characters, should be invalid",
+			domainName:  strings.Repeat("a.", 127) + "com",
+			expectedErr: "invalid domain name[" + strings.Repeat("a.", 127) + "com]",
+		},
+		{
+			description: "TC9: Label of 64 characters, should be invalid",
+			domainName:  strings.Repeat("a", 64) + ".com",
+			expectedErr: "invalid label[" + strings.Repeat("a", 64) + "] of domain name[" +
+				strings.Repeat("a", 64) + ".com]",
+		},
+		{
+			description: "TC10: Label starting with hyphen, should be invalid",
+			domainName:  "-www.example.com",
+			expectedErr: "invalid label[-www] of domain name[-www.example.com]",
+		},
+		{
+			description: "TC11: Empty label, should be invalid",
+			domainName:  "www..example.com",
+			expectedErr: "invalid label[] of domain name[www..example.com]",
+		},
+		{
+			description: "TC12: Wildcard not in the leading label, should be invalid",
+			domainName:  "www.*.com",
+			expectedErr: "invalid label[*] of domain name[www.*.com]",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			err := DomainName(tc.domainName)
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestPfd(t *testing.T) {
+	testCases := []struct {
+		description           string
+		pfd                   *models.Pfd
+		expectedInvalidParams []models.InvalidParam
+	}{
+		{
+			description: "TC1: Valid PFD, should return no InvalidParams",
+			pfd: &models.Pfd{
+				PfdId:            "pfd1",
+				FlowDescriptions: []string{"permit out ip from 10.68.28.39 80 to any"},
+				Urls:             []string{"http://www.example.com/video"},
+				DomainNames:      []string{"www.example.com"},
+			},
+		},
+		{
+			description: "TC2: Invalid contents, should point at each of them",
+			pfd: &models.Pfd{
+				PfdId:            "pfd1",
+				FlowDescriptions: []string{"permit out ip from any to any", "deny out ip from any to any"},
+				Urls:             []string{"ftp://www.example.com/file"},
+				DomainNames:      []string{"www.example.com", "www..example.com"},
+			},
+			expectedInvalidParams: []models.InvalidParam{
+				{
+					Param:  "/pfds/pfd1/flowDescriptions/1",
+					Reason: "action should be 'permit'",
+				},
+				{
+					Param:  "/pfds/pfd1/urls/0",
+					Reason: "invalid URL scheme[ftp], should be http or https",
+				},
+				{
+					Param:  "/pfds/pfd1/domainNames/1",
+					Reason: "invalid label[] of domain name[www..example.com]",
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.Equal(t, tc.expectedInvalidParams, Pfd("/pfds/pfd1", tc.pfd))
+		})
+	}
+}
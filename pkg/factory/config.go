@@ -8,9 +8,11 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/asaskevich/govalidator"
 	"github.com/davecgh/go-spew/spew"
@@ -43,6 +45,30 @@ const (
 	NefCallbackResUriPrefix  = "/" + ServiceNefCallback + "/v1"
 )
 
+const (
+	StoreTypeFile = "file"
+)
+
+// NefDefaultSbiClientTimeout bounds the requests to NRF/PCF/UDR/UDM/BSF, so that an NF not responding
+// doesn't hold the AF request forever
+const NefDefaultSbiClientTimeout = 5 * time.Second
+
+const (
+	// Indirect communication with NF discovery by NEF (TS 23.501 Annex E)
+	ScpModelC = "C"
+	// Indirect communication with NF discovery delegated to SCP
+	ScpModelD = "D"
+)
+
+const (
+	TlsClientAuthNone          = "none"
+	TlsClientAuthVerifyIfGiven = "verifyIfGiven"
+	TlsClientAuthRequire       = "require"
+	TlsClientIdentityCN        = "cn"
+	TlsClientIdentityDNS       = "dns"
+	TlsClientIdentityURI       = "uri"
+)
+
 type Config struct {
 	Info          *Info          `yaml:"info" valid:"required"`
 	Configuration *Configuration `yaml:"configuration" valid:"required"`
@@ -78,6 +104,32 @@ type Configuration struct {
 	NrfUri      string    `yaml:"nrfUri,omitempty" valid:"required"`
 	NrfCertPem  string    `yaml:"nrfCertPem,omitempty" valid:"optional"`
 	ServiceList []Service `yaml:"serviceList,omitempty" valid:"required"`
+	GeoZones    []GeoZone `yaml:"geoZones,omitempty" valid:"optional"`
+	// Lifetimes of the resources created by AF, without it the resources never expire
+	ResourceLifetimes []ResourceLifetime `yaml:"resourceLifetimes,omitempty" valid:"optional"`
+	// Caching time (in seconds) of PFDs when AF doesn't provide one, 0 means PFDs are not cached
+	PfdCachingTime int `yaml:"pfdCachingTime,omitempty" valid:"optional"`
+	// Authorization of AFs, without it any AF is allowed to use any service
+	AfProfiles []AfProfile `yaml:"afProfiles,omitempty" valid:"optional"`
+	// Validation of the OAuth2 access tokens of AFs, without it the requests of AFs are not authenticated
+	NorthboundOAuth2 *NorthboundOAuth2 `yaml:"northboundOAuth2,omitempty" valid:"optional"`
+	// CAPIF core function the NEF onboards to as API exposing function, without it the APIs are not published
+	Capif *Capif `yaml:"capif,omitempty" valid:"optional"`
+	// Persistent store of AFs with their subscriptions and transactions, without it they're lost when NEF restarts
+	Store *Store `yaml:"store,omitempty" valid:"optional"`
+	// Reconciliation of NEF state with UDR/PCF, without it the mismatches are only reported every 10 minutes
+	Reconciliation *Reconciliation `yaml:"reconciliation,omitempty" valid:"optional"`
+	// Locality registered to NRF, the PCF/UDR/UDM/BSF instances in the same locality are preferred
+	Locality string `yaml:"locality,omitempty" valid:"optional"`
+	// Use the PCF discovered from NRF when BSF holds no PCF binding of the UE, without it the request is rejected
+	PcfBindingFallback bool `yaml:"pcfBindingFallback,omitempty" valid:"optional"`
+	// SCP which the requests to NRF/PCF/UDR/UDM/BSF are sent through, without it they're sent directly
+	Scp *Scp `yaml:"scp,omitempty" valid:"optional"`
+	// HTTP client of the requests to NRF/PCF/UDR/UDM/BSF and CAPIF, without it only the default timeout applies
+	SbiClient *SbiClient `yaml:"sbiClient,omitempty" valid:"optional"`
+	// AFs notified of the vendor-specific SUBSCRIPTION_EXPIRED event, which is not defined by TS 29.522,
+	// without it no AF is notified when its subscription is removed due to expiry
+	SubscriptionExpiredNotifAfs []string `yaml:"subscriptionExpiredNotifAfs,omitempty" valid:"optional"`
 }
 
 type Logger struct {
@@ -92,6 +144,74 @@ func (c *Configuration) validate() (bool, error) {
 			return result, err
 		}
 	}
+	zoneIDs := make(map[string]struct{})
+	for i := range c.GeoZones {
+		if result, err := c.GeoZones[i].validate(); err != nil {
+			return result, err
+		}
+		if _, ok := zoneIDs[c.GeoZones[i].ZoneId]; ok {
+			err := errors.New("invalid geoZones[" + strconv.Itoa(i) + "]: duplicated zoneId " + c.GeoZones[i].ZoneId)
+			return false, appendInvalid(err)
+		}
+		zoneIDs[c.GeoZones[i].ZoneId] = struct{}{}
+	}
+	if c.PfdCachingTime < 0 {
+		err := errors.New("invalid pfdCachingTime: " + strconv.Itoa(c.PfdCachingTime) + ", should not be negative")
+		return false, appendInvalid(err)
+	}
+	serviceNames := make(map[string]struct{})
+	for i := range c.ResourceLifetimes {
+		if result, err := c.ResourceLifetimes[i].validate(); err != nil {
+			return result, err
+		}
+		if _, ok := serviceNames[c.ResourceLifetimes[i].ServiceName]; ok {
+			err := errors.New("invalid resourceLifetimes[" + strconv.Itoa(i) + "]: duplicated serviceName " +
+				c.ResourceLifetimes[i].ServiceName)
+			return false, appendInvalid(err)
+		}
+		serviceNames[c.ResourceLifetimes[i].ServiceName] = struct{}{}
+	}
+	if oauth2 := c.NorthboundOAuth2; oauth2 != nil {
+		if result, err := oauth2.validate(); err != nil {
+			return result, err
+		}
+	}
+	if capif := c.Capif; capif != nil {
+		if result, err := capif.validate(); err != nil {
+			return result, err
+		}
+	}
+	if store := c.Store; store != nil {
+		if result, err := store.validate(); err != nil {
+			return result, err
+		}
+	}
+	if reconciliation := c.Reconciliation; reconciliation != nil {
+		if result, err := reconciliation.validate(); err != nil {
+			return result, err
+		}
+	}
+	if scp := c.Scp; scp != nil {
+		if result, err := scp.validate(); err != nil {
+			return result, err
+		}
+	}
+	if sbiClient := c.SbiClient; sbiClient != nil {
+		if result, err := sbiClient.validate(); err != nil {
+			return result, err
+		}
+	}
+	afIDs := make(map[string]struct{})
+	for i := range c.AfProfiles {
+		if result, err := c.AfProfiles[i].validate(); err != nil {
+			return result, err
+		}
+		if _, ok := afIDs[c.AfProfiles[i].AfId]; ok {
+			err := errors.New("invalid afProfiles[" + strconv.Itoa(i) + "]: duplicated afId " + c.AfProfiles[i].AfId)
+			return false, appendInvalid(err)
+		}
+		afIDs[c.AfProfiles[i].AfId] = struct{}{}
+	}
 	for i, s := range c.ServiceList {
 		switch s.ServiceName {
 		case ServiceNefPfd:
@@ -131,16 +251,332 @@ func (s *Sbi) validate() (bool, error) {
 }
 
 type Service struct {
-	ServiceName string `yaml:"serviceName"`
-	SuppFeat    string `yaml:"suppFeat,omitempty"`
+	ServiceName string `yaml:"serviceName" json:"serviceName"`
+	SuppFeat    string `yaml:"suppFeat,omitempty" json:"suppFeat,omitempty"`
+}
+
+// GeoZone maps a zone ID (i.e. validGeoZoneIds of traffic influence) to the 3GPP area
+type GeoZone struct {
+	ZoneId string        `yaml:"zoneId" valid:"type(string),minstringlength(1),required"`
+	Tais   []models.Tai  `yaml:"tais,omitempty" valid:"optional"`
+	Ncgis  []models.Ncgi `yaml:"ncgis,omitempty" valid:"optional"`
+}
+
+var (
+	mccRegexp      = regexp.MustCompile(`^[0-9]{3}$`)
+	mncRegexp      = regexp.MustCompile(`^[0-9]{2,3}$`)
+	tacRegexp      = regexp.MustCompile(`^([A-Fa-f0-9]{4}|[A-Fa-f0-9]{6})$`)
+	nrCellIdRegexp = regexp.MustCompile(`^[A-Fa-f0-9]{9}$`)
+)
+
+func (g *GeoZone) validate() (bool, error) {
+	if len(g.Tais) == 0 && len(g.Ncgis) == 0 {
+		err := errors.New("invalid geoZone[" + g.ZoneId + "]: one of tais or ncgis shall be included")
+		return false, appendInvalid(err)
+	}
+	for i, tai := range g.Tais {
+		if !isValidPlmnId(tai.PlmnId) || !tacRegexp.MatchString(tai.Tac) {
+			err := errors.New("invalid geoZone[" + g.ZoneId + "].tais[" + strconv.Itoa(i) + "]")
+			return false, appendInvalid(err)
+		}
+	}
+	for i, ncgi := range g.Ncgis {
+		if !isValidPlmnId(ncgi.PlmnId) || !nrCellIdRegexp.MatchString(ncgi.NrCellId) {
+			err := errors.New("invalid geoZone[" + g.ZoneId + "].ncgis[" + strconv.Itoa(i) + "]")
+			return false, appendInvalid(err)
+		}
+	}
+	result, err := govalidator.ValidateStruct(g)
+	return result, appendInvalid(err)
+}
+
+func isValidPlmnId(plmnId *models.PlmnId) bool {
+	return plmnId != nil &&
+		mccRegexp.MatchString(plmnId.Mcc) &&
+		mncRegexp.MatchString(plmnId.Mnc)
+}
+
+// ResourceLifetime is the lifetime (in seconds) granted to the subscriptions or transactions of a service
+type ResourceLifetime struct {
+	ServiceName string `yaml:"serviceName" valid:"required"`
+	// Granted when AF doesn't request an expiry, 0 means the resource never expires
+	DefaultLifetime int `yaml:"defaultLifetime,omitempty" valid:"optional"`
+	// Upper bound of the granted lifetime, 0 means unlimited
+	MaxLifetime int `yaml:"maxLifetime,omitempty" valid:"optional"`
+}
+
+func (r *ResourceLifetime) validate() (bool, error) {
+	if r.ServiceName != ServiceTraffInflu && r.ServiceName != ServicePfdMng {
+		err := errors.New("invalid resourceLifetime[" + r.ServiceName + "]: should be " +
+			ServiceTraffInflu + " or " + ServicePfdMng)
+		return false, appendInvalid(err)
+	}
+	if r.DefaultLifetime < 0 || r.MaxLifetime < 0 {
+		err := errors.New("invalid resourceLifetime[" + r.ServiceName + "]: lifetime should not be negative")
+		return false, appendInvalid(err)
+	}
+	if r.MaxLifetime > 0 && (r.DefaultLifetime == 0 || r.DefaultLifetime > r.MaxLifetime) {
+		err := errors.New("invalid resourceLifetime[" + r.ServiceName + "]: " +
+			"defaultLifetime should be within (0, maxLifetime] when maxLifetime is set")
+		return false, appendInvalid(err)
+	}
+	result, err := govalidator.ValidateStruct(r)
+	return result, appendInvalid(err)
+}
+
+// AfProfile lists the services and the resources an AF (i.e. afId or scsAsId in the resource URI) is allowed to use.
+// An empty list of externalAppIds, dnns or snssais means the AF is not restricted on it. An AF restricted on
+// dnns or snssais has to provide them in the traffic influence, and can't provision PFDs.
+type AfProfile struct {
+	AfId           string          `yaml:"afId" valid:"type(string),minstringlength(1),required"`
+	Services       []string        `yaml:"services" valid:"required"`
+	ExternalAppIds []string        `yaml:"externalAppIds,omitempty" valid:"optional"`
+	Dnns           []string        `yaml:"dnns,omitempty" valid:"optional"`
+	Snssais        []models.Snssai `yaml:"snssais,omitempty" valid:"optional"`
+}
+
+func (a *AfProfile) validate() (bool, error) {
+	for i, s := range a.Services {
+		if s != ServiceTraffInflu && s != ServicePfdMng {
+			err := errors.New("invalid afProfile[" + a.AfId + "].services[" + strconv.Itoa(i) + "]: " +
+				s + ", should be " + ServiceTraffInflu + " or " + ServicePfdMng)
+			return false, appendInvalid(err)
+		}
+	}
+	for i, snssai := range a.Snssais {
+		if snssai.Sst < 0 || snssai.Sst > 255 {
+			err := errors.New("invalid afProfile[" + a.AfId + "].snssais[" + strconv.Itoa(i) + "]: sst should be 0-255")
+			return false, appendInvalid(err)
+		}
+	}
+	result, err := govalidator.ValidateStruct(a)
+	return result, appendInvalid(err)
+}
+
+func (a *AfProfile) AllowService(serviceName string) bool {
+	for _, s := range a.Services {
+		if s == serviceName {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *AfProfile) AllowExternalAppId(appID string) bool {
+	if len(a.ExternalAppIds) == 0 {
+		return true
+	}
+	for _, id := range a.ExternalAppIds {
+		if id == appID {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *AfProfile) AllowDnn(dnn string) bool {
+	if len(a.Dnns) == 0 {
+		return true
+	}
+	for _, d := range a.Dnns {
+		if d == dnn {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowAllDnns reports whether the AF may act on any DNN, i.e. it is not restricted on dnns
+func (a *AfProfile) AllowAllDnns() bool {
+	return len(a.Dnns) == 0
+}
+
+// AllowAllSnssais reports whether the AF may act on any S-NSSAI, i.e. it is not restricted on snssais
+func (a *AfProfile) AllowAllSnssais() bool {
+	return len(a.Snssais) == 0
+}
+
+func (a *AfProfile) AllowSnssai(snssai *models.Snssai) bool {
+	if len(a.Snssais) == 0 {
+		return true
+	}
+	for _, s := range a.Snssais {
+		if s.Sst == snssai.Sst && strings.EqualFold(s.Sd, snssai.Sd) {
+			return true
+		}
+	}
+	return false
+}
+
+// NorthboundOAuth2 validates the JWT access tokens presented by AFs on 3gpp-traffic-influence and
+// 3gpp-pfd-management. The token shall be signed by one of the public keys, be unexpired, be issued for
+// the audience, have the service name in its scope, and its subject shall be the afId/scsAsId of the request.
+// The tokens on nnef-oam are validated the same way except that the subject is not checked.
+type NorthboundOAuth2 struct {
+	// PEM files of the public keys or certificates of the token issuers, e.g. NRF or CAPIF core function
+	PublicKeys []string `yaml:"publicKeys" valid:"required"`
+	Audience   string   `yaml:"audience" valid:"type(string),minstringlength(1),required"`
+	// Expected issuer of the tokens, empty means any issuer signing with the public keys
+	Issuer string `yaml:"issuer,omitempty" valid:"optional"`
+}
+
+func (o *NorthboundOAuth2) validate() (bool, error) {
+	for i, key := range o.PublicKeys {
+		if key == "" {
+			err := errors.New("invalid northboundOAuth2.publicKeys[" + strconv.Itoa(i) + "]: empty path")
+			return false, appendInvalid(err)
+		}
+	}
+	result, err := govalidator.ValidateStruct(o)
+	return result, appendInvalid(err)
+}
+
+// Capif is the onboarding information of NEF at the CAPIF core function (TS 29.222).
+// The API invoker ID of CAPIF is taken as the afId/scsAsId of the northbound APIs.
+type Capif struct {
+	Uri string `yaml:"uri" valid:"url,required"`
+	// API publishing function ID and API exposing function ID assigned at onboarding
+	ApfId string `yaml:"apfId" valid:"type(string),minstringlength(1),required"`
+	AefId string `yaml:"aefId" valid:"type(string),minstringlength(1),required"`
+	// Interval (in seconds) of reporting the API invocation logs, 0 means the default interval
+	LogReportInterval int `yaml:"logReportInterval,omitempty" valid:"optional"`
+}
+
+func (c *Capif) validate() (bool, error) {
+	if c.LogReportInterval < 0 {
+		err := errors.New("invalid capif.logReportInterval: " + strconv.Itoa(c.LogReportInterval) +
+			", should not be negative")
+		return false, appendInvalid(err)
+	}
+	result, err := govalidator.ValidateStruct(c)
+	return result, appendInvalid(err)
+}
+
+type Store struct {
+	Type string `yaml:"type" valid:"required,in(file)"`
+	// Directory of the file store
+	Path string `yaml:"path" valid:"type(string),minstringlength(1),required"`
+}
+
+func (s *Store) validate() (bool, error) {
+	result, err := govalidator.ValidateStruct(s)
+	return result, appendInvalid(err)
+}
+
+type Reconciliation struct {
+	// Interval (in seconds) of reconciling after the one at startup, 0 means the default interval
+	Interval int `yaml:"interval,omitempty" valid:"optional"`
+	// Reinstall the missing traffic influence, otherwise the mismatches are only reported.
+	// The PFDs missing in UDR are always only reported, since NEF doesn't keep them.
+	Repair bool `yaml:"repair,omitempty" valid:"optional"`
+}
+
+func (r *Reconciliation) validate() (bool, error) {
+	if r.Interval < 0 {
+		err := errors.New("invalid reconciliation.interval: " + strconv.Itoa(r.Interval) +
+			", should not be negative")
+		return false, appendInvalid(err)
+	}
+	result, err := govalidator.ValidateStruct(r)
+	return result, appendInvalid(err)
+}
+
+type Scp struct {
+	Uri string `yaml:"uri" valid:"url,required"`
+	// Model of indirect communication: C (NEF discovers the NF instances) or D (default, SCP discovers them)
+	Model string `yaml:"model,omitempty" valid:"optional,in(C|D)"`
+}
+
+func (s *Scp) validate() (bool, error) {
+	result, err := govalidator.ValidateStruct(s)
+	return result, appendInvalid(err)
+}
+
+// DelegatedDiscovery returns true if the NF discovery is delegated to SCP (Model D)
+func (s *Scp) DelegatedDiscovery() bool {
+	return s.Model != ScpModelC
+}
+
+type SbiClient struct {
+	// Timeout (in milliseconds) of a request until its response is read, 0 means the default timeout
+	Timeout int `yaml:"timeout,omitempty" valid:"optional"`
+	// Timeouts overriding the one above for the services, e.g. nudr-dr
+	ServiceTimeouts []ServiceTimeout `yaml:"serviceTimeouts,omitempty" valid:"optional"`
+	// Retries of an idempotent request (GET/PUT/DELETE) on no response or 502/503/504, 0 means no retry
+	MaxRetries int `yaml:"maxRetries,omitempty" valid:"optional"`
+	// Backoff (in milliseconds) before the first retry, doubled on each retry with jitter
+	RetryBackoff int `yaml:"retryBackoff,omitempty" valid:"optional"`
+	// Consecutive failures of a target NF which open its circuit breaker, 0 means no circuit breaking
+	CircuitBreakerThreshold int `yaml:"circuitBreakerThreshold,omitempty" valid:"optional"`
+	// Time (in seconds) an open circuit breaker fails the requests before one is tried again
+	CircuitBreakerOpenTime int `yaml:"circuitBreakerOpenTime,omitempty" valid:"optional"`
+	// Idle connections kept to a target NF, 0 means the default of net/http
+	MaxIdleConnsPerHost int `yaml:"maxIdleConnsPerHost,omitempty" valid:"optional"`
+	// Use HTTP/2 only, with prior knowledge (h2c) on http URIs
+	Http2 bool `yaml:"http2,omitempty" valid:"optional"`
+}
+
+type ServiceTimeout struct {
+	ServiceName string `yaml:"serviceName" valid:"type(string),minstringlength(1),required"`
+	// Timeout (in milliseconds) of the requests to the service
+	Timeout int `yaml:"timeout" valid:"required"`
+}
+
+func (s *SbiClient) validate() (bool, error) {
+	if s.Timeout < 0 || s.MaxRetries < 0 || s.RetryBackoff < 0 ||
+		s.CircuitBreakerThreshold < 0 || s.CircuitBreakerOpenTime < 0 || s.MaxIdleConnsPerHost < 0 {
+		err := errors.New("invalid sbiClient: timeout, retries, backoff, circuit breaker and connections " +
+			"should not be negative")
+		return false, appendInvalid(err)
+	}
+	serviceNames := make(map[string]struct{})
+	for i, t := range s.ServiceTimeouts {
+		if t.Timeout < 0 {
+			err := errors.New("invalid sbiClient.serviceTimeouts[" + strconv.Itoa(i) + "]: timeout " +
+				strconv.Itoa(t.Timeout) + " should not be negative")
+			return false, appendInvalid(err)
+		}
+		if _, ok := serviceNames[t.ServiceName]; ok {
+			err := errors.New("invalid sbiClient.serviceTimeouts[" + strconv.Itoa(i) + "]: duplicated serviceName " +
+				t.ServiceName)
+			return false, appendInvalid(err)
+		}
+		serviceNames[t.ServiceName] = struct{}{}
+	}
+	result, err := govalidator.ValidateStruct(s)
+	return result, appendInvalid(err)
+}
+
+// ServiceTimeout returns the timeout of the requests to the service
+func (s *SbiClient) ServiceTimeout(serviceName string) time.Duration {
+	for _, t := range s.ServiceTimeouts {
+		if t.ServiceName == serviceName {
+			return time.Duration(t.Timeout) * time.Millisecond
+		}
+	}
+	if s.Timeout > 0 {
+		return time.Duration(s.Timeout) * time.Millisecond
+	}
+	return NefDefaultSbiClientTimeout
 }
 
 type Tls struct {
 	Pem string `yaml:"pem,omitempty" valid:"type(string),minstringlength(1),required"`
 	Key string `yaml:"key,omitempty" valid:"type(string),minstringlength(1),required"`
+	// PEM file of the CA certificates verifying the client certificates of AFs
+	ClientCA string `yaml:"clientCA,omitempty" valid:"optional"`
+	// Verification of the client certificates: none (default), verifyIfGiven or require
+	ClientAuth string `yaml:"clientAuth,omitempty" valid:"optional,in(none|verifyIfGiven|require)"`
+	// Field of the client certificate taken as the AF identity: cn (default), dns or uri.
+	// The AF identity shall be the afId/scsAsId of the request.
+	ClientIdentity string `yaml:"clientIdentity,omitempty" valid:"optional,in(cn|dns|uri)"`
 }
 
 func (t *Tls) validate() (bool, error) {
+	if t.ClientAuth != "" && t.ClientAuth != TlsClientAuthNone && t.ClientCA == "" {
+		err := errors.New("invalid tls: clientCA is required when clientAuth is " + t.ClientAuth)
+		return false, appendInvalid(err)
+	}
 	result, err := govalidator.ValidateStruct(t)
 	return result, err
 }
@@ -333,6 +769,40 @@ func (c *Config) NrfCertPem() string {
 	return "" // havn't setup in config
 }
 
+func (c *Config) Locality() string {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.Configuration.Locality
+}
+
+// PcfBindingFallback reports whether the PCF discovered from NRF is used when BSF holds no PCF binding of the UE
+func (c *Config) PcfBindingFallback() bool {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.Configuration.PcfBindingFallback
+}
+
+// Scp returns nil if the requests are sent to NRF/PCF/UDR/UDM/BSF directly
+func (c *Config) Scp() *Scp {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.Configuration.Scp
+}
+
+// SbiClient returns the default one if it's not configured
+func (c *Config) SbiClient() *SbiClient {
+	c.RLock()
+	defer c.RUnlock()
+
+	if c.Configuration.SbiClient != nil {
+		return c.Configuration.SbiClient
+	}
+	return &SbiClient{}
+}
+
 func (c *Config) ServiceList() []Service {
 	c.RLock()
 	defer c.RUnlock()
@@ -363,11 +833,33 @@ func (c *Config) GetCertKeyPath() string {
 	return NefDefaultPrivateKeyPath
 }
 
-func (c *Config) NFServices() []models.NrfNfManagementNfService {
+// TlsClientAuth returns the verification mode of the client certificates and the CA file verifying them
+func (c *Config) TlsClientAuth() (string, string) {
+	c.RLock()
+	defer c.RUnlock()
+
+	if tls := c.Configuration.Sbi.Tls; tls != nil && tls.ClientAuth != "" {
+		return tls.ClientAuth, tls.ClientCA
+	}
+	return TlsClientAuthNone, ""
+}
+
+func (c *Config) TlsClientIdentity() string {
+	c.RLock()
+	defer c.RUnlock()
+
+	if tls := c.Configuration.Sbi.Tls; tls != nil && tls.ClientIdentity != "" {
+		return tls.ClientIdentity
+	}
+	return TlsClientIdentityCN
+}
+
+// NFServices returns the NF services of the serviceList to be registered to NRF
+func (c *Config) NFServices(serviceList []Service) []models.NrfNfManagementNfService {
 	versions := strings.Split(c.Version(), ".")
 	majorVersionUri := "v" + versions[0]
 	nfServices := []models.NrfNfManagementNfService{}
-	for i, s := range c.ServiceList() {
+	for i, s := range serviceList {
 		nfService := models.NrfNfManagementNfService{
 			ServiceInstanceId: strconv.Itoa(i),
 			ServiceName:       models.ServiceName(s.ServiceName),
@@ -410,3 +902,101 @@ func (c *Config) ServiceUri(name string) string {
 		return ""
 	}
 }
+
+func (c *Config) GeoZone(zoneID string) (*GeoZone, bool) {
+	c.RLock()
+	defer c.RUnlock()
+
+	for i := range c.Configuration.GeoZones {
+		if c.Configuration.GeoZones[i].ZoneId == zoneID {
+			return &c.Configuration.GeoZones[i], true
+		}
+	}
+	return nil, false
+}
+
+// ResourceLifetime returns the default and the maximum lifetime of the resources of the service,
+// 0 means unlimited.
+func (c *Config) ResourceLifetime(serviceName string) (time.Duration, time.Duration) {
+	c.RLock()
+	defer c.RUnlock()
+
+	for _, r := range c.Configuration.ResourceLifetimes {
+		if r.ServiceName == serviceName {
+			return time.Duration(r.DefaultLifetime) * time.Second, time.Duration(r.MaxLifetime) * time.Second
+		}
+	}
+	return 0, 0
+}
+
+// SubscriptionExpiredNotif reports whether the AF asks for the SUBSCRIPTION_EXPIRED event
+func (c *Config) SubscriptionExpiredNotif(afID string) bool {
+	c.RLock()
+	defer c.RUnlock()
+
+	for _, id := range c.Configuration.SubscriptionExpiredNotifAfs {
+		if id == afID {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) PfdCachingTime() int32 {
+	c.RLock()
+	defer c.RUnlock()
+
+	return int32(c.Configuration.PfdCachingTime)
+}
+
+// AfAuthorizationEnabled reports whether the AFs are authorized by the configured AF profiles
+func (c *Config) AfAuthorizationEnabled() bool {
+	c.RLock()
+	defer c.RUnlock()
+
+	return len(c.Configuration.AfProfiles) > 0
+}
+
+func (c *Config) AfProfile(afID string) (*AfProfile, bool) {
+	c.RLock()
+	defer c.RUnlock()
+
+	for i := range c.Configuration.AfProfiles {
+		if c.Configuration.AfProfiles[i].AfId == afID {
+			return &c.Configuration.AfProfiles[i], true
+		}
+	}
+	return nil, false
+}
+
+// NorthboundOAuth2 returns nil if the access tokens of AFs are not validated
+func (c *Config) NorthboundOAuth2() *NorthboundOAuth2 {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.Configuration.NorthboundOAuth2
+}
+
+// Capif returns nil if NEF doesn't onboard to CAPIF
+func (c *Config) Capif() *Capif {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.Configuration.Capif
+}
+
+// Store returns nil if the state of NEF is not persisted
+func (c *Config) Store() *Store {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.Configuration.Store
+}
+
+// Reconciliation returns nil if neither the interval nor the repair is configured
+func (c *Config) Reconciliation() *Reconciliation {
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.Configuration.Reconciliation
+}
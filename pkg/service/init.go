@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"runtime/debug"
 	"sync"
+	"time"
 
 	nef_context "github.com/free5gc/nef/internal/context"
 	"github.com/free5gc/nef/internal/logger"
@@ -58,7 +60,7 @@ func NewApp(
 	if nef.consumer, err = consumer.NewConsumer(nef); err != nil {
 		return nil, err
 	}
-	if nef.notifier, err = notifier.NewNotifier(); err != nil {
+	if nef.notifier, err = notifier.NewNotifier(nef.consumer.DirectHTTPClient()); err != nil {
 		return nil, err
 	}
 	if nef.proc, err = processor.NewProcessor(nef); err != nil {
@@ -156,6 +158,59 @@ func (a *NefApp) registerToNrf(ctx context.Context) error {
 	return nil
 }
 
+// runNrfHeartbeat sends heartbeat to NRF every heartBeatTimer until NEF is terminated,
+// otherwise NRF marks NEF SUSPENDED.
+func (a *NefApp) runNrfHeartbeat() {
+	a.wg.Add(1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				// Print stack for panic to log. Fatalf() will let program exit.
+				logger.MainLog.Fatalf("panic: %v\n%s", p, string(debug.Stack()))
+			}
+			a.wg.Done()
+		}()
+
+		timer := time.NewTimer(a.nefCtx.HeartBeatTimer())
+		defer timer.Stop()
+		for {
+			select {
+			case <-a.ctx.Done():
+				logger.MainLog.Infoln("NRF heartbeat is stopped")
+				return
+			case <-timer.C:
+				a.sendNrfHeartbeat()
+				// heartBeatTimer may be changed by NRF
+				timer.Reset(a.nefCtx.HeartBeatTimer())
+			}
+		}
+	}()
+}
+
+// subscribeNfStatus lets the cached URIs of PCF/UDR/UDM/BSF be evicted when they're deregistered or changed
+func (a *NefApp) subscribeNfStatus() {
+	if err := a.proc.SubscribeNfStatus(); err != nil {
+		logger.MainLog.Errorf("subscribe to NF status failed: %+v", err)
+	}
+}
+
+func (a *NefApp) sendNrfHeartbeat() {
+	pd, err := a.consumer.SendHeartbeat()
+	switch {
+	case pd != nil && pd.Status == http.StatusNotFound:
+		logger.MainLog.Warnln("NEF is not registered in NRF, register again")
+		if err = a.registerToNrf(a.ctx); err != nil {
+			logger.MainLog.Errorf("register to NRF failed: %+v", err)
+			return
+		}
+		a.subscribeNfStatus()
+	case pd != nil:
+		logger.MainLog.Errorf("heartbeat to NRF failed: %+v", pd)
+	case err != nil:
+		logger.MainLog.Errorf("heartbeat to NRF failed: %+v", err)
+	}
+}
+
 func (a *NefApp) Start() error {
 	a.wg.Add(1)
 	/* Go Routine is spawned here for listening for cancellation event on
@@ -166,13 +221,24 @@ func (a *NefApp) Start() error {
 		return err
 	}
 
+	a.nefCtx.RunTempValidityScheduler(a.ctx, &a.wg, a.proc)
+	a.nefCtx.RunExpiryReaper(a.ctx, &a.wg, a.proc)
+	a.nefCtx.RunInvocationLogReporter(a.ctx, &a.wg, a.proc)
+
 	err := a.registerToNrf(a.ctx)
 	if err != nil {
 		logger.MainLog.Errorf("register to NRF failed: %+v", err)
 	} else {
 		logger.MainLog.Infoln("register to NRF successfully")
+		a.subscribeNfStatus()
+		a.runNrfHeartbeat()
 	}
 
+	a.nefCtx.RunServiceAPIPublisher(a.ctx, &a.wg, a.proc)
+
+	// UDR/PCF are discovered from NRF after registration
+	a.nefCtx.RunReconciler(a.ctx, &a.wg, a.proc)
+
 	a.WaitRoutineStopped()
 	return nil
 }
@@ -198,6 +264,9 @@ func (a *NefApp) terminateProcedure() {
 		a.sbiServer.Terminate()
 	}
 
+	a.proc.UnpublishServiceAPIs()
+	a.proc.UnsubscribeNfStatus()
+
 	// deregister with NRF
 	if _, err := a.consumer.DeregisterNFInstance(); err != nil {
 		logger.MainLog.Error(err)